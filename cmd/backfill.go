@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/roll"
+)
+
+// backfillFlags are the flags configuring how backfills are run, shared by
+// the commands that run backfills
+type backfillFlags struct {
+	batchSize  int
+	batchDelay time.Duration
+	throttle   migrations.ThrottleConfig
+	workers    int
+}
+
+func (f *backfillFlags) register(flags *pflag.FlagSet) {
+	flags.IntVar(&f.batchSize, "backfill-batch-size", migrations.DefaultBackfillBatchSize, "Number of rows updated by each backfill batch")
+	flags.DurationVar(&f.batchDelay, "backfill-batch-delay", 0, "Time to wait between two backfill batches")
+	flags.IntVar(&f.workers, "backfill-workers", 1, "Number of partitions of a table backfilled concurrently")
+	flags.DurationVar(&f.throttle.MaxReplicationLag, "backfill-max-replication-lag", 0, "Replication lag above which backfills slow down and pause (0 to ignore replication lag)")
+	flags.IntVar(&f.throttle.MaxWaitingLocks, "backfill-max-waiting-locks", 0, "Number of waiting lock requests above which backfills slow down and pause (0 to ignore waiting locks)")
+	flags.DurationVar(&f.throttle.MaxBatchDuration, "backfill-max-batch-duration", 0, "Backfill batch duration above which backfills slow down (0 to ignore batch durations)")
+	flags.DurationVar(&f.throttle.MaxDelay, "backfill-max-delay", 0, "Longest delay between two backfill batches when slowing down (default 10s)")
+}
+
+func (f *backfillFlags) options() []roll.Option {
+	return []roll.Option{
+		roll.WithBackfillBatchSize(f.batchSize),
+		roll.WithBackfillBatchDelay(f.batchDelay),
+		roll.WithBackfillThrottle(f.throttle),
+		roll.WithBackfillWorkers(f.workers),
+	}
+}
+
+func backfillCmd() *cobra.Command {
+	var backfill backfillFlags
+
+	backfillCmd := &cobra.Command{
+		Use:   "backfill",
+		Short: "Run the pending backfills of the active migration",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := NewRoll(cmd.Context(), backfill.options()...)
+			if err != nil {
+				return err
+			}
+			defer m.Close()
+
+			progress := newStartProgress("Running backfills...")
+
+			err = m.Backfill(cmd.Context(), progress.update)
+			sp := progress.result()
+			if err != nil {
+				if errors.Is(err, context.Canceled) {
+					sp.Warning("Backfill interrupted. Run `pgroll backfill` again to resume it")
+					return err
+				}
+				sp.Fail(fmt.Sprintf("Failed to run backfills: %s", err))
+				return err
+			}
+
+			sp.Success("Backfills complete. The migration can now be completed")
+			return nil
+		},
+	}
+
+	backfill.register(backfillCmd.Flags())
+
+	return backfillCmd
+}
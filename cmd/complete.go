@@ -7,26 +7,43 @@ import (
 
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/roll"
 )
 
-var completeCmd = &cobra.Command{
-	Use:   "complete <file>",
-	Short: "Complete an ongoing migration with the operations present in the given file",
-	RunE: func(cmd *cobra.Command, args []string) error {
-		m, err := NewRoll(cmd.Context())
-		if err != nil {
-			return err
-		}
-		defer m.Close()
-
-		sp, _ := pterm.DefaultSpinner.WithText("Completing migration...").Start()
-		err = m.Complete(cmd.Context())
-		if err != nil {
-			sp.Fail(fmt.Sprintf("Failed to complete migration: %s", err))
-			return err
-		}
-
-		sp.Success("Migration successful!")
-		return nil
-	},
+func completeCmd() *cobra.Command {
+	var verify bool
+	var verifyConfig migrations.VerifyConfig
+
+	completeCmd := &cobra.Command{
+		Use:   "complete <file>",
+		Short: "Complete an ongoing migration with the operations present in the given file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var opts []roll.Option
+			if verify {
+				opts = append(opts, roll.WithCompleteVerification(verifyConfig))
+			}
+
+			m, err := NewRoll(cmd.Context(), opts...)
+			if err != nil {
+				return err
+			}
+			defer m.Close()
+
+			sp, _ := pterm.DefaultSpinner.WithText("Completing migration...").Start()
+			err = m.Complete(cmd.Context())
+			if err != nil {
+				sp.Fail(fmt.Sprintf("Failed to complete migration: %s", err))
+				return err
+			}
+
+			sp.Success("Migration successful!")
+			return nil
+		},
+	}
+
+	completeCmd.Flags().BoolVar(&verify, "verify", false, "Refuse to complete the migration unless its up columns match their up expressions")
+	registerVerifyFlags(completeCmd.Flags(), "verify-", &verifyConfig)
+
+	return completeCmd
 }
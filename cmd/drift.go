@@ -0,0 +1,43 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/xataio/pgroll/cmd/flags"
+	"github.com/xataio/pgroll/pkg/state"
+
+	"github.com/spf13/cobra"
+)
+
+var driftCmd = &cobra.Command{
+	Use:   "drift",
+	Short: "Report differences between the schema recorded in the migration history and the live schema",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx := cmd.Context()
+		state, err := state.New(ctx, flags.PostgresURL(), flags.StateSchema())
+		if err != nil {
+			return err
+		}
+		defer state.Close()
+
+		diffs, err := state.Drift(ctx, flags.Schema())
+		if err != nil {
+			return err
+		}
+
+		diffsJSON, err := json.MarshalIndent(diffs, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(diffsJSON))
+
+		if len(diffs) > 0 {
+			return fmt.Errorf("schema %q has drifted from its migration history: %d differences found", flags.Schema(), len(diffs))
+		}
+		return nil
+	},
+}
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/xataio/pgroll/cmd/flags"
+	"github.com/xataio/pgroll/pkg/state"
+
+	"github.com/spf13/cobra"
+)
+
+func historyCmd() *cobra.Command {
+	var all bool
+
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show the migration history of the schema",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+			state, err := state.New(ctx, flags.PostgresURL(), flags.StateSchema())
+			if err != nil {
+				return err
+			}
+			defer state.Close()
+
+			history, err := state.History(ctx, flags.Schema(), all)
+			if err != nil {
+				return err
+			}
+
+			historyJSON, err := json.MarshalIndent(history, "", "  ")
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(historyJSON))
+			return nil
+		},
+	}
+
+	historyCmd.Flags().BoolVar(&all, "all", false, "Include failed and rolled back migrations")
+
+	return historyCmd
+}
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pterm/pterm"
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+// startProgress shows the progress of starting a migration: a spinner,
+// replaced by a progress bar while a table is being backfilled.
+type startProgress struct {
+	text    string
+	spinner *pterm.SpinnerPrinter
+	bar     *pterm.ProgressbarPrinter
+}
+
+func newStartProgress(text string) *startProgress {
+	sp, _ := pterm.DefaultSpinner.WithText(text).Start()
+	return &startProgress{text: text, spinner: sp}
+}
+
+// update renders the progress of a backfill
+func (s *startProgress) update(p migrations.BackfillProgress) {
+	if s.bar == nil {
+		_ = s.spinner.Stop()
+		s.bar, _ = pterm.DefaultProgressbar.
+			WithTotal(int(max(p.Total, 1))).
+			WithShowCount(true).
+			WithRemoveWhenDone(false).
+			Start(backfillTitle(p))
+	}
+
+	// The total is only an estimate: keep the bar running until the backfill
+	// is actually done, even if the estimate turns out to be too low.
+	total := max(p.Total, p.Rows+1)
+	if p.Done {
+		total = max(p.Rows, 1)
+	}
+	s.bar.Total = int(total)
+	s.bar.UpdateTitle(backfillTitle(p))
+	s.bar.Add(int(p.Rows) - s.bar.Current)
+
+	if p.Done {
+		_, _ = s.bar.Stop()
+		s.bar = nil
+		s.spinner, _ = pterm.DefaultSpinner.WithText(s.text).Start()
+	}
+}
+
+// result stops any progress bar and returns the spinner, to report the
+// outcome of the command
+func (s *startProgress) result() *pterm.SpinnerPrinter {
+	if s.bar != nil {
+		_, _ = s.bar.Stop()
+		s.bar = nil
+	}
+	return s.spinner
+}
+
+func backfillTitle(p migrations.BackfillProgress) string {
+	title := fmt.Sprintf("Backfilling %s", p.Table)
+	if p.Operation != "" {
+		title += fmt.Sprintf(" (%s)", p.Operation)
+	}
+	if p.RowsPerSecond > 0 {
+		title += fmt.Sprintf(" | %.0f rows/s", p.RowsPerSecond)
+	}
+	if p.ETA > 0 {
+		title += fmt.Sprintf(" | ETA %s", p.ETA.Round(time.Second))
+	}
+	return title
+}
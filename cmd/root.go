@@ -38,7 +38,7 @@ var rootCmd = &cobra.Command{
 	Version:      Version,
 }
 
-func NewRoll(ctx context.Context) (*roll.Roll, error) {
+func NewRoll(ctx context.Context, opts ...roll.Option) (*roll.Roll, error) {
 	pgURL := flags.PostgresURL()
 	schema := flags.Schema()
 	stateSchema := flags.StateSchema()
@@ -50,21 +50,31 @@ func NewRoll(ctx context.Context) (*roll.Roll, error) {
 		return nil, err
 	}
 
-	return roll.New(ctx, pgURL, schema, state,
+	opts = append([]roll.Option{
 		roll.WithLockTimeoutMs(lockTimeout),
 		roll.WithRole(role),
-	)
+	}, opts...)
+
+	return roll.New(ctx, pgURL, schema, state, opts...)
 }
 
 // Execute executes the root command.
 func Execute() error {
 	// register subcommands
 	rootCmd.AddCommand(startCmd())
-	rootCmd.AddCommand(completeCmd)
+	rootCmd.AddCommand(backfillCmd())
+	rootCmd.AddCommand(completeCmd())
 	rootCmd.AddCommand(rollbackCmd)
 	rootCmd.AddCommand(analyzeCmd)
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(historyCmd())
+	rootCmd.AddCommand(driftCmd)
+	rootCmd.AddCommand(verifyCmd())
+
+	// cancel the context of the running command on SIGINT or SIGTERM
+	ctx, stop := withSignalHandling(context.Background())
+	defer stop()
 
-	return rootCmd.Execute()
+	return rootCmd.ExecuteContext(ctx)
 }
@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// withSignalHandling returns a context that is cancelled on the first SIGINT
+// or SIGTERM. Cancelling the context lets a running migration stop gracefully:
+// the current backfill batch is finished and the migration is rolled back or
+// kept in progress. A second signal aborts the process immediately.
+func withSignalHandling(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-sigs:
+			fmt.Fprintln(os.Stderr, "\nInterrupted, stopping gracefully. Interrupt again to abort immediately.")
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+
+		<-sigs
+		fmt.Fprintln(os.Stderr, "\nAborted.")
+		os.Exit(130)
+	}()
+
+	return ctx, func() {
+		signal.Stop(sigs)
+		cancel()
+	}
+}
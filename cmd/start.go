@@ -3,12 +3,13 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"github.com/pterm/pterm"
 	"github.com/xataio/pgroll/cmd/flags"
 	"github.com/xataio/pgroll/pkg/migrations"
 	"github.com/xataio/pgroll/pkg/roll"
@@ -18,6 +19,9 @@ import (
 
 func startCmd() *cobra.Command {
 	var complete bool
+	var keepOnInterrupt bool
+	var noBackfill bool
+	var backfill backfillFlags
 
 	startCmd := &cobra.Command{
 		Use:   "start <file>",
@@ -26,7 +30,15 @@ func startCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			fileName := args[0]
 
-			m, err := NewRoll(cmd.Context())
+			opts := backfill.options()
+			if keepOnInterrupt {
+				opts = append(opts, roll.WithKeepOnInterrupt())
+			}
+			if noBackfill {
+				opts = append(opts, roll.WithDeferredBackfill())
+			}
+
+			m, err := NewRoll(cmd.Context(), opts...)
 			if err != nil {
 				return err
 			}
@@ -43,13 +55,19 @@ func startCmd() *cobra.Command {
 				return fmt.Errorf("reading migration file: %w", err)
 			}
 
-			sp, _ := pterm.DefaultSpinner.WithText("Starting migration...").Start()
-			cb := func(n int64) {
-				sp.UpdateText(fmt.Sprintf("%d records complete...", n))
-			}
+			progress := newStartProgress("Starting migration...")
 
-			err = m.Start(cmd.Context(), migration, cb)
+			err = m.Start(cmd.Context(), migration, progress.update)
+			sp := progress.result()
 			if err != nil {
+				if errors.Is(err, context.Canceled) {
+					if keepOnInterrupt {
+						sp.Warning(fmt.Sprintf("Migration %q interrupted and kept in progress. Run `pgroll start %s` again to resume it, or `pgroll rollback` to revert it", migration.Name, fileName))
+					} else {
+						sp.Warning(fmt.Sprintf("Migration %q interrupted and rolled back", migration.Name))
+					}
+					return err
+				}
 				sp.Fail(fmt.Sprintf("Failed to start migration: %s", err))
 				return err
 			}
@@ -64,6 +82,9 @@ func startCmd() *cobra.Command {
 			version := strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
 			viewName := roll.VersionedSchemaName(flags.Schema(), version)
 			msg := fmt.Sprintf("New version of the schema available under the postgres %q schema", viewName)
+			if noBackfill {
+				msg += ". Run `pgroll backfill` to backfill existing rows before completing the migration"
+			}
 			sp.Success(msg)
 
 			return nil
@@ -71,6 +92,10 @@ func startCmd() *cobra.Command {
 	}
 
 	startCmd.Flags().BoolVarP(&complete, "complete", "c", false, "Mark the migration as complete")
+	startCmd.Flags().BoolVar(&keepOnInterrupt, "keep-on-interrupt", false, "Keep the migration in progress instead of rolling it back when interrupted, so that it can be resumed")
+	startCmd.Flags().BoolVar(&noBackfill, "no-backfill", false, "Defer the backfills of the migration, to be run with the backfill command")
+	startCmd.MarkFlagsMutuallyExclusive("complete", "no-backfill")
+	backfill.register(startCmd.Flags())
 
 	return startCmd
 }
@@ -3,27 +3,33 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 
+	"github.com/pterm/pterm"
 	"github.com/xataio/pgroll/cmd/flags"
+	"github.com/xataio/pgroll/pkg/migrations"
 	"github.com/xataio/pgroll/pkg/state"
 
 	"github.com/spf13/cobra"
 )
 
 var statusCmd = &cobra.Command{
-	Use:   "status",
+	Use:   "status [file...]",
 	Short: "Show pgroll status",
-	RunE: func(cmd *cobra.Command, _ []string) error {
+	Long:  "Show pgroll status. Warns about any of the given migration files that were modified since they were started.",
+	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
-		state, err := state.New(ctx, flags.PostgresURL(), flags.StateSchema())
+		st, err := state.New(ctx, flags.PostgresURL(), flags.StateSchema())
 		if err != nil {
 			return err
 		}
-		defer state.Close()
+		defer st.Close()
 
-		status, err := state.Status(ctx, flags.Schema())
+		status, err := st.Status(ctx, flags.Schema())
 		if err != nil {
 			return err
 		}
@@ -34,6 +40,35 @@ var statusCmd = &cobra.Command{
 		}
 
 		fmt.Println(string(statusJSON))
+
+		for _, fileName := range args {
+			err := verifyMigrationFile(ctx, st, fileName)
+			var mismatch state.ChecksumMismatchError
+			if errors.As(err, &mismatch) {
+				pterm.Warning.WithWriter(os.Stderr).Printfln("%s: %s", fileName, err)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
 		return nil
 	},
 }
+
+// verifyMigrationFile checks that the migration in the given file was not
+// modified since it was started
+func verifyMigrationFile(ctx context.Context, st *state.State, fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("opening migration file: %w", err)
+	}
+	defer file.Close()
+
+	migration, err := migrations.ReadMigration(file)
+	if err != nil {
+		return fmt.Errorf("reading migration file: %w", err)
+	}
+
+	return st.VerifyChecksum(ctx, flags.Schema(), migration)
+}
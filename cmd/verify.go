@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// registerVerifyFlags registers the flags configuring how the up columns of a
+// migration are verified, with the given prefix
+func registerVerifyFlags(flags *pflag.FlagSet, prefix string, cfg *migrations.VerifyConfig) {
+	flags.Float64Var(&cfg.SamplePercent, prefix+"sample-percent", 0, "Percentage of the pages of each table to verify (0 to verify all rows)")
+	flags.IntVar(&cfg.MaxMismatches, prefix+"max-mismatches", migrations.DefaultVerifyMaxMismatches, "Maximum number of mismatching rows reported for each column")
+}
+
+func verifyCmd() *cobra.Command {
+	var cfg migrations.VerifyConfig
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify that the columns filled by the up expressions of the active migration match them",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			m, err := NewRoll(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer m.Close()
+
+			results, err := m.Verify(cmd.Context(), &cfg)
+			if err != nil {
+				return err
+			}
+
+			resultsJSON, err := json.MarshalIndent(results, "", "  ")
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(resultsJSON))
+
+			var mismatches int64
+			for _, r := range results {
+				mismatches += r.Mismatches
+			}
+			if mismatches > 0 {
+				return fmt.Errorf("%d rows don't match the up expressions of the active migration", mismatches)
+			}
+			return nil
+		},
+	}
+
+	registerVerifyFlags(verifyCmd.Flags(), "", &cfg)
+
+	return verifyCmd
+}
@@ -7,97 +7,623 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/lib/pq"
 	"github.com/xataio/pgroll/pkg/schema"
 )
 
+// DefaultBackfillBatchSize is the number of rows updated by each batch of a
+// backfill, unless configured otherwise
+const DefaultBackfillBatchSize = 1000
+
+// BackfillConfig configures how backfills are run
+type BackfillConfig struct {
+	// BatchSize is the number of rows updated by each batch
+	BatchSize int
+
+	// BatchDelay is the time to wait between two batches
+	BatchDelay time.Duration
+
+	// Throttle configures how the pace of backfills adapts to the health of
+	// the database
+	Throttle ThrottleConfig
+
+	// Workers is the number of partitions of a table that are backfilled
+	// concurrently, each on its own connection
+	Workers int
+
+	// Checkpoints persists the progress of backfills, so that an interrupted
+	// backfill resumes where it stopped. Progress is not persisted when nil.
+	Checkpoints BackfillCheckpointer
+
+	// Defer skips backfills, only recording them as pending in Checkpoints so
+	// that they can be run later with `Backfill`
+	Defer bool
+}
+
+// BackfillCheckpoint records the progress of the backfill of one partition of
+// a table.
+// For tables backfilled by ranges of pages, keys are page numbers: `LastKey`
+// is the first page left to backfill and `UpperKey` the page before which the
+// partition ends.
+type BackfillCheckpoint struct {
+	// LastKey is the last key backfilled in the partition, or the exclusive
+	// lower bound of the partition if none was backfilled yet. Nil if
+	// unbounded.
+	LastKey []*string
+
+	// UpperKey is the inclusive upper bound of the partition. Nil if
+	// unbounded.
+	UpperKey []*string
+
+	// Done is true once the whole partition has been backfilled
+	Done bool
+}
+
+// BackfillCheckpointer persists the checkpoints of the backfills run by an
+// operation, and whether each of them has finished
+type BackfillCheckpointer interface {
+	// SaveBackfill records whether the backfill of the given table has
+	// finished. Backfills that haven't finished are pending.
+	SaveBackfill(ctx context.Context, table string, done bool) error
+
+	// LoadCheckpoints returns the checkpoints of all the partitions of the
+	// given table, ordered by partition, or nil if the backfill of the table
+	// has not started yet
+	LoadCheckpoints(ctx context.Context, table string) ([]BackfillCheckpoint, error)
+
+	// SaveCheckpoint saves the checkpoint of the given partition of the table
+	SaveCheckpoint(ctx context.Context, table string, partition int, checkpoint BackfillCheckpoint) error
+}
+
+// NewBackfillConfig returns a backfill config with the default settings
+func NewBackfillConfig() *BackfillConfig {
+	return &BackfillConfig{
+		BatchSize: DefaultBackfillBatchSize,
+	}
+}
+
+// WithSettings returns a copy of the config, overridden by the settings
+// given in a migration
+func (c *BackfillConfig) WithSettings(settings *BackfillSettings) *BackfillConfig {
+	cfg := *c
+	if settings == nil {
+		return &cfg
+	}
+	if settings.BatchSize != nil {
+		cfg.BatchSize = *settings.BatchSize
+	}
+	if settings.BatchDelayMs != nil {
+		cfg.BatchDelay = time.Duration(*settings.BatchDelayMs) * time.Millisecond
+	}
+	if settings.Workers != nil {
+		cfg.Workers = *settings.Workers
+	}
+	return &cfg
+}
+
+// partitions returns the partitions of the table saved by a previous attempt
+// to backfill it or, the first time the table is backfilled, the partitions
+// returned by `split`, which are then saved.
+func (c *BackfillConfig) partitions(ctx context.Context, table string, split func() ([]BackfillCheckpoint, error)) ([]BackfillCheckpoint, error) {
+	if c != nil && c.Checkpoints != nil {
+		partitions, err := c.Checkpoints.LoadCheckpoints(ctx, table)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load backfill checkpoints: %w", err)
+		}
+		if len(partitions) > 0 {
+			return partitions, nil
+		}
+	}
+
+	partitions, err := split()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, p := range partitions {
+		if err := c.saveCheckpoint(ctx, table, i, p); err != nil {
+			return nil, err
+		}
+	}
+
+	return partitions, nil
+}
+
+// saveCheckpoint saves the checkpoint of a partition, if checkpoints are
+// enabled. The checkpoint is saved even if the context is cancelled, so that
+// an interrupted backfill doesn't lose the progress of its last batch.
+func (c *BackfillConfig) saveCheckpoint(ctx context.Context, table string, partition int, checkpoint BackfillCheckpoint) error {
+	if c == nil || c.Checkpoints == nil {
+		return nil
+	}
+
+	err := c.Checkpoints.SaveCheckpoint(context.WithoutCancel(ctx), table, partition, checkpoint)
+	if err != nil {
+		return fmt.Errorf("unable to save backfill checkpoint: %w", err)
+	}
+	return nil
+}
+
+// saveBackfill records whether the backfill of a table has finished, if
+// checkpoints are enabled
+func (c *BackfillConfig) saveBackfill(ctx context.Context, table string, done bool) error {
+	if c == nil || c.Checkpoints == nil {
+		return nil
+	}
+
+	if err := c.Checkpoints.SaveBackfill(ctx, table, done); err != nil {
+		return fmt.Errorf("unable to save backfill: %w", err)
+	}
+	return nil
+}
+
+// workers returns the configured number of workers, or 1
+func (c *BackfillConfig) workers() int {
+	if c == nil || c.Workers <= 1 {
+		return 1
+	}
+	return c.Workers
+}
+
+// batchSize returns the configured batch size, or the default one
+func (c *BackfillConfig) batchSize() int {
+	if c == nil || c.BatchSize <= 0 {
+		return DefaultBackfillBatchSize
+	}
+	return c.BatchSize
+}
+
 // Backfill updates all rows in the given table, in batches, using the
 // following algorithm:
-// 1. Get the primary key column for the table.
-// 2. Get the first batch of rows from the table, ordered by the primary key.
-// 3. Update each row in the batch, setting the value of the primary key column to itself.
+// 1. Get a unique key for the table (see `backfillKey`).
+// 2. Get the first batch of rows from the table, ordered by the key.
+// 3. Update each row in the batch, setting the value of the first key column to itself.
 // 4. Repeat steps 2 and 3 until no more rows are returned.
-func backfill(ctx context.Context, conn *sql.DB, table *schema.Table, cbs ...CallbackFn) error {
-	// Get the primary key column for the table
-	pks := table.GetPrimaryKey()
-	if len(pks) != 1 {
-		return errors.New("table must have a single primary key column")
+//
+// Composite keys are paginated using row value comparisons on all of their
+// columns. Tables without a usable key are backfilled by ranges of pages
+// instead (see `backfillByCtid`).
+//
+// With several workers, the key range (or the pages) of the table is split in
+// as many partitions, which are backfilled concurrently (see `runWorkers`).
+//
+// Batches are sized, and spaced out, according to the given config. Between
+// batches, the backfill may slow down or pause depending on the load of the
+// database (see `throttler`). Progress is reported to the callbacks after
+// each batch (see `BackfillProgress`).
+//
+// If the context is cancelled, the backfill stops once the current batch has
+// been committed and returns the context's error.
+//
+// The backfill is recorded as pending until it finishes. Deferred backfills
+// are only recorded, to be run later with `Backfill`. A failed backfill
+// returns a `BackfillError`.
+func backfill(ctx context.Context, conn *sql.DB, table *schema.Table, bf *BackfillConfig, cbs ...CallbackFn) error {
+	if err := bf.saveBackfill(ctx, table.Name, false); err != nil {
+		return err
+	}
+	if bf != nil && bf.Defer {
+		return nil
 	}
-	pk := pks[0]
 
-	// Create a batcher for the table.
-	b := batcher{
-		table:     table,
-		pkColumn:  pk,
-		lastPK:    nil,
-		batchSize: 1000,
+	if err := runBackfill(ctx, conn, table, bf, cbs...); err != nil {
+		return BackfillError{Table: table.Name, Err: err}
 	}
 
-	// Update each batch of rows, invoking callbacks for each one.
-	for batch := 0; ; batch++ {
-		for _, cb := range cbs {
-			cb(int64(batch * b.batchSize))
+	return bf.saveBackfill(ctx, table.Name, true)
+}
+
+// Backfill runs the backfill of a table that was deferred when the operation
+// requiring it was started
+func Backfill(ctx context.Context, conn *sql.DB, table *schema.Table, bf *BackfillConfig, cbs ...CallbackFn) error {
+	if bf != nil && bf.Defer {
+		cfg := *bf
+		cfg.Defer = false
+		bf = &cfg
+	}
+
+	return backfill(ctx, conn, table, bf, cbs...)
+}
+
+func runBackfill(ctx context.Context, conn *sql.DB, table *schema.Table, bf *BackfillConfig, cbs ...CallbackFn) error {
+	// Get the key columns for the table
+	key := backfillKey(table)
+	if key == nil {
+		return backfillByCtid(ctx, conn, table, bf, cbs...)
+	}
+
+	// Split the key range between the workers, or resume the partitions of
+	// a previous attempt
+	partitions, err := bf.partitions(ctx, table.Name, func() ([]BackfillCheckpoint, error) {
+		if bf.workers() == 1 {
+			return []BackfillCheckpoint{{}}, nil
 		}
+		partitions, err := keyPartitions(ctx, conn, table, key, bf.workers())
+		if err != nil {
+			return nil, fmt.Errorf("unable to partition table %q: %w", table.Name, err)
+		}
+		return partitions, nil
+	})
+	if err != nil {
+		return err
+	}
 
-		if err := b.updateBatch(ctx, conn); err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
+	p, err := newProgress(ctx, conn, table.Name, cbs)
+	if err != nil {
+		return err
+	}
+
+	err = runWorkers(ctx, len(partitions), func(ctx context.Context, worker int) error {
+		if partitions[worker].Done {
+			return nil
+		}
+
+		// Create a batcher for the partition of the table.
+		b := batcher{
+			table:      table,
+			keyColumns: key,
+			lastKey:    partitions[worker].LastKey,
+			upperKey:   partitions[worker].UpperKey,
+			batchSize:  bf.batchSize(),
+		}
+
+		// Save the progress of the partition after each batch
+		checkpoint := func(done bool) error {
+			return bf.saveCheckpoint(ctx, table.Name, worker, BackfillCheckpoint{
+				LastKey:  b.lastKey,
+				UpperKey: b.upperKey,
+				Done:     done,
+			})
+		}
+
+		return b.run(ctx, conn, newThrottler(conn, bf), p, checkpoint)
+	})
+	if err != nil {
+		return err
+	}
+
+	p.done()
+	return nil
+}
+
+// backfillKey returns the columns of a unique key that can be used to
+// paginate through the rows of the table. This is the primary key if there is
+// one, otherwise the unique constraint or unique index with the fewest columns
+// whose columns are all NOT NULL. Partial indexes and indexes on expressions
+// don't cover every row and are not considered.
+// It returns nil if the table has no such key.
+func backfillKey(table *schema.Table) []*schema.Column {
+	if pks := table.GetPrimaryKey(); len(pks) > 0 {
+		return pks
+	}
+
+	type candidate struct {
+		name    string
+		columns []string
+	}
+	var candidates []candidate
+	for _, uc := range table.UniqueConstraints {
+		candidates = append(candidates, candidate{name: uc.Name, columns: uc.Columns})
+	}
+	for _, idx := range table.Indexes {
+		if idx.Unique && idx.Predicate == nil {
+			candidates = append(candidates, candidate{name: idx.Name, columns: idx.Columns})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if len(candidates[i].columns) != len(candidates[j].columns) {
+			return len(candidates[i].columns) < len(candidates[j].columns)
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	for _, c := range candidates {
+		if key := notNullColumns(table, c.columns); key != nil {
+			return key
+		}
+	}
+
+	return nil
+}
+
+// notNullColumns returns the columns of the table with the given names, or nil
+// if any of them is nullable or is not a column of the table.
+func notNullColumns(table *schema.Table, names []string) []*schema.Column {
+	if len(names) == 0 {
+		return nil
+	}
+
+	columns := make([]*schema.Column, 0, len(names))
+	for _, name := range names {
+		var found *schema.Column
+		for _, col := range table.Columns {
+			if col.Name == name {
+				c := col
+				found = &c
 				break
 			}
+		}
+		if found == nil || found.Nullable {
+			return nil
+		}
+		columns = append(columns, found)
+	}
+
+	return columns
+}
+
+// backfillByCtid updates all rows of a table that has no usable unique key.
+// Rows are updated in batches of pages, identified by the physical location
+// (ctid) of the rows. Only the pages that exist when the backfill starts are
+// scanned: rows written after that have already gone through the triggers set
+// up by the operation.
+// A row moved to a later page by a concurrent update may be updated twice,
+// which is harmless.
+func backfillByCtid(ctx context.Context, conn *sql.DB, table *schema.Table, bf *BackfillConfig, cbs ...CallbackFn) error {
+	// Get the current number of pages of the table and an estimate of the
+	// number of rows per page, to size the batches.
+	var pages, rowsPerPage int64
+	err := conn.QueryRowContext(ctx, `
+		SELECT
+			pg_relation_size(c.oid) / current_setting('block_size')::int,
+			CASE WHEN c.relpages > 0 AND c.reltuples > 0 THEN ceil(c.reltuples / c.relpages)::bigint ELSE 0 END
+		FROM pg_catalog.pg_class c
+		WHERE c.oid = to_regclass($1)`,
+		pq.QuoteIdentifier(table.Name)).Scan(&pages, &rowsPerPage)
+	if err != nil {
+		return err
+	}
+	if rowsPerPage == 0 {
+		// the table has never been analyzed, assume small rows
+		rowsPerPage = 100
+	}
+	pagesPerBatch := max(1, int64(bf.batchSize())/rowsPerPage)
+
+	// Any column can be set to itself to fire the triggers
+	var column string
+	for _, col := range table.Columns {
+		if column == "" || col.Name < column {
+			column = col.Name
+		}
+	}
+
+	// Split the pages between the workers, or resume the partitions of a
+	// previous attempt
+	partitions, err := bf.partitions(ctx, table.Name, func() ([]BackfillCheckpoint, error) {
+		workers := max(1, min(int64(bf.workers()), pages))
+		partitions := make([]BackfillCheckpoint, 0, workers)
+		for i := int64(0); i < workers; i++ {
+			partitions = append(partitions, BackfillCheckpoint{
+				LastKey:  pageKey(pages * i / workers),
+				UpperKey: pageKey(pages * (i + 1) / workers),
+			})
+		}
+		return partitions, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	p, err := newProgress(ctx, conn, table.Name, cbs)
+	if err != nil {
+		return err
+	}
+
+	err = runWorkers(ctx, len(partitions), func(ctx context.Context, worker int) error {
+		if partitions[worker].Done {
+			return nil
+		}
+		firstPage, err := keyPage(partitions[worker].LastKey)
+		if err != nil {
 			return err
 		}
+		lastPage, err := keyPage(partitions[worker].UpperKey)
+		if err != nil {
+			return err
+		}
+
+		t := newThrottler(conn, bf)
+		var lastBatch time.Duration
+
+		for page := firstPage; page < lastPage; page += pagesPerBatch {
+			// Stop between batches if the backfill has been interrupted.
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if page > firstPage {
+				if err := t.wait(ctx, lastBatch); err != nil {
+					return err
+				}
+			}
+
+			query := fmt.Sprintf("UPDATE %[1]s SET %[2]s=%[1]s.%[2]s WHERE ctid >= '(%[3]d,0)'::tid AND ctid < '(%[4]d,0)'::tid",
+				pq.QuoteIdentifier(table.Name),
+				pq.QuoteIdentifier(column),
+				page,
+				min(page+pagesPerBatch, lastPage))
+
+			// As for keyed batches, the batch is run to completion even if the
+			// backfill is interrupted.
+			started := time.Now()
+			res, err := conn.ExecContext(context.WithoutCancel(ctx), query)
+			if err != nil {
+				return err
+			}
+			lastBatch = time.Since(started)
+			updated, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			err = bf.saveCheckpoint(ctx, table.Name, worker, BackfillCheckpoint{
+				LastKey:  pageKey(min(page+pagesPerBatch, lastPage)),
+				UpperKey: partitions[worker].UpperKey,
+			})
+			if err != nil {
+				return err
+			}
+			p.add(updated)
+		}
+
+		return bf.saveCheckpoint(ctx, table.Name, worker, BackfillCheckpoint{
+			LastKey:  partitions[worker].UpperKey,
+			UpperKey: partitions[worker].UpperKey,
+			Done:     true,
+		})
+	})
+	if err != nil {
+		return err
 	}
 
+	p.done()
 	return nil
 }
 
+// pageKey returns the key used in checkpoints for a page of a table
+func pageKey(page int64) []*string {
+	s := strconv.FormatInt(page, 10)
+	return []*string{&s}
+}
+
+// keyPage returns the page of a table stored in a checkpoint key
+func keyPage(key []*string) (int64, error) {
+	if len(key) != 1 || key[0] == nil {
+		return 0, fmt.Errorf("invalid page checkpoint %v", key)
+	}
+	return strconv.ParseInt(*key[0], 10, 64)
+}
+
 type batcher struct {
-	table     *schema.Table
-	pkColumn  *schema.Column
-	lastPK    *string
-	batchSize int
+	table      *schema.Table
+	keyColumns []*schema.Column
+	lastKey    []*string
+	upperKey   []*string
+	batchSize  int
+}
+
+// run updates all the rows of the batcher's key range, batch by batch,
+// reporting its progress and saving a checkpoint after each batch.
+func (b *batcher) run(ctx context.Context, conn *sql.DB, t *throttler, p *progress, checkpoint func(done bool) error) error {
+	var lastBatch time.Duration
+
+	// Update each batch of rows, reporting progress after each one.
+	for batch := 0; ; batch++ {
+		// Stop between batches if the backfill has been interrupted.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if batch > 0 {
+			if err := t.wait(ctx, lastBatch); err != nil {
+				return err
+			}
+		}
+
+		// The batch itself is run with a context that can't be cancelled, so
+		// that an interrupted backfill finishes the batch it is working on
+		// rather than aborting it half way.
+		started := time.Now()
+		updated, err := b.updateBatch(context.WithoutCancel(ctx), conn)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return checkpoint(true)
+			}
+			return err
+		}
+		lastBatch = time.Since(started)
+
+		if err := checkpoint(false); err != nil {
+			return err
+		}
+		p.add(updated)
+	}
 }
 
 // updateBatch updates the next batch of rows in the table.
-func (b *batcher) updateBatch(ctx context.Context, conn *sql.DB) error {
+func (b *batcher) updateBatch(ctx context.Context, conn *sql.DB) (int64, error) {
 	// Start the transaction for this batch
 	tx, err := conn.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer tx.Rollback()
 
 	// Build the query to update the next batch of rows
 	query := b.buildQuery()
 
-	// Execute the query to update the next batch of rows and update the last PK
+	// Execute the query to update the next batch of rows and update the last key
 	// value for the next batch
-	err = tx.QueryRowContext(ctx, query).Scan(&b.lastPK)
+	var updated int64
+	lastKey := make([]*string, len(b.keyColumns))
+	dest := make([]any, len(lastKey)+1)
+	for i := range lastKey {
+		dest[i] = &lastKey[i]
+	}
+	dest[len(lastKey)] = &updated
+	err = tx.QueryRowContext(ctx, query).Scan(dest...)
 	if err != nil {
-		return err
+		return 0, err
 	}
+	b.lastKey = lastKey
 
 	// Commit the transaction for this batch
-	return tx.Commit()
+	return updated, tx.Commit()
 }
 
 // buildQuery builds the query used to update the next batch of rows.
 func (b *batcher) buildQuery() string {
+	table := pq.QuoteIdentifier(b.table.Name)
+
+	keyColumns := make([]string, len(b.keyColumns))
+	joinConditions := make([]string, len(b.keyColumns))
+	returning := make([]string, len(b.keyColumns))
+	descending := make([]string, len(b.keyColumns))
+	for i, col := range b.keyColumns {
+		column := pq.QuoteIdentifier(col.Name)
+		keyColumns[i] = column
+		joinConditions[i] = fmt.Sprintf("%[1]s.%[2]s = batch.%[2]s", table, column)
+		returning[i] = fmt.Sprintf("%s.%s", table, column)
+		descending[i] = column + " DESC"
+	}
+
+	var conditions []string
+	if b.lastKey != nil {
+		conditions = append(conditions, fmt.Sprintf("(%s) > (%s)", strings.Join(keyColumns, ", "), quoteLiterals(b.lastKey)))
+	}
+	if b.upperKey != nil {
+		conditions = append(conditions, fmt.Sprintf("(%s) <= (%s)", strings.Join(keyColumns, ", "), quoteLiterals(b.upperKey)))
+	}
 	whereClause := ""
-	if b.lastPK != nil {
-		whereClause = fmt.Sprintf("WHERE %s > %v", pq.QuoteIdentifier(b.pkColumn.Name), pq.QuoteLiteral(*b.lastPK))
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
 	}
 
 	return fmt.Sprintf(`
     WITH batch AS (
       SELECT %[1]s FROM %[2]s %[4]s ORDER BY %[1]s LIMIT %[3]d FOR NO KEY UPDATE
     ), update AS (
-      UPDATE %[2]s SET %[1]s=%[2]s.%[1]s FROM batch WHERE %[2]s.%[1]s = batch.%[1]s RETURNING %[2]s.%[1]s
+      UPDATE %[2]s SET %[5]s=%[2]s.%[5]s FROM batch WHERE %[6]s RETURNING %[7]s
     )
-    SELECT LAST_VALUE(%[1]s) OVER() FROM update
+    SELECT %[1]s, (SELECT count(*) FROM update) FROM update ORDER BY %[8]s LIMIT 1
     `,
-		pq.QuoteIdentifier(b.pkColumn.Name),
-		pq.QuoteIdentifier(b.table.Name),
+		strings.Join(keyColumns, ", "),
+		table,
 		b.batchSize,
-		whereClause)
+		whereClause,
+		keyColumns[0],
+		strings.Join(joinConditions, " AND "),
+		strings.Join(returning, ", "),
+		strings.Join(descending, ", "))
+}
+
+func quoteLiterals(values []*string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = pq.QuoteLiteral(*v)
+	}
+	return strings.Join(quoted, ", ")
 }
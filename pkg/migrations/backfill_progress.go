@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// BackfillProgress describes the progress of the backfill of a table. It is
+// reported to callbacks once when the backfill starts, after each batch and
+// once more when the backfill is done.
+type BackfillProgress struct {
+	// Operation is the name of the migration operation running the backfill.
+	// It is set when the migration is started with `roll.Roll`.
+	Operation OpName
+
+	// Table is the name of the table being backfilled
+	Table string
+
+	// Rows is the number of rows backfilled so far
+	Rows int64
+
+	// Total is an estimate of the number of rows to backfill, or 0 if
+	// unknown. It is never lower than Rows.
+	Total int64
+
+	// RowsPerSecond is the average number of rows backfilled per second since
+	// the backfill started
+	RowsPerSecond float64
+
+	// ETA is the estimated time left until the backfill is done, or 0 if
+	// unknown
+	ETA time.Duration
+
+	// Done is true once the backfill of the table has finished
+	Done bool
+}
+
+// Percent returns the percentage of the estimated total that has been
+// backfilled, or 0 if the total is unknown
+func (p BackfillProgress) Percent() float64 {
+	if p.Done {
+		return 100
+	}
+	if p.Total == 0 {
+		return 0
+	}
+	return float64(p.Rows) * 100 / float64(p.Total)
+}
+
+// progress aggregates the number of rows backfilled by all the workers of a
+// backfill, and reports it to the callbacks.
+type progress struct {
+	mu      sync.Mutex
+	table   string
+	rows    int64
+	total   int64
+	started time.Time
+	cbs     []CallbackFn
+}
+
+// newProgress starts tracking the progress of the backfill of a table and
+// reports that it started. The total number of rows is only estimated if
+// there are callbacks to report it to.
+func newProgress(ctx context.Context, conn *sql.DB, table string, cbs []CallbackFn) (*progress, error) {
+	p := &progress{table: table, started: time.Now(), cbs: cbs}
+	if len(cbs) > 0 {
+		var err error
+		p.total, err = estimateRows(ctx, conn, table)
+		if err != nil {
+			return nil, fmt.Errorf("unable to estimate the size of table %q: %w", table, err)
+		}
+	}
+
+	p.add(0)
+	return p, nil
+}
+
+// add adds the given number of rows to the total and reports the new total.
+// Callbacks are never invoked concurrently.
+func (p *progress) add(rows int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.rows += rows
+	p.report(false)
+}
+
+// done reports that the backfill has finished
+func (p *progress) done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.total = p.rows
+	p.report(true)
+}
+
+func (p *progress) report(done bool) {
+	event := BackfillProgress{
+		Table: p.table,
+		Rows:  p.rows,
+		Total: max(p.total, p.rows),
+		Done:  done,
+	}
+
+	if elapsed := time.Since(p.started).Seconds(); elapsed > 0 {
+		event.RowsPerSecond = float64(p.rows) / elapsed
+	}
+	if event.RowsPerSecond > 0 && !done {
+		remaining := float64(event.Total - event.Rows)
+		event.ETA = time.Duration(remaining / event.RowsPerSecond * float64(time.Second))
+	}
+
+	for _, cb := range p.cbs {
+		cb(event)
+	}
+}
+
+// estimateRows returns an estimate of the number of rows in a table.
+// As the planner does, the density of rows recorded by the last ANALYZE or
+// VACUUM is scaled to the current size of the table. Tables that were never
+// analyzed are counted instead.
+func estimateRows(ctx context.Context, conn *sql.DB, table string) (int64, error) {
+	var estimate int64
+	err := conn.QueryRowContext(ctx, `
+		SELECT CASE WHEN c.relpages > 0 AND c.reltuples >= 0
+			THEN (c.reltuples / c.relpages * (pg_relation_size(c.oid) / current_setting('block_size')::int))::bigint
+			ELSE -1 END
+		FROM pg_catalog.pg_class c
+		WHERE c.oid = to_regclass($1)`,
+		pq.QuoteIdentifier(table)).Scan(&estimate)
+	if err != nil {
+		return 0, err
+	}
+	if estimate >= 0 {
+		return estimate, nil
+	}
+
+	err = conn.QueryRowContext(ctx, fmt.Sprintf("SELECT count(*) FROM %s", pq.QuoteIdentifier(table))).Scan(&estimate)
+	return estimate, err
+}
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressEstimatesRateAndETA(t *testing.T) {
+	t.Parallel()
+
+	var events []BackfillProgress
+	p := &progress{
+		table:   "table1",
+		total:   100,
+		started: time.Now().Add(-10 * time.Second),
+		cbs:     []CallbackFn{func(p BackfillProgress) { events = append(events, p) }},
+	}
+
+	p.add(50)
+	p.add(100)
+	p.done()
+
+	assert.Len(t, events, 3)
+
+	// Halfway through the estimated total
+	assert.Equal(t, "table1", events[0].Table)
+	assert.Equal(t, int64(50), events[0].Rows)
+	assert.Equal(t, int64(100), events[0].Total)
+	assert.InDelta(t, 50, events[0].Percent(), 0.01)
+	assert.InDelta(t, 5, events[0].RowsPerSecond, 0.1)
+	assert.InDelta(t, 10*time.Second, events[0].ETA, float64(time.Second))
+
+	// The estimate was too low: the total is never lower than the rows done
+	assert.Equal(t, int64(150), events[1].Rows)
+	assert.Equal(t, int64(150), events[1].Total)
+	assert.Equal(t, time.Duration(0), events[1].ETA)
+
+	// Done
+	assert.True(t, events[2].Done)
+	assert.Equal(t, int64(150), events[2].Total)
+	assert.InDelta(t, 100, events[2].Percent(), 0.01)
+}
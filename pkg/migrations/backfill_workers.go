@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/lib/pq"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+// samplesPerPartition is the number of keys sampled from a table for each
+// partition, to find the boundaries of the partitions
+const samplesPerPartition = 1000
+
+// keyPartitions splits the key range of the table into at most n partitions
+// holding roughly the same number of rows, returned as the checkpoints of
+// partitions that haven't been backfilled yet. The boundaries of the partitions
+// are taken from a random sample of the table's keys.
+func keyPartitions(ctx context.Context, conn *sql.DB, table *schema.Table, key []*schema.Column, n int) ([]BackfillCheckpoint, error) {
+	// Sample enough pages of the table to get about `samplesPerPartition` keys
+	// per partition. Tables that have never been analyzed are fully scanned.
+	var estimatedRows float64
+	err := conn.QueryRowContext(ctx, "SELECT reltuples FROM pg_catalog.pg_class WHERE oid = to_regclass($1)",
+		pq.QuoteIdentifier(table.Name)).Scan(&estimatedRows)
+	if err != nil {
+		return nil, err
+	}
+	percent := 100.0
+	if estimatedRows > 0 {
+		percent = min(100, float64(samplesPerPartition*n)/estimatedRows*100)
+	}
+
+	keyColumns := make([]string, len(key))
+	for i, col := range key {
+		keyColumns[i] = pq.QuoteIdentifier(col.Name)
+	}
+
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT %[1]s FROM %[2]s TABLESAMPLE SYSTEM (%[3]f) ORDER BY %[1]s",
+		strings.Join(keyColumns, ", "),
+		pq.QuoteIdentifier(table.Name),
+		percent))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples [][]*string
+	for rows.Next() {
+		sample := make([]*string, len(key))
+		dest := make([]any, len(sample))
+		for i := range sample {
+			dest[i] = &sample[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		samples = append(samples, sample)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Use evenly spaced samples as the boundaries between partitions. Keys
+	// are unique, so there are no empty partitions unless there are fewer
+	// samples than partitions.
+	partitions := make([]BackfillCheckpoint, 0, n)
+	var from []*string
+	prev := 0
+	for i := 1; i < n; i++ {
+		idx := len(samples) * i / n
+		if idx == prev {
+			continue
+		}
+		prev = idx
+
+		to := samples[idx-1]
+		partitions = append(partitions, BackfillCheckpoint{LastKey: from, UpperKey: to})
+		from = to
+	}
+	partitions = append(partitions, BackfillCheckpoint{LastKey: from})
+
+	return partitions, nil
+}
+
+// runWorkers runs n workers concurrently. The first worker to fail cancels
+// the context of the other ones, which stop after their current batch, and
+// its error is returned once all workers have stopped.
+func runWorkers(ctx context.Context, n int, work func(ctx context.Context, worker int) error) error {
+	if n == 1 {
+		return work(ctx, 0)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			if err := work(ctx, worker); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	return firstErr
+}
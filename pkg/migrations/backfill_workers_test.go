@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunWorkersCancelsAllWorkersOnError(t *testing.T) {
+	t.Parallel()
+
+	errFailed := errors.New("worker failed")
+	var cancelled atomic.Int32
+
+	err := runWorkers(context.Background(), 4, func(ctx context.Context, worker int) error {
+		if worker == 0 {
+			return errFailed
+		}
+		<-ctx.Done()
+		cancelled.Add(1)
+		return ctx.Err()
+	})
+
+	assert.ErrorIs(t, err, errFailed)
+	assert.Equal(t, int32(3), cancelled.Load())
+}
+
+func TestProgressIsAggregatedAcrossWorkers(t *testing.T) {
+	t.Parallel()
+
+	var reported atomic.Int64
+	p := &progress{cbs: []CallbackFn{func(p BackfillProgress) { reported.Store(p.Rows) }}}
+
+	err := runWorkers(context.Background(), 4, func(ctx context.Context, worker int) error {
+		for i := 0; i < 10; i++ {
+			p.add(100)
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4000), reported.Load())
+}
@@ -7,6 +7,10 @@ func (c *CheckConstraint) Validate() error {
 		return FieldRequiredError{Name: "name"}
 	}
 
+	if err := ValidateIdentifier(c.Name); err != nil {
+		return err
+	}
+
 	if c.Constraint == "" {
 		return FieldRequiredError{Name: "constraint"}
 	}
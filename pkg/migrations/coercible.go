@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// typeAliases maps the names of the types whose changes can be made in place
+// to a single name per type
+var typeAliases = map[string]string{
+	"varchar":           "varchar",
+	"character varying": "varchar",
+	"text":              "text",
+	"numeric":           "numeric",
+	"decimal":           "numeric",
+	"varbit":            "varbit",
+	"bit varying":       "varbit",
+}
+
+// maxTypeModifiers is the number of modifiers that each type accepts
+var maxTypeModifiers = map[string]int{
+	"varchar": 1,
+	"text":    0,
+	"numeric": 2,
+	"varbit":  1,
+}
+
+// sqlType is a type name split into its base type and its modifiers, such as
+// the maximum length of a `varchar` or the precision and scale of a `numeric`
+type sqlType struct {
+	base      string
+	modifiers []int
+}
+
+// parseType parses the name of a type whose changes can be made in place. It
+// returns false for any other type, including arrays.
+func parseType(name string) (sqlType, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	var modifiers []int
+	if open := strings.Index(name, "("); open >= 0 {
+		if !strings.HasSuffix(name, ")") {
+			return sqlType{}, false
+		}
+		for _, m := range strings.Split(name[open+1:len(name)-1], ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(m))
+			if err != nil {
+				return sqlType{}, false
+			}
+			modifiers = append(modifiers, n)
+		}
+		name = name[:open]
+	}
+
+	base, ok := typeAliases[strings.Join(strings.Fields(name), " ")]
+	if !ok {
+		return sqlType{}, false
+	}
+
+	// a numeric precision without a scale has a scale of zero
+	if base == "numeric" && len(modifiers) == 1 {
+		modifiers = append(modifiers, 0)
+	}
+	if len(modifiers) > maxTypeModifiers[base] {
+		return sqlType{}, false
+	}
+
+	return sqlType{base: base, modifiers: modifiers}, true
+}
+
+// IsBinaryCoercible returns true if a column of type `from` can be changed to
+// type `to` without rewriting or scanning the table, because every value of
+// the old type is a valid value of the new type with the same representation.
+// Only the changes that widen a `varchar`, `text`, `numeric` or `varbit`
+// column are recognized.
+func IsBinaryCoercible(from, to string) bool {
+	f, ok := parseType(from)
+	if !ok {
+		return false
+	}
+	t, ok := parseType(to)
+	if !ok {
+		return false
+	}
+
+	switch {
+	case f.base == "varchar" && t.base == "text":
+		return true
+	case f.base == "text" && t.base == "varchar":
+		return len(t.modifiers) == 0
+	case f.base != t.base:
+		return false
+	}
+
+	// removing the limits of the type, or keeping them, is always safe
+	if len(t.modifiers) == 0 {
+		return true
+	}
+	if len(f.modifiers) == 0 {
+		return false
+	}
+
+	switch f.base {
+	case "varchar", "varbit":
+		return t.modifiers[0] >= f.modifiers[0]
+	case "numeric":
+		// the precision can grow as long as the scale is unchanged
+		return t.modifiers[0] >= f.modifiers[0] && t.modifiers[1] == f.modifiers[1]
+	}
+	return false
+}
+
+// isColumnReference returns true if the SQL expression is a reference to the
+// given column, and nothing else
+func isColumnReference(sql, column string) bool {
+	sql = strings.TrimSpace(sql)
+	return sql == column || sql == pq.QuoteIdentifier(column)
+}
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsBinaryCoercible(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		from, to  string
+		coercible bool
+	}{
+		{"varchar(50)", "varchar(255)", true},
+		{"varchar(50)", "character varying(50)", true},
+		{"varchar(50)", "varchar", true},
+		{"varchar(50)", "text", true},
+		{"varchar", "TEXT", true},
+		{"text", "varchar", true},
+		{"numeric(10,2)", "numeric", true},
+		{"numeric(10,2)", "numeric(12, 2)", true},
+		{"numeric(10)", "decimal(12,0)", true},
+		{"bit varying(5)", "varbit(10)", true},
+		{"text", "text", true},
+
+		{"varchar(255)", "varchar(50)", false},
+		{"varchar", "varchar(255)", false},
+		{"text", "varchar(255)", false},
+		{"numeric", "numeric(10,2)", false},
+		{"numeric(10,2)", "numeric(12,3)", false},
+		{"numeric(10,2)", "numeric(8,2)", false},
+		{"varchar(50)", "varbit(50)", false},
+		{"character(10)", "text", false},
+		{"integer", "bigint", false},
+		{"text[]", "text[]", false},
+		{"varchar(50,2)", "text", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.coercible, IsBinaryCoercible(tt.from, tt.to), "%s -> %s", tt.from, tt.to)
+	}
+}
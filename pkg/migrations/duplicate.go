@@ -49,26 +49,58 @@ func (d *Duplicator) WithoutNotNull() *Duplicator {
 	return d
 }
 
-// Duplicate creates a new column with the same type and foreign key
-// constraints as the original column.
+// Duplicate creates a new column with the same type, default, constraints,
+// indexes and other attributes as the original column.
 func (d *Duplicator) Duplicate(ctx context.Context) error {
 	const (
 		cAlterTableSQL         = `ALTER TABLE %s ADD COLUMN %s %s`
 		cSetDefaultSQL         = `ALTER COLUMN %s SET DEFAULT %s`
-		cAddForeignKeySQL      = `ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)`
+		cSetStorageSQL         = `ALTER COLUMN %s SET STORAGE %s`
+		cSetStatisticsSQL      = `ALTER COLUMN %s SET STATISTICS %d`
+		cAddForeignKeySQL      = `ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)%s`
 		cAddCheckConstraintSQL = `ADD CONSTRAINT %s %s NOT VALID`
-		cCreateUniqueIndexSQL  = `CREATE UNIQUE INDEX CONCURRENTLY %s ON %s (%s)`
+		cCreateUniqueIndexSQL  = `CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS %s ON %s (%s)`
+		cAddUniqueSQL          = `ALTER TABLE %s ADD CONSTRAINT %s UNIQUE USING INDEX %s`
+		cCommentOnColumnSQL    = `COMMENT ON COLUMN %s.%s IS %s`
+		cGrantSQL              = `GRANT %s (%s) ON %s TO %s`
 	)
 
+	// The collation and storage mode of the column may not be valid for
+	// another type, so they are only duplicated if the type is unchanged
+	sameType := d.withType == d.column.Type
+
 	// Generate SQL to duplicate the column's name and type
 	sql := fmt.Sprintf(cAlterTableSQL,
 		pq.QuoteIdentifier(d.table.Name),
 		pq.QuoteIdentifier(d.asName),
 		d.withType)
 
-	// Generate SQL to duplicate the column's default value
+	// Generate SQL to duplicate the column's collation
+	if sameType && d.column.Collation != "" {
+		sql += " COLLATE " + d.column.Collation
+	}
+
+	// Generate SQL to duplicate the column's default value. The duplicate of an
+	// identity column takes its values from the identity's sequence, until the
+	// identity is moved to it on completion.
 	if d.column.Default != nil {
 		sql += fmt.Sprintf(", "+cSetDefaultSQL, d.asName, *d.column.Default)
+	} else if d.column.Identity != "" {
+		sequence, err := serialSequence(ctx, d.conn, d.table.Name, d.column.Name)
+		if err != nil {
+			return err
+		}
+		if sequence.Valid {
+			sql += fmt.Sprintf(", "+cSetDefaultSQL, pq.QuoteIdentifier(d.asName), fmt.Sprintf("nextval(%s)", pq.QuoteLiteral(sequence.String)))
+		}
+	}
+
+	// Generate SQL to duplicate the column's storage mode and statistics target
+	if sameType && d.column.Storage != "" {
+		sql += fmt.Sprintf(", "+cSetStorageSQL, pq.QuoteIdentifier(d.asName), d.column.Storage)
+	}
+	if d.column.StatisticsTarget != nil {
+		sql += fmt.Sprintf(", "+cSetStatisticsSQL, pq.QuoteIdentifier(d.asName), *d.column.StatisticsTarget)
 	}
 
 	// Generate SQL to add an unchecked NOT NULL constraint if the original column
@@ -91,7 +123,8 @@ func (d *Duplicator) Duplicate(ctx context.Context) error {
 				pq.QuoteIdentifier(DuplicationName(fk.Name)),
 				strings.Join(quoteColumnNames(copyAndReplace(fk.Columns, d.column.Name, d.asName)), ", "),
 				pq.QuoteIdentifier(fk.ReferencedTable),
-				strings.Join(quoteColumnNames(fk.ReferencedColumns), ", "))
+				strings.Join(quoteColumnNames(fk.ReferencedColumns), ", "),
+				foreignKeyOptions(&fk))
 		}
 	}
 
@@ -109,20 +142,65 @@ func (d *Duplicator) Duplicate(ctx context.Context) error {
 		}
 	}
 
-	_, err := d.conn.ExecContext(ctx, sql)
+	// The column and its constraints are added in a single statement, so if
+	// the column already exists, a previous, interrupted attempt to start the
+	// migration has already duplicated it.
+	exists, err := columnExists(ctx, d.conn, d.table.Name, d.asName)
 	if err != nil {
 		return err
 	}
+	if !exists {
+		_, err = d.conn.ExecContext(ctx, sql)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Duplicate the column's comment
+	if d.column.Comment != "" {
+		_, err = d.conn.ExecContext(ctx, fmt.Sprintf(cCommentOnColumnSQL,
+			pq.QuoteIdentifier(d.table.Name),
+			pq.QuoteIdentifier(d.asName),
+			pq.QuoteLiteral(d.column.Comment)))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Duplicate the privileges granted on the column
+	for _, p := range d.column.Privileges {
+		grantee := p.Grantee
+		if grantee != "PUBLIC" {
+			grantee = pq.QuoteIdentifier(grantee)
+		}
+		if p.Grantable {
+			grantee += " WITH GRANT OPTION"
+		}
+
+		_, err = d.conn.ExecContext(ctx, fmt.Sprintf(cGrantSQL,
+			p.Privilege,
+			pq.QuoteIdentifier(d.asName),
+			pq.QuoteIdentifier(d.table.Name),
+			grantee))
+		if err != nil {
+			return err
+		}
+	}
 
 	// Generate SQL to duplicate any unique constraints on the column
-	// The constraint is duplicated by adding a unique index on the column concurrently.
-	// The index is converted into a unique constraint on migration completion.
+	// The constraint is duplicated by adding a unique index on the column
+	// concurrently, which then backs the duplicated constraint. The duplicated
+	// constraint is renamed on migration completion, along with its index.
 	for _, uc := range d.table.UniqueConstraints {
 		if uc.Name == d.withoutConstraint {
 			continue
 		}
 
 		if slices.Contains(uc.Columns, d.column.Name) {
+			if err := dropInvalidIndex(ctx, d.conn, DuplicationName(uc.Name)); err != nil {
+				return err
+			}
+
 			sql = fmt.Sprintf(cCreateUniqueIndexSQL,
 				pq.QuoteIdentifier(DuplicationName(uc.Name)),
 				pq.QuoteIdentifier(d.table.Name),
@@ -133,22 +211,151 @@ func (d *Duplicator) Duplicate(ctx context.Context) error {
 			if err != nil {
 				return err
 			}
+
+			exists, err := constraintExists(ctx, d.conn, d.table.Name, DuplicationName(uc.Name))
+			if err != nil {
+				return err
+			}
+			if !exists {
+				_, err = d.conn.ExecContext(ctx, fmt.Sprintf(cAddUniqueSQL,
+					pq.QuoteIdentifier(d.table.Name),
+					pq.QuoteIdentifier(DuplicationName(uc.Name)),
+					pq.QuoteIdentifier(DuplicationName(uc.Name))))
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// Duplicate any other indexes on the column concurrently, including
+	// partial and expression indexes. The unique constraints were duplicated
+	// above, and the primary key is duplicated by `duplicatePrimaryKey`.
+	indexes, err := indexesOnColumn(ctx, d.conn, d.table.Name, d.column.Name)
+	if err != nil {
+		return err
+	}
+	for _, name := range indexes {
+		idx, ok := d.table.Indexes[name]
+		if !ok || idx.Name == d.withoutConstraint || IsDuplicatedName(idx.Name) {
+			continue
+		}
+		if _, ok := d.table.UniqueConstraints[idx.Name]; ok {
+			continue
+		}
+		if isPrimaryKeyIndex(d.table, &idx) {
+			continue
+		}
+
+		sql, err := d.duplicateIndexSQL(&idx)
+		if err != nil {
+			return err
+		}
+		if err := dropInvalidIndex(ctx, d.conn, DuplicationName(idx.Name)); err != nil {
+			return err
+		}
+		_, err = d.conn.ExecContext(ctx, sql)
+		if err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// duplicateIndexSQL returns the SQL to create a copy of the index on the
+// duplicated column. The index is rebuilt from its definition, so that its
+// operator classes, collations and storage parameters are kept.
+func (d *Duplicator) duplicateIndexSQL(idx *schema.Index) (string, error) {
+	return rewriteIndexDefinition(idx.Definition, DuplicationName(idx.Name), d.column.Name, d.asName)
+}
+
+// isPrimaryKeyIndex returns true if the index backs the primary key of the
+// table
+func isPrimaryKeyIndex(table *schema.Table, idx *schema.Index) bool {
+	return idx.Unique &&
+		idx.Predicate == nil &&
+		len(table.PrimaryKey) > 0 &&
+		slices.Equal(idx.Columns, table.PrimaryKey)
+}
+
+// foreignKeyOptions returns the SQL for the actions and deferrability of a
+// foreign key, so that its duplicate behaves the same way. Schemas recorded
+// before these were read don't have them, and the defaults are used.
+func foreignKeyOptions(fk *schema.ForeignKey) string {
+	var sql string
+	if fk.OnDelete != "" {
+		sql += " ON DELETE " + fk.OnDelete
+	}
+	if fk.OnUpdate != "" {
+		sql += " ON UPDATE " + fk.OnUpdate
+	}
+	if fk.Deferrable {
+		sql += " DEFERRABLE"
+		if fk.InitiallyDeferred {
+			sql += " INITIALLY DEFERRED"
+		}
+	}
+	return sql
+}
+
+const duplicationPrefix = "_pgroll_dup_"
+
 func DuplicationName(name string) string {
-	return "_pgroll_dup_" + name
+	return Identifier(duplicationPrefix, name)
 }
 
 func IsDuplicatedName(name string) bool {
-	return strings.HasPrefix(name, "_pgroll_dup_")
+	return strings.HasPrefix(name, duplicationPrefix)
 }
 
+// StripDuplicationPrefix returns the name of the object that was duplicated
+// under the given name. This only works for names that didn't have to be
+// shortened; the original names of the others are given by
+// `ShortenedDuplicationNames`.
 func StripDuplicationPrefix(name string) string {
-	return strings.TrimPrefix(name, "_pgroll_dup_")
+	return strings.TrimPrefix(name, duplicationPrefix)
+}
+
+// ShortenedDuplicationNames returns the objects of the schema that are
+// duplicates of other objects and whose names had to be shortened, mapped to
+// the names of the objects they duplicate, by table name. It must be called
+// while both the originals and their duplicates exist, as the original names
+// can't be recovered from the shortened names.
+func ShortenedDuplicationNames(s *schema.Schema) map[string]map[string]string {
+	names := make(map[string]map[string]string)
+
+	for _, table := range s.Tables {
+		objects := make(map[string]bool)
+		for name := range table.Indexes {
+			objects[name] = true
+		}
+		for name := range table.ForeignKeys {
+			objects[name] = true
+		}
+		for name := range table.CheckConstraints {
+			objects[name] = true
+		}
+		for name := range table.UniqueConstraints {
+			objects[name] = true
+		}
+
+		for name := range objects {
+			if IsDuplicatedName(name) || !IsShortenedIdentifier(duplicationPrefix, name) {
+				continue
+			}
+			if !objects[DuplicationName(name)] {
+				continue
+			}
+
+			if names[table.Name] == nil {
+				names[table.Name] = make(map[string]string)
+			}
+			names[table.Name][DuplicationName(name)] = name
+		}
+	}
+
+	return names
 }
 
 func copyAndReplace(xs []string, oldValue, newValue string) []string {
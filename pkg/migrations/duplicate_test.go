@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+func TestDuplicateIndexSQL(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		column     string
+		definition string
+		want       string
+	}{
+		"plain index": {
+			column:     "name",
+			definition: "CREATE INDEX idx ON public.users USING btree (name, age)",
+			want:       `CREATE INDEX CONCURRENTLY IF NOT EXISTS "_pgroll_dup_idx" ON public.users USING btree ("_pgroll_new_name", age)`,
+		},
+		"partial unique expression index": {
+			column:     "name",
+			definition: "CREATE UNIQUE INDEX idx ON public.users USING btree (lower(name)) WHERE (age > 18)",
+			want:       `CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS "_pgroll_dup_idx" ON public.users USING btree (lower("_pgroll_new_name")) WHERE (age > 18)`,
+		},
+		"sort orders, collations and included columns": {
+			column:     "name",
+			definition: `CREATE INDEX idx ON public.users USING btree (age, name COLLATE "C" DESC NULLS LAST) INCLUDE (id, name)`,
+			want:       `CREATE INDEX CONCURRENTLY IF NOT EXISTS "_pgroll_dup_idx" ON public.users USING btree (age, "_pgroll_new_name" COLLATE "C" DESC NULLS LAST) INCLUDE (id, "_pgroll_new_name")`,
+		},
+		"operator classes and storage parameters": {
+			column:     "name",
+			definition: "CREATE INDEX idx ON public.users USING gin (name gin_trgm_ops) WITH (fastupdate=off)",
+			want:       `CREATE INDEX CONCURRENTLY IF NOT EXISTS "_pgroll_dup_idx" ON public.users USING gin ("_pgroll_new_name" gin_trgm_ops) WITH (fastupdate=off)`,
+		},
+		"columns whose names contain the name of the column": {
+			column:     "name",
+			definition: "CREATE INDEX idx ON public.users USING btree (lower(username), name) WHERE (nickname IS NOT NULL)",
+			want:       `CREATE INDEX CONCURRENTLY IF NOT EXISTS "_pgroll_dup_idx" ON public.users USING btree (lower(username), "_pgroll_new_name") WHERE (nickname IS NOT NULL)`,
+		},
+		"short column name": {
+			column:     "a",
+			definition: "CREATE INDEX idx ON public.users USING btree (a) WHERE ((age > 18) AND (a <> 'a'::text))",
+			want:       `CREATE INDEX CONCURRENTLY IF NOT EXISTS "_pgroll_dup_idx" ON public.users USING btree ("_pgroll_new_a") WHERE ((age > 18) AND ("_pgroll_new_a" <> 'a'::text))`,
+		},
+		"column named like a function, a type and a table": {
+			column:     "text",
+			definition: "CREATE INDEX idx ON public.text USING btree (text((text)::text), public.text(text))",
+			want:       `CREATE INDEX CONCURRENTLY IF NOT EXISTS "_pgroll_dup_idx" ON public.text USING btree (text(("_pgroll_new_text")::text), public.text("_pgroll_new_text"))`,
+		},
+		"quoted column name": {
+			column:     "User Name",
+			definition: `CREATE INDEX idx ON public.users USING btree ("User Name", (("User Name" || E'\'User Name')))`,
+			want:       `CREATE INDEX CONCURRENTLY IF NOT EXISTS "_pgroll_dup_idx" ON public.users USING btree ("_pgroll_new_User Name", (("_pgroll_new_User Name" || E'\'User Name')))`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			table := &schema.Table{Name: "users"}
+			idx := &schema.Index{Name: "idx", Definition: tt.definition}
+			d := NewColumnDuplicator(nil, table, &schema.Column{Name: tt.column, Type: "text"})
+
+			sql, err := d.duplicateIndexSQL(idx)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, sql)
+		})
+	}
+}
+
+func TestDuplicateIndexSQLInvalidDefinition(t *testing.T) {
+	t.Parallel()
+
+	d := NewColumnDuplicator(nil, &schema.Table{Name: "users"}, &schema.Column{Name: "name", Type: "text"})
+
+	for _, definition := range []string{
+		"",
+		"CREATE INDEX idx ON public.users",
+		"CREATE INDEX idx ON public.users USING btree (lower(name)",
+	} {
+		_, err := d.duplicateIndexSQL(&schema.Index{Name: "idx", Definition: definition})
+		assert.Error(t, err, definition)
+	}
+}
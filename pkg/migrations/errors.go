@@ -61,6 +61,15 @@ func (e ColumnIsNotNullableError) Error() string {
 	return fmt.Sprintf("column %q on table %q is NOT NULL", e.Name, e.Table)
 }
 
+type ColumnHasNullValuesError struct {
+	Table string
+	Name  string
+}
+
+func (e ColumnHasNullValuesError) Error() string {
+	return fmt.Sprintf("column %q on table %q has NULL values, an up SQL that rewrites them is required", e.Name, e.Table)
+}
+
 type ColumnIsNullableError struct {
 	Table string
 	Name  string
@@ -70,6 +79,40 @@ func (e ColumnIsNullableError) Error() string {
 	return fmt.Sprintf("column %q on table %q is nullable", e.Name, e.Table)
 }
 
+type RelationAlreadyExistsError struct {
+	Name string
+}
+
+func (e RelationAlreadyExistsError) Error() string {
+	return fmt.Sprintf("a view, sequence or type named %q already exists", e.Name)
+}
+
+type ColumnIsGeneratedError struct {
+	Table string
+	Name  string
+}
+
+func (e ColumnIsGeneratedError) Error() string {
+	return fmt.Sprintf("column %q on table %q is a generated column", e.Name, e.Table)
+}
+
+type ColumnIsIdentityError struct {
+	Table string
+	Name  string
+}
+
+func (e ColumnIsIdentityError) Error() string {
+	return fmt.Sprintf("column %q on table %q is an identity column", e.Name, e.Table)
+}
+
+type TypeDoesNotExistError struct {
+	Name string
+}
+
+func (e TypeDoesNotExistError) Error() string {
+	return fmt.Sprintf("type %q does not exist", e.Name)
+}
+
 type IndexAlreadyExistsError struct {
 	Name string
 }
@@ -157,15 +200,6 @@ func (e MultipleAlterColumnChangesError) Error() string {
 	return fmt.Sprintf("alter column operations require exactly one change, found %d", e.Changes)
 }
 
-type InvalidPrimaryKeyError struct {
-	Table  string
-	Fields int
-}
-
-func (e InvalidPrimaryKeyError) Error() string {
-	return fmt.Sprintf("primary key on table %q must be defined on exactly one column, found %d", e.Table, e.Fields)
-}
-
 type InvalidReplicaIdentityError struct {
 	Table    string
 	Identity string
@@ -174,3 +208,36 @@ type InvalidReplicaIdentityError struct {
 func (e InvalidReplicaIdentityError) Error() string {
 	return fmt.Sprintf("replica identity on table %q must be one of 'NOTHING', 'DEFAULT', 'INDEX' or 'FULL', found %q", e.Table, e.Identity)
 }
+
+type UpColumnMismatchError struct {
+	Table      string
+	Column     string
+	Mismatches int64
+}
+
+func (e UpColumnMismatchError) Error() string {
+	return fmt.Sprintf("column %q of table %q differs from its up expression in %d rows", e.Column, e.Table, e.Mismatches)
+}
+
+type IdentifierTooLongError struct {
+	Name string
+}
+
+func (e IdentifierTooLongError) Error() string {
+	return fmt.Sprintf("identifier %q is longer than the maximum of %d bytes", e.Name, MaxIdentifierLength)
+}
+
+// BackfillError is returned when the backfill of a table fails. The
+// checkpoints of the backfill are kept, so that it can be resumed.
+type BackfillError struct {
+	Table string
+	Err   error
+}
+
+func (e BackfillError) Error() string {
+	return fmt.Sprintf("backfill of table %q failed: %s", e.Table, e.Err)
+}
+
+func (e BackfillError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// columnExists returns true if the given column exists on the table. The
+// table name is resolved using the search_path of the connection.
+//
+// It is used to make operation completion re-runnable: steps that can't be
+// expressed with `IF EXISTS` are skipped when a previous attempt already
+// performed them.
+func columnExists(ctx context.Context, conn *sql.DB, table, column string) (bool, error) {
+	var exists bool
+	err := conn.QueryRowContext(ctx, `SELECT EXISTS (
+		SELECT 1
+		FROM pg_catalog.pg_attribute
+		WHERE attrelid = to_regclass($1)
+		AND attname = $2
+		AND attnum > 0
+		AND NOT attisdropped
+	)`, pq.QuoteIdentifier(table), column).Scan(&exists)
+
+	return exists, err
+}
+
+// constraintExists returns true if a constraint with the given name is
+// defined on the table. The table name is resolved using the search_path of
+// the connection.
+func constraintExists(ctx context.Context, conn *sql.DB, table, constraint string) (bool, error) {
+	var exists bool
+	err := conn.QueryRowContext(ctx, `SELECT EXISTS (
+		SELECT 1
+		FROM pg_catalog.pg_constraint
+		WHERE conrelid = to_regclass($1)
+		AND conname = $2
+	)`, pq.QuoteIdentifier(table), constraint).Scan(&exists)
+
+	return exists, err
+}
+
+// indexesOnColumn returns the names of the indexes of the table that use the
+// given column, whether as a key or included column, or in an expression or
+// the predicate of the index. The column is matched by its attribute number,
+// so columns whose names merely contain the name of the column don't match.
+func indexesOnColumn(ctx context.Context, conn *sql.DB, table, column string) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT ix.relname
+		FROM pg_catalog.pg_index AS pi
+			INNER JOIN pg_catalog.pg_class AS ix ON ix.oid = pi.indexrelid
+			INNER JOIN pg_catalog.pg_attribute AS a ON a.attrelid = pi.indrelid
+		WHERE pi.indrelid = to_regclass($1)
+		AND a.attname = $2
+		AND NOT a.attisdropped
+		-- key and included columns are listed in indkey, and the columns used by
+		-- expressions and predicates are recorded as dependencies
+		AND (a.attnum = ANY (pi.indkey) OR EXISTS (
+			SELECT 1
+			FROM pg_catalog.pg_depend AS d
+			WHERE d.classid = 'pg_catalog.pg_class'::regclass
+			AND d.objid = pi.indexrelid
+			AND d.refclassid = 'pg_catalog.pg_class'::regclass
+			AND d.refobjid = pi.indrelid
+			AND d.refobjsubid = a.attnum
+		))
+		ORDER BY ix.relname`, pq.QuoteIdentifier(table), column)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, name)
+	}
+
+	return indexes, rows.Err()
+}
+
+// dropInvalidIndex drops the index if it is invalid, as left by an interrupted
+// attempt to create it concurrently, so that it can be created again. `CREATE
+// INDEX CONCURRENTLY IF NOT EXISTS` would otherwise keep the invalid index.
+func dropInvalidIndex(ctx context.Context, conn *sql.DB, index string) error {
+	var invalid bool
+	err := conn.QueryRowContext(ctx, `SELECT EXISTS (
+		SELECT 1
+		FROM pg_catalog.pg_index
+		WHERE indexrelid = to_regclass($1)
+		AND NOT indisvalid
+	)`, pq.QuoteIdentifier(index)).Scan(&invalid)
+	if err != nil || !invalid {
+		return err
+	}
+
+	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %s", pq.QuoteIdentifier(index)))
+	return err
+}
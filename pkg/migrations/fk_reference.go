@@ -9,6 +9,10 @@ func (f *ForeignKeyReference) Validate(s *schema.Schema) error {
 		return FieldRequiredError{Name: "name"}
 	}
 
+	if err := ValidateIdentifier(f.Name); err != nil {
+		return err
+	}
+
 	table := s.GetTable(f.Table)
 	if table == nil {
 		return TableDoesNotExistError{Name: f.Table}
@@ -0,0 +1,309 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+type sqlTokenKind int
+
+const (
+	tokenSpace sqlTokenKind = iota
+	tokenIdentifier
+	tokenQuotedIdentifier
+	tokenString
+	tokenOther
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+}
+
+// rewriteIndexDefinition rewrites an index definition, as given by
+// pg_get_indexdef, into the SQL to create the index concurrently under a new
+// name, with any references to the column replaced by references to the new
+// column.
+//
+// The definition is tokenized so that only identifiers are replaced: string
+// literals, function names, qualified names, collations, operator classes and
+// storage parameters are left as they are, as are columns whose names merely
+// contain the name of the column.
+func rewriteIndexDefinition(definition, name, column, newColumn string) (string, error) {
+	tokens := tokenizeSQL(definition)
+
+	// The definition has the form:
+	// CREATE [UNIQUE] INDEX name ON [ONLY] table USING method (keys) [INCLUDE (columns)]
+	//   [NULLS NOT DISTINCT] [WITH (parameters)] [TABLESPACE tablespace] [WHERE predicate]
+	on := indexOfKeyword(tokens, 0, "ON")
+	using := indexOfKeyword(tokens, on+1, "USING")
+	if on < 0 || using < 0 {
+		return "", fmt.Errorf("unable to parse index definition %q", definition)
+	}
+	unique := indexOfKeyword(tokens[:on], 0, "UNIQUE") >= 0
+
+	keysStart := nextSignificant(tokens, nextSignificant(tokens, using+1)+1)
+	if keysStart < 0 || tokens[keysStart].text != "(" {
+		return "", fmt.Errorf("unable to parse index definition %q", definition)
+	}
+	keysEnd := matchingParenthesis(tokens, keysStart)
+	if keysEnd < 0 {
+		return "", fmt.Errorf("unable to parse index definition %q", definition)
+	}
+
+	// Each key is an expression followed by an optional collation, operator
+	// class and sort order. Only the expression refers to columns.
+	for _, key := range splitList(tokens, keysStart+1, keysEnd) {
+		start := nextSignificant(tokens[:key[1]], key[0])
+		if start < 0 {
+			continue
+		}
+		end := start + 1
+		if tokens[start].text == "(" {
+			end = matchingParenthesis(tokens, start) + 1
+		} else {
+			// A column, or a (possibly qualified) function call
+			for end+1 < key[1] && tokens[end].text == "." {
+				end += 2
+			}
+			if next := nextSignificant(tokens[:key[1]], end); next >= 0 && tokens[next].text == "(" {
+				end = matchingParenthesis(tokens, next) + 1
+			}
+		}
+		if end <= start {
+			return "", fmt.Errorf("unable to parse index definition %q", definition)
+		}
+		rewriteColumnReferences(tokens[start:end], column, newColumn)
+	}
+
+	// The included columns and the predicate refer to columns too
+	for i := keysEnd + 1; i < len(tokens); i++ {
+		switch {
+		case isKeyword(tokens[i], "INCLUDE"):
+			start := nextSignificant(tokens, i+1)
+			if start < 0 || tokens[start].text != "(" {
+				return "", fmt.Errorf("unable to parse index definition %q", definition)
+			}
+			end := matchingParenthesis(tokens, start)
+			if end < 0 {
+				return "", fmt.Errorf("unable to parse index definition %q", definition)
+			}
+			rewriteColumnReferences(tokens[start:end+1], column, newColumn)
+			i = end
+		case isKeyword(tokens[i], "WHERE"):
+			rewriteColumnReferences(tokens[i+1:], column, newColumn)
+			i = len(tokens)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("CREATE ")
+	if unique {
+		sb.WriteString("UNIQUE ")
+	}
+	sb.WriteString("INDEX CONCURRENTLY IF NOT EXISTS ")
+	sb.WriteString(pq.QuoteIdentifier(name))
+	sb.WriteString(" ")
+	for _, t := range tokens[on:] {
+		sb.WriteString(t.text)
+	}
+	return sb.String(), nil
+}
+
+// rewriteColumnReferences replaces the identifiers in the expression that
+// refer to the column with the quoted new column name. Identifiers that are
+// qualified, that are followed by a parenthesis (function names), or that
+// follow a cast or `COLLATE` (type and collation names) are not column
+// references.
+func rewriteColumnReferences(tokens []sqlToken, column, newColumn string) {
+	for i, t := range tokens {
+		if identifierName(t) != column {
+			continue
+		}
+		if prev := previousSignificant(tokens, i-1); prev >= 0 {
+			p := tokens[prev]
+			if p.text == "." || p.text == ":" || isKeyword(p, "COLLATE") {
+				continue
+			}
+		}
+		if next := nextSignificant(tokens, i+1); next >= 0 {
+			if n := tokens[next].text; n == "." || n == "(" {
+				continue
+			}
+		}
+		tokens[i] = sqlToken{kind: tokenQuotedIdentifier, text: pq.QuoteIdentifier(newColumn)}
+	}
+}
+
+// tokenizeSQL splits SQL into tokens. Concatenating the text of the tokens
+// gives back the SQL.
+func tokenizeSQL(sql string) []sqlToken {
+	var tokens []sqlToken
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		j := i + 1
+		kind := tokenOther
+
+		switch {
+		case isSpace(c):
+			kind = tokenSpace
+			for j < len(sql) && isSpace(sql[j]) {
+				j++
+			}
+		case c == '"':
+			kind = tokenQuotedIdentifier
+			j = endOfQuoted(sql, i, '"', false)
+		case c == '\'':
+			kind = tokenString
+			// Strings with escapes are given as E'...'
+			escapes := len(tokens) > 0 && strings.EqualFold(tokens[len(tokens)-1].text, "E")
+			j = endOfQuoted(sql, i, '\'', escapes)
+		case isIdentifierStart(c):
+			kind = tokenIdentifier
+			for j < len(sql) && isIdentifierPart(sql[j]) {
+				j++
+			}
+		case c >= '0' && c <= '9':
+			for j < len(sql) && (isIdentifierPart(sql[j]) || sql[j] == '.') {
+				j++
+			}
+		}
+
+		tokens = append(tokens, sqlToken{kind: kind, text: sql[i:j]})
+		i = j
+	}
+
+	return tokens
+}
+
+// endOfQuoted returns the position after the quoted string or identifier
+// starting at i. Doubled quotes, and escaped characters if enabled, don't end
+// it.
+func endOfQuoted(sql string, i int, quote byte, escapes bool) int {
+	for j := i + 1; j < len(sql); j++ {
+		switch {
+		case escapes && sql[j] == '\\':
+			j++
+		case sql[j] == quote:
+			if j+1 < len(sql) && sql[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(sql)
+}
+
+// identifierName returns the name given by an identifier token, or the empty
+// string if the token isn't an identifier
+func identifierName(t sqlToken) string {
+	switch t.kind {
+	case tokenIdentifier:
+		return t.text
+	case tokenQuotedIdentifier:
+		return strings.ReplaceAll(strings.TrimSuffix(strings.TrimPrefix(t.text, `"`), `"`), `""`, `"`)
+	}
+	return ""
+}
+
+func isKeyword(t sqlToken, keyword string) bool {
+	return t.kind == tokenIdentifier && strings.EqualFold(t.text, keyword)
+}
+
+// indexOfKeyword returns the position of the first occurrence of the keyword
+// from position i, outside of any parentheses, or -1 if there is none
+func indexOfKeyword(tokens []sqlToken, i int, keyword string) int {
+	if i < 0 {
+		return -1
+	}
+	depth := 0
+	for ; i < len(tokens); i++ {
+		switch {
+		case tokens[i].text == "(":
+			depth++
+		case tokens[i].text == ")":
+			depth--
+		case depth == 0 && isKeyword(tokens[i], keyword):
+			return i
+		}
+	}
+	return -1
+}
+
+// matchingParenthesis returns the position of the parenthesis closing the one
+// at position i, or -1 if it isn't closed
+func matchingParenthesis(tokens []sqlToken, i int) int {
+	depth := 0
+	for ; i < len(tokens); i++ {
+		switch tokens[i].text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitList returns the bounds of the comma separated elements of the list
+// between positions start and end
+func splitList(tokens []sqlToken, start, end int) [][2]int {
+	var elements [][2]int
+	depth := 0
+	for i := start; i < end; i++ {
+		switch tokens[i].text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case ",":
+			if depth == 0 {
+				elements = append(elements, [2]int{start, i})
+				start = i + 1
+			}
+		}
+	}
+	return append(elements, [2]int{start, end})
+}
+
+func nextSignificant(tokens []sqlToken, i int) int {
+	if i < 0 {
+		return -1
+	}
+	for ; i < len(tokens); i++ {
+		if tokens[i].kind != tokenSpace {
+			return i
+		}
+	}
+	return -1
+}
+
+func previousSignificant(tokens []sqlToken, i int) int {
+	for ; i >= 0; i-- {
+		if tokens[i].kind != tokenSpace {
+			return i
+		}
+	}
+	return -1
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isIdentifierPart(c byte) bool {
+	return isIdentifierStart(c) || c == '$' || (c >= '0' && c <= '9')
+}
@@ -4,20 +4,25 @@ package migrations
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
 
 	_ "github.com/lib/pq"
 	"github.com/xataio/pgroll/pkg/schema"
 )
 
-type CallbackFn func(int64)
+// CallbackFn is called to report the progress of backfills
+type CallbackFn func(BackfillProgress)
 
 type Operation interface {
 	// Start will apply the required changes to enable supporting the new schema
 	// version in the database (through a view)
 	// update the given views to expose the new schema version
-	Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, cbs ...CallbackFn) error
+	// Any backfill needed by the operation is run according to the given
+	// backfill config, which may be nil to use the defaults.
+	Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, bf *BackfillConfig, cbs ...CallbackFn) error
 
 	// Complete will update the database schema to match the current version
 	// after calling Start.
@@ -43,15 +48,54 @@ type RequiresSchemaRefreshOperation interface {
 	RequiresSchemaRefresh()
 }
 
+// TablesOperation is an operation that only changes the tables it returns,
+// given by their names both before and after the operation, so that only
+// those tables have to be read again after it runs. Other operations may
+// change any table.
+type TablesOperation interface {
+	Tables() []string
+}
+
+// RecreatesViewsOperation is an operation whose completion alters columns in a
+// way that postgres refuses while views depend on them. The views of the
+// returned tables are dropped before the operation completes on the given
+// schema, and created again once the migration is complete.
+type RecreatesViewsOperation interface {
+	RecreatedViews(s *schema.Schema) []string
+}
+
+// UpColumnsOperation is an operation that fills columns with the result of
+// `up` SQL expressions, which can be verified with `VerifyUpColumn`
+type UpColumnsOperation interface {
+	UpColumns() []UpColumn
+}
+
 type (
 	Operations []Operation
 	Migration  struct {
 		Name string `json:"name"`
 
 		Operations Operations `json:"operations"`
+
+		// Optional settings for the backfills run by the migration
+		Backfill *BackfillSettings `json:"backfill,omitempty"`
 	}
 )
 
+// ChangedTables returns the tables that the migration's operations change,
+// or nil if any of its operations may change any table
+func (m *Migration) ChangedTables() []string {
+	tables := []string{}
+	for _, op := range m.Operations {
+		op, ok := op.(TablesOperation)
+		if !ok {
+			return nil
+		}
+		tables = append(tables, op.Tables()...)
+	}
+	return tables
+}
+
 // Validate will check that the migration can be applied to the given schema
 // returns a descriptive error if the migration is invalid
 func (m *Migration) Validate(ctx context.Context, s *schema.Schema) error {
@@ -72,3 +116,16 @@ func (m *Migration) Validate(ctx context.Context, s *schema.Schema) error {
 
 	return nil
 }
+
+// Checksum returns a checksum of the migration's operations. It is computed
+// from their canonical JSON representation, so it does not depend on the
+// formatting of the migration file.
+func (m *Migration) Checksum() (string, error) {
+	raw, err := m.Operations.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
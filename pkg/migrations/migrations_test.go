@@ -4,6 +4,7 @@ package migrations
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -38,3 +39,37 @@ func TestMigrationsIsolatedValid(t *testing.T) {
 	err := migration.Validate(context.TODO(), schema.New())
 	assert.NoError(t, err)
 }
+
+func TestMigrationChecksum(t *testing.T) {
+	readMigration := func(raw string) *Migration {
+		migration, err := ReadMigration(strings.NewReader(raw))
+		assert.NoError(t, err)
+		return migration
+	}
+
+	migration := readMigration(`{"name": "01_sql", "operations": [{"sql": {"up": "SELECT 1"}}]}`)
+	reformatted := readMigration(`{
+		"name": "01_sql",
+		"operations": [
+			{
+				"sql": {
+					"up": "SELECT 1"
+				}
+			}
+		]
+	}`)
+	modified := readMigration(`{"name": "01_sql", "operations": [{"sql": {"up": "SELECT 2"}}]}`)
+
+	checksum, err := migration.Checksum()
+	assert.NoError(t, err)
+
+	// The checksum does not depend on the formatting of the migration
+	reformattedChecksum, err := reformatted.Checksum()
+	assert.NoError(t, err)
+	assert.Equal(t, checksum, reformattedChecksum)
+
+	// The checksum changes when the operations change
+	modifiedChecksum, err := modified.Checksum()
+	assert.NoError(t, err)
+	assert.NotEqual(t, checksum, modifiedChecksum)
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"unicode/utf8"
+)
+
+// MaxIdentifierLength is the maximum length, in bytes, of a Postgres
+// identifier. Postgres silently truncates longer identifiers.
+const MaxIdentifierLength = 63
+
+// identifierHashLength is the number of hex digits of the hash that ends the
+// identifiers that had to be shortened
+const identifierHashLength = 8
+
+// Identifier returns the name of an object that pgroll derives from the name
+// of another object by adding a prefix.
+//
+// If `prefix + name` fits in an identifier it is returned as is. Otherwise it
+// is shortened to fit and ends with a hash of the full name, so that the
+// identifier is valid and different long names give different identifiers.
+// Postgres' `identifier` function in the state schema implements the same
+// scheme.
+func Identifier(prefix, name string) string {
+	full := prefix + name
+	if len(full) <= MaxIdentifierLength {
+		return full
+	}
+
+	sum := sha256.Sum256([]byte(full))
+	suffix := "_" + hex.EncodeToString(sum[:])[:identifierHashLength]
+
+	// Remove whole characters so that multi-byte characters aren't split
+	truncated := full
+	for len(truncated) > MaxIdentifierLength-len(suffix) {
+		_, size := utf8.DecodeLastRuneInString(truncated)
+		truncated = truncated[:len(truncated)-size]
+	}
+
+	return truncated + suffix
+}
+
+// IsShortenedIdentifier returns true if `Identifier(prefix, name)` had to
+// shorten the name, in which case the name can't be recovered from the
+// identifier
+func IsShortenedIdentifier(prefix, name string) bool {
+	return len(prefix)+len(name) > MaxIdentifierLength
+}
+
+// ValidateIdentifier checks that a name given in a migration fits in a
+// Postgres identifier
+func ValidateIdentifier(name string) error {
+	if len(name) > MaxIdentifierLength {
+		return IdentifierTooLongError{Name: name}
+	}
+
+	return nil
+}
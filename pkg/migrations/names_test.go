@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+func TestIdentifier(t *testing.T) {
+	t.Parallel()
+
+	t.Run("short names are kept as is", func(t *testing.T) {
+		assert.Equal(t, "_pgroll_new_name", Identifier("_pgroll_new_", "name"))
+		assert.Equal(t, "_pgroll_new_"+strings.Repeat("a", 51), Identifier("_pgroll_new_", strings.Repeat("a", 51)))
+	})
+
+	t.Run("long names are shortened and hashed", func(t *testing.T) {
+		a := Identifier("_pgroll_new_", strings.Repeat("a", 60)+"x")
+		b := Identifier("_pgroll_new_", strings.Repeat("a", 60)+"y")
+
+		assert.Len(t, a, MaxIdentifierLength)
+		assert.Len(t, b, MaxIdentifierLength)
+		assert.True(t, strings.HasPrefix(a, "_pgroll_new_aaaa"))
+		assert.NotEqual(t, a, b)
+		assert.Equal(t, a, Identifier("_pgroll_new_", strings.Repeat("a", 60)+"x"))
+		assert.True(t, IsShortenedIdentifier("_pgroll_new_", strings.Repeat("a", 60)+"x"))
+	})
+
+	t.Run("multi-byte characters are not split", func(t *testing.T) {
+		id := Identifier("_pgroll_new_", strings.Repeat("é", 40))
+
+		assert.LessOrEqual(t, len(id), MaxIdentifierLength)
+		assert.True(t, utf8.ValidString(id))
+	})
+}
+
+func TestValidateIdentifier(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, ValidateIdentifier(strings.Repeat("a", MaxIdentifierLength)))
+	assert.Equal(t, IdentifierTooLongError{Name: strings.Repeat("a", 64)}, ValidateIdentifier(strings.Repeat("a", 64)))
+}
+
+func TestShortenedDuplicationNames(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("c", 55)
+	s := &schema.Schema{
+		Tables: map[string]schema.Table{
+			"users": {
+				Name: "users",
+				CheckConstraints: map[string]schema.CheckConstraint{
+					"short":                  {Name: "short"},
+					DuplicationName("short"): {Name: DuplicationName("short")},
+					long:                     {Name: long},
+					DuplicationName(long):    {Name: DuplicationName(long)},
+				},
+				Indexes: map[string]schema.Index{
+					strings.Repeat("i", 60): {Name: strings.Repeat("i", 60)},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, map[string]map[string]string{
+		"users": {DuplicationName(long): long},
+	}, ShortenedDuplicationNames(s))
+}
@@ -15,7 +15,7 @@ import (
 
 var _ Operation = (*OpAddColumn)(nil)
 
-func (o *OpAddColumn) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, cbs ...CallbackFn) error {
+func (o *OpAddColumn) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, bf *BackfillConfig, cbs ...CallbackFn) error {
 	table := s.GetTable(o.Table)
 
 	if err := addColumn(ctx, conn, *o, table); err != nil {
@@ -42,7 +42,7 @@ func (o *OpAddColumn) Start(ctx context.Context, conn *sql.DB, stateSchema strin
 
 	if o.Up != nil {
 		err := createTrigger(ctx, conn, triggerConfig{
-			Name:           TriggerName(o.Table, o.Column.Name),
+			Name:           TriggerFunctionName(o.Table, o.Column.Name),
 			Direction:      TriggerDirectionUp,
 			Columns:        s.GetTable(o.Table).Columns,
 			SchemaName:     s.Name,
@@ -54,7 +54,7 @@ func (o *OpAddColumn) Start(ctx context.Context, conn *sql.DB, stateSchema strin
 		if err != nil {
 			return fmt.Errorf("failed to create trigger: %w", err)
 		}
-		if err := backfill(ctx, conn, table, cbs...); err != nil {
+		if err := backfill(ctx, conn, table, bf, cbs...); err != nil {
 			return fmt.Errorf("failed to backfill column: %w", err)
 		}
 	}
@@ -69,22 +69,34 @@ func (o *OpAddColumn) Start(ctx context.Context, conn *sql.DB, stateSchema strin
 func (o *OpAddColumn) Complete(ctx context.Context, conn *sql.DB, s *schema.Schema) error {
 	tempName := TemporaryName(o.Column.Name)
 
-	_, err := conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s RENAME COLUMN %s TO %s",
-		pq.QuoteIdentifier(o.Table),
-		pq.QuoteIdentifier(tempName),
-		pq.QuoteIdentifier(o.Column.Name),
-	))
+	// The column has already been renamed if a previous attempt to complete
+	// the migration was interrupted.
+	tempExists, err := columnExists(ctx, conn, o.Table, tempName)
 	if err != nil {
 		return err
 	}
+	if tempExists {
+		_, err = conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s RENAME COLUMN %s TO %s",
+			pq.QuoteIdentifier(o.Table),
+			pq.QuoteIdentifier(tempName),
+			pq.QuoteIdentifier(o.Column.Name),
+		))
+		if err != nil {
+			return err
+		}
+	}
 
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, o.Column.Name))))
+	err = dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, o.Column.Name))
 	if err != nil {
 		return err
 	}
 
-	if !o.Column.IsNullable() && o.Column.Default == nil {
+	notNullExists, err := constraintExists(ctx, conn, o.Table, NotNullConstraintName(o.Column.Name))
+	if err != nil {
+		return err
+	}
+
+	if notNullExists {
 		_, err = conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s VALIDATE CONSTRAINT %s",
 			pq.QuoteIdentifier(o.Table),
 			pq.QuoteIdentifier(NotNullConstraintName(o.Column.Name))))
@@ -129,8 +141,7 @@ func (o *OpAddColumn) Rollback(ctx context.Context, conn *sql.DB) error {
 		return err
 	}
 
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, o.Column.Name))))
+	err = dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, o.Column.Name))
 	return err
 }
 
@@ -144,6 +155,10 @@ func (o *OpAddColumn) Validate(ctx context.Context, s *schema.Schema) error {
 		return ColumnAlreadyExistsError{Name: o.Column.Name, Table: o.Table}
 	}
 
+	if err := ValidateIdentifier(o.Column.Name); err != nil {
+		return err
+	}
+
 	if o.Column.References != nil {
 		if err := o.Column.References.Validate(s); err != nil {
 			return ColumnReferenceError{
@@ -164,12 +179,6 @@ func (o *OpAddColumn) Validate(ctx context.Context, s *schema.Schema) error {
 		}
 	}
 
-	// Ensure that the column has a primary key defined on exactly one column.
-	pk := table.GetPrimaryKey()
-	if len(pk) != 1 {
-		return InvalidPrimaryKeyError{Table: o.Table, Fields: len(pk)}
-	}
-
 	if !o.Column.IsNullable() && o.Column.Default == nil && o.Up == nil {
 		return FieldRequiredError{Name: "up"}
 	}
@@ -202,7 +211,7 @@ func addColumn(ctx context.Context, conn *sql.DB, o OpAddColumn, t *schema.Table
 	o.Column.Check = nil
 
 	o.Column.Name = TemporaryName(o.Column.Name)
-	_, err := conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s",
+	_, err := conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s",
 		pq.QuoteIdentifier(t.Name),
 		ColumnToSQL(o.Column),
 	))
@@ -210,7 +219,12 @@ func addColumn(ctx context.Context, conn *sql.DB, o OpAddColumn, t *schema.Table
 }
 
 func addNotNullConstraint(ctx context.Context, conn *sql.DB, table, column, physicalColumn string) error {
-	_, err := conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s CHECK (%s IS NOT NULL) NOT VALID",
+	exists, err := constraintExists(ctx, conn, table, NotNullConstraintName(column))
+	if err != nil || exists {
+		return err
+	}
+
+	_, err = conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s CHECK (%s IS NOT NULL) NOT VALID",
 		pq.QuoteIdentifier(table),
 		pq.QuoteIdentifier(NotNullConstraintName(column)),
 		pq.QuoteIdentifier(physicalColumn),
@@ -219,7 +233,12 @@ func addNotNullConstraint(ctx context.Context, conn *sql.DB, table, column, phys
 }
 
 func (o *OpAddColumn) addCheckConstraint(ctx context.Context, conn *sql.DB) error {
-	_, err := conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s CHECK (%s) NOT VALID",
+	exists, err := constraintExists(ctx, conn, o.Table, o.Column.Check.Name)
+	if err != nil || exists {
+		return err
+	}
+
+	_, err = conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s CHECK (%s) NOT VALID",
 		pq.QuoteIdentifier(o.Table),
 		pq.QuoteIdentifier(o.Column.Check.Name),
 		rewriteCheckExpression(o.Column.Check.Constraint, o.Column.Name, TemporaryName(o.Column.Name)),
@@ -227,10 +246,23 @@ func (o *OpAddColumn) addCheckConstraint(ctx context.Context, conn *sql.DB) erro
 	return err
 }
 
+const notNullConstraintPrefix = "_pgroll_check_not_null_"
+
 func NotNullConstraintName(columnName string) string {
-	return "_pgroll_check_not_null_" + columnName
+	return Identifier(notNullConstraintPrefix, columnName)
 }
 
 func IsNotNullConstraintName(name string) bool {
-	return strings.HasPrefix(name, "_pgroll_check_not_null_")
+	return strings.HasPrefix(name, notNullConstraintPrefix)
+}
+
+func (o *OpAddColumn) UpColumns() []UpColumn {
+	if o.Up == nil {
+		return nil
+	}
+	return []UpColumn{{Table: o.Table, Column: TemporaryName(o.Column.Name), Up: *o.Up}}
+}
+
+func (o *OpAddColumn) Tables() []string {
+	return []string{o.Table}
 }
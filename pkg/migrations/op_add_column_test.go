@@ -4,6 +4,7 @@ package migrations_test
 
 import (
 	"database/sql"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -364,6 +365,11 @@ func TestAddColumnWithUpSql(t *testing.T) {
 				},
 			},
 			afterStart: func(t *testing.T, db *sql.DB) {
+				// The trigger of the table runs the up and down expressions.
+				TableTriggerMustExist(t, db, "public", "products", map[string]string{
+					migrations.TriggerFunctionName("products", "description"): "UPPER(name)",
+				})
+
 				// inserting via both the old and the new views works
 				MustInsert(t, db, "public", "01_add_table", "products", map[string]string{
 					"name": "apple",
@@ -389,6 +395,8 @@ func TestAddColumnWithUpSql(t *testing.T) {
 				// The trigger has been dropped.
 				triggerName := migrations.TriggerName("products", "description")
 				TriggerMustNotExist(t, db, "public", "products", triggerName)
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "products")
 			},
 			afterComplete: func(t *testing.T, db *sql.DB) {
 				// after rollback + restart + complete, all 'description' values are the backfilled ones.
@@ -405,6 +413,8 @@ func TestAddColumnWithUpSql(t *testing.T) {
 				// The trigger has been dropped.
 				triggerName := migrations.TriggerName("products", "description")
 				TriggerMustNotExist(t, db, "public", "products", triggerName)
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "products")
 			},
 		},
 		{
@@ -446,6 +456,11 @@ func TestAddColumnWithUpSql(t *testing.T) {
 				},
 			},
 			afterStart: func(t *testing.T, db *sql.DB) {
+				// The trigger of the table runs the up and down expressions.
+				TableTriggerMustExist(t, db, "public", "products", map[string]string{
+					migrations.TriggerFunctionName("products", "description"): "UPPER(name)",
+				})
+
 				// inserting via both the old and the new views works
 				MustInsert(t, db, "public", "01_add_table", "products", map[string]string{
 					"id":   "a",
@@ -473,6 +488,8 @@ func TestAddColumnWithUpSql(t *testing.T) {
 				// The trigger has been dropped.
 				triggerName := migrations.TriggerName("products", "description")
 				TriggerMustNotExist(t, db, "public", "products", triggerName)
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "products")
 			},
 			afterComplete: func(t *testing.T, db *sql.DB) {
 				// after rollback + restart + complete, all 'description' values are the backfilled ones.
@@ -489,6 +506,8 @@ func TestAddColumnWithUpSql(t *testing.T) {
 				// The trigger has been dropped.
 				triggerName := migrations.TriggerName("products", "description")
 				TriggerMustNotExist(t, db, "public", "products", triggerName)
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "products")
 			},
 		},
 	})
@@ -648,33 +667,208 @@ func TestAddColumnValidation(t *testing.T) {
 			},
 			wantStartErr: migrations.FieldRequiredError{Name: "up"},
 		},
+	})
+}
+
+func TestAddColumnToTableWithCompositePrimaryKey(t *testing.T) {
+	t.Parallel()
+
+	ExecuteTests(t, TestCases{{
+		name: "add column with up SQL to a table with a composite primary key",
+		migrations: []migrations.Migration{
+			{
+				Name: "01_add_table",
+				Operations: migrations.Operations{
+					&migrations.OpRawSQL{
+						Up: `CREATE TABLE orders(customer_id integer, order_id integer, name text, primary key (customer_id, order_id));
+							INSERT INTO orders SELECT i % 7, i, 'order ' || i FROM generate_series(1, 2500) AS i`,
+						Down: "DROP TABLE orders",
+					},
+				},
+			},
+			{
+				Name: "02_add_column",
+				Operations: migrations.Operations{
+					&migrations.OpAddColumn{
+						Table: "orders",
+						Up:    ptr("UPPER(name)"),
+						Column: migrations.Column{
+							Name:     "description",
+							Type:     "text",
+							Nullable: ptr(true),
+						},
+					},
+				},
+			},
+		},
+		afterStart: func(t *testing.T, db *sql.DB) {
+			// all rows, spanning several batches, have been backfilled
+			var count int
+			err := db.QueryRow(`SELECT count(*) FROM public_02_add_column.orders WHERE description = UPPER(name)`).Scan(&count)
+			assert.NoError(t, err)
+			assert.Equal(t, 2500, count)
+		},
+		afterRollback: func(t *testing.T, db *sql.DB) {},
+		afterComplete: func(t *testing.T, db *sql.DB) {
+			// the column has been backfilled
+			var count int
+			err := db.QueryRow(`SELECT count(*) FROM public.orders WHERE description = UPPER(name)`).Scan(&count)
+			assert.NoError(t, err)
+			assert.Equal(t, 2500, count)
+		},
+	}})
+}
+
+func TestAddColumnWithParallelBackfill(t *testing.T) {
+	t.Parallel()
+
+	allRowsBackfilled := func(schema string) func(t *testing.T, db *sql.DB) {
+		return func(t *testing.T, db *sql.DB) {
+			var count int
+			err := db.QueryRow(fmt.Sprintf(`SELECT count(*) FROM %s.orders WHERE description = UPPER(name)`, schema)).Scan(&count)
+			assert.NoError(t, err)
+			assert.Equal(t, 5000, count)
+		}
+	}
+
+	ExecuteTests(t, TestCases{
 		{
-			name: "table must have a primary key on exactly one column",
+			name: "backfill a table with a composite primary key using several workers",
 			migrations: []migrations.Migration{
 				{
 					Name: "01_add_table",
 					Operations: migrations.Operations{
 						&migrations.OpRawSQL{
-							Up:   "CREATE TABLE orders(id integer, order_id integer, name text, primary key (id, order_id))",
+							Up: `CREATE TABLE orders(customer_id integer, order_id integer, name text, primary key (customer_id, order_id));
+								INSERT INTO orders SELECT i % 7, i, 'order ' || i FROM generate_series(1, 5000) AS i;
+								ANALYZE orders`,
 							Down: "DROP TABLE orders",
 						},
 					},
 				},
 				{
-					Name: "02_add_column",
+					Name:     "02_add_column",
+					Backfill: &migrations.BackfillSettings{BatchSize: ptr(100), Workers: ptr(4)},
+					Operations: migrations.Operations{
+						&migrations.OpAddColumn{
+							Table: "orders",
+							Up:    ptr("UPPER(name)"),
+							Column: migrations.Column{
+								Name:     "description",
+								Type:     "text",
+								Nullable: ptr(true),
+							},
+						},
+					},
+				},
+			},
+			afterStart:    allRowsBackfilled("public_02_add_column"),
+			afterRollback: func(t *testing.T, db *sql.DB) {},
+			afterComplete: allRowsBackfilled("public"),
+		},
+		{
+			name: "backfill a table without a unique key using several workers",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_add_table",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: `CREATE TABLE orders(order_id integer, name text);
+								INSERT INTO orders SELECT i % 10, 'order ' || i FROM generate_series(1, 5000) AS i;
+								ANALYZE orders`,
+							Down: "DROP TABLE orders",
+						},
+					},
+				},
+				{
+					Name:     "02_add_column",
+					Backfill: &migrations.BackfillSettings{BatchSize: ptr(100), Workers: ptr(4)},
 					Operations: migrations.Operations{
 						&migrations.OpAddColumn{
 							Table: "orders",
 							Up:    ptr("UPPER(name)"),
 							Column: migrations.Column{
-								Name: "description",
-								Type: "text",
+								Name:     "description",
+								Type:     "text",
+								Nullable: ptr(true),
 							},
 						},
 					},
 				},
 			},
-			wantStartErr: migrations.InvalidPrimaryKeyError{Table: "orders", Fields: 2},
+			afterStart:    allRowsBackfilled("public_02_add_column"),
+			afterRollback: func(t *testing.T, db *sql.DB) {},
+			afterComplete: allRowsBackfilled("public"),
+		},
+	})
+}
+
+func TestAddColumnToTableWithoutPrimaryKey(t *testing.T) {
+	t.Parallel()
+
+	addColumnMigration := migrations.Migration{
+		Name: "02_add_column",
+		Operations: migrations.Operations{
+			&migrations.OpAddColumn{
+				Table: "orders",
+				Up:    ptr("UPPER(name)"),
+				Column: migrations.Column{
+					Name:     "description",
+					Type:     "text",
+					Nullable: ptr(true),
+				},
+			},
+		},
+	}
+
+	allRowsBackfilled := func(schema string) func(t *testing.T, db *sql.DB) {
+		return func(t *testing.T, db *sql.DB) {
+			var count int
+			err := db.QueryRow(fmt.Sprintf(`SELECT count(*) FROM %s.orders WHERE description = UPPER(name)`, schema)).Scan(&count)
+			assert.NoError(t, err)
+			assert.Equal(t, 2500, count)
+		}
+	}
+
+	ExecuteTests(t, TestCases{
+		{
+			name: "add column with up SQL to a table with a unique not null index",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_add_table",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: `CREATE TABLE orders(order_id integer NOT NULL, name text);
+								CREATE UNIQUE INDEX orders_order_id ON orders(order_id);
+								INSERT INTO orders SELECT i, 'order ' || i FROM generate_series(1, 2500) AS i`,
+							Down: "DROP TABLE orders",
+						},
+					},
+				},
+				addColumnMigration,
+			},
+			afterStart:    allRowsBackfilled("public_02_add_column"),
+			afterRollback: func(t *testing.T, db *sql.DB) {},
+			afterComplete: allRowsBackfilled("public"),
+		},
+		{
+			name: "add column with up SQL to a table without any unique key",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_add_table",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: `CREATE TABLE orders(order_id integer, name text);
+								INSERT INTO orders SELECT i % 10, 'order ' || i FROM generate_series(1, 2500) AS i`,
+							Down: "DROP TABLE orders",
+						},
+					},
+				},
+				addColumnMigration,
+			},
+			afterStart:    allRowsBackfilled("public_02_add_column"),
+			afterRollback: func(t *testing.T, db *sql.DB) {},
+			afterComplete: allRowsBackfilled("public"),
 		},
 	})
 }
@@ -5,16 +5,17 @@ package migrations
 import (
 	"context"
 	"database/sql"
+	"slices"
 
 	"github.com/xataio/pgroll/pkg/schema"
 )
 
 var _ Operation = (*OpAlterColumn)(nil)
 
-func (o *OpAlterColumn) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, cbs ...CallbackFn) error {
+func (o *OpAlterColumn) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, bf *BackfillConfig, cbs ...CallbackFn) error {
 	op := o.innerOperation()
 
-	return op.Start(ctx, conn, stateSchema, s, cbs...)
+	return op.Start(ctx, conn, stateSchema, s, bf, cbs...)
 }
 
 func (o *OpAlterColumn) Complete(ctx context.Context, conn *sql.DB, s *schema.Schema) error {
@@ -40,16 +41,11 @@ func (o *OpAlterColumn) Validate(ctx context.Context, s *schema.Schema) error {
 	if table == nil {
 		return TableDoesNotExistError{Name: o.Table}
 	}
-	if table.GetColumn(o.Column) == nil {
+	column := table.GetColumn(o.Column)
+	if column == nil {
 		return ColumnDoesNotExistError{Table: o.Table, Name: o.Column}
 	}
 
-	// Ensure that the column has a primary key defined on exactly one column.
-	pk := table.GetPrimaryKey()
-	if len(pk) != 1 {
-		return InvalidPrimaryKeyError{Table: o.Table, Fields: len(pk)}
-	}
-
 	// Apply any special validation rules for the inner operation
 	op := o.innerOperation()
 	if _, ok := op.(*OpRenameColumn); ok {
@@ -59,6 +55,17 @@ func (o *OpAlterColumn) Validate(ctx context.Context, s *schema.Schema) error {
 		if o.Down != nil {
 			return NoDownSQLAllowedError{}
 		}
+	} else if o.duplicatesColumn(s) {
+		// Duplicating the column would lose how the values of generated and
+		// identity columns are generated. The identity of a primary key column
+		// whose type is changed to another integer type is moved to the new
+		// column on completion.
+		if column.Generated != nil {
+			return ColumnIsGeneratedError{Table: o.Table, Name: o.Column}
+		}
+		if column.Identity != "" && !(o.Type != nil && isIntegerType(*o.Type) && slices.Contains(table.PrimaryKey, o.Column)) {
+			return ColumnIsIdentityError{Table: o.Table, Name: o.Column}
+		}
 	}
 
 	// Validate the inner operation in isolation
@@ -129,6 +136,20 @@ func (o *OpAlterColumn) innerOperation() Operation {
 	return nil
 }
 
+// duplicatesColumn returns true if the inner operation duplicates the column,
+// rather than changing it in place
+func (o *OpAlterColumn) duplicatesColumn(s *schema.Schema) bool {
+	switch op := o.innerOperation().(type) {
+	case *OpRenameColumn:
+		return false
+	case *OpChangeType:
+		return !op.changesInPlace(s)
+	case *OpSetNotNull:
+		return !op.inPlace()
+	}
+	return true
+}
+
 // numChanges returns the number of kinds of change that one 'alter column'
 // operation represents.
 func (o *OpAlterColumn) numChanges() int {
@@ -162,3 +183,21 @@ func ptrToStr(s *string) string {
 	}
 	return *s
 }
+
+func (o *OpAlterColumn) RecreatedViews(s *schema.Schema) []string {
+	if op, ok := o.innerOperation().(RecreatesViewsOperation); ok {
+		return op.RecreatedViews(s)
+	}
+	return nil
+}
+
+func (o *OpAlterColumn) UpColumns() []UpColumn {
+	if op, ok := o.innerOperation().(UpColumnsOperation); ok {
+		return op.UpColumns()
+	}
+	return nil
+}
+
+func (o *OpAlterColumn) Tables() []string {
+	return []string{o.Table}
+}
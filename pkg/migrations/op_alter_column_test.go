@@ -3,8 +3,10 @@
 package migrations_test
 
 import (
+	"database/sql"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/xataio/pgroll/pkg/migrations"
 )
 
@@ -155,14 +157,13 @@ func TestAlterColumnValidation(t *testing.T) {
 			wantStartErr: migrations.MultipleAlterColumnChangesError{Changes: 2},
 		},
 		{
-			name: "table must have a primary key on exactly one column",
+			name: "identity columns that aren't primary keys can't be duplicated",
 			migrations: []migrations.Migration{
 				{
-					Name: "01_add_table",
+					Name: "01_create_table",
 					Operations: migrations.Operations{
 						&migrations.OpRawSQL{
-							Up:   "CREATE TABLE orders(id integer, order_id integer, quantity integer, primary key (id, order_id))",
-							Down: "DROP TABLE orders",
+							Up: "CREATE TABLE items (id int GENERATED ALWAYS AS IDENTITY, price int)",
 						},
 					},
 				},
@@ -170,14 +171,81 @@ func TestAlterColumnValidation(t *testing.T) {
 					Name: "02_alter_column",
 					Operations: migrations.Operations{
 						&migrations.OpAlterColumn{
-							Table:  "orders",
-							Column: "quantity",
-							Name:   ptr("renamed_quantity"),
+							Table:  "items",
+							Column: "id",
+							Type:   ptr("bigint"),
+							Up:     ptr("id"),
+							Down:   ptr("id"),
 						},
 					},
 				},
 			},
-			wantStartErr: migrations.InvalidPrimaryKeyError{Table: "orders", Fields: 2},
+			wantStartErr: migrations.ColumnIsIdentityError{Table: "items", Name: "id"},
+		},
+		{
+			name: "generated columns can't be duplicated",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: "CREATE TABLE items (price int, total int GENERATED ALWAYS AS (price * 2) STORED)",
+						},
+					},
+				},
+				{
+					Name: "02_alter_column",
+					Operations: migrations.Operations{
+						&migrations.OpAlterColumn{
+							Table:  "items",
+							Column: "total",
+							Check: &migrations.CheckConstraint{
+								Name:       "total_positive",
+								Constraint: "total > 0",
+							},
+							Up:   ptr("total"),
+							Down: ptr("total"),
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.ColumnIsGeneratedError{Table: "items", Name: "total"},
+		},
+		{
+			name: "generated columns can be changed in place",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_create_table",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: "CREATE TABLE items (price int, total int GENERATED ALWAYS AS (price * 2) STORED)",
+						},
+					},
+				},
+				{
+					Name: "02_alter_column",
+					Operations: migrations.Operations{
+						&migrations.OpAlterColumn{
+							Table:    "items",
+							Column:   "total",
+							Nullable: ptr(false),
+							Up:       ptr("total"),
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB) {
+				// The column isn't duplicated
+				ColumnMustNotExist(t, db, "public", "items", migrations.TemporaryName("total"))
+			},
+			afterComplete: func(t *testing.T, db *sql.DB) {
+				// The column is still generated
+				MustInsert(t, db, "public", "02_alter_column", "items", map[string]string{
+					"price": "10",
+				})
+				rows := MustSelect(t, db, "public", "02_alter_column", "items")
+				assert.Equal(t, []map[string]any{{"price": 10, "total": 20}}, rows)
+			},
 		},
 	})
 }
@@ -6,6 +6,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 
 	"github.com/lib/pq"
 	"github.com/xataio/pgroll/pkg/schema"
@@ -21,10 +22,20 @@ type OpChangeType struct {
 
 var _ Operation = (*OpChangeType)(nil)
 
-func (o *OpChangeType) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, cbs ...CallbackFn) error {
+func (o *OpChangeType) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, bf *BackfillConfig, cbs ...CallbackFn) error {
 	table := s.GetTable(o.Table)
 	column := table.GetColumn(o.Column)
 
+	// A change that doesn't need a rewrite is made in place on completion.
+	// Until then the view of the new version exposes the column with its old
+	// type: casting it to the new type in the view would make the column read
+	// only, as postgres only writes through the columns of a view that are
+	// plain references to columns of the table. The column keeps its old type
+	// in the schema too, so that the schema describes the column as it is.
+	if o.changesInPlace(s) {
+		return nil
+	}
+
 	// Create a copy of the column on the underlying table.
 	d := NewColumnDuplicator(conn, table, column).WithType(o.Type)
 	if err := d.Duplicate(ctx); err != nil {
@@ -33,7 +44,7 @@ func (o *OpChangeType) Start(ctx context.Context, conn *sql.DB, stateSchema stri
 
 	// Add a trigger to copy values from the old column to the new, rewriting values using the `up` SQL.
 	err := createTrigger(ctx, conn, triggerConfig{
-		Name:           TriggerName(o.Table, o.Column),
+		Name:           TriggerFunctionName(o.Table, o.Column),
 		Direction:      TriggerDirectionUp,
 		Columns:        table.Columns,
 		SchemaName:     s.Name,
@@ -47,10 +58,18 @@ func (o *OpChangeType) Start(ctx context.Context, conn *sql.DB, stateSchema stri
 	}
 
 	// Backfill the new column with values from the old column.
-	if err := backfill(ctx, conn, table, cbs...); err != nil {
+	if err := backfill(ctx, conn, table, bf, cbs...); err != nil {
 		return fmt.Errorf("failed to backfill column: %w", err)
 	}
 
+	// If the column is part of the primary key, build the index for the new
+	// primary key on the new column.
+	if slices.Contains(table.PrimaryKey, o.Column) {
+		if err := duplicatePrimaryKey(ctx, conn, table, o.Column); err != nil {
+			return fmt.Errorf("failed to duplicate primary key: %w", err)
+		}
+	}
+
 	// Add the new column to the internal schema representation. This is done
 	// here, before creation of the down trigger, so that the trigger can declare
 	// a variable for the new column.
@@ -60,7 +79,7 @@ func (o *OpChangeType) Start(ctx context.Context, conn *sql.DB, stateSchema stri
 
 	// Add a trigger to copy values from the new column to the old, rewriting values using the `down` SQL.
 	err = createTrigger(ctx, conn, triggerConfig{
-		Name:           TriggerName(o.Table, TemporaryName(o.Column)),
+		Name:           TriggerFunctionName(o.Table, TemporaryName(o.Column)),
 		Direction:      TriggerDirectionDown,
 		Columns:        table.Columns,
 		SchemaName:     s.Name,
@@ -77,32 +96,42 @@ func (o *OpChangeType) Start(ctx context.Context, conn *sql.DB, stateSchema stri
 }
 
 func (o *OpChangeType) Complete(ctx context.Context, conn *sql.DB, s *schema.Schema) error {
-	// Remove the up function and trigger
-	_, err := conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, o.Column))))
-	if err != nil {
+	if o.changesInPlace(s) {
+		_, err := conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s ALTER COLUMN %s TYPE %s",
+			pq.QuoteIdentifier(o.Table),
+			pq.QuoteIdentifier(o.Column),
+			o.Type))
 		return err
 	}
 
-	// Remove the down function and trigger
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, TemporaryName(o.Column)))))
+	// Remove the up trigger
+	err := dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, o.Column))
 	if err != nil {
 		return err
 	}
 
-	// Drop the old column
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s DROP COLUMN IF EXISTS %s",
-		pq.QuoteIdentifier(o.Table),
-		pq.QuoteIdentifier(o.Column)))
+	// Remove the down trigger
+	err = dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, TemporaryName(o.Column)))
 	if err != nil {
 		return err
 	}
 
-	// Rename the new column to the old column name
 	table := s.GetTable(o.Table)
-	column := table.GetColumn(o.Column)
-	if err := RenameDuplicatedColumn(ctx, conn, table, column); err != nil {
+	if slices.Contains(table.PrimaryKey, o.Column) {
+		// Replace the old column with the new one and move the primary key,
+		// the foreign keys referencing the column and its sequence over
+		if err := swapPrimaryKey(ctx, conn, table, o.Column, o.Type); err != nil {
+			return err
+		}
+	} else {
+		// Drop the old column
+		if err := dropOriginalColumn(ctx, conn, o.Table, o.Column); err != nil {
+			return err
+		}
+	}
+
+	// Rename the new column to the old column name
+	if err := RenameDuplicatedColumn(ctx, conn, table, o.Column); err != nil {
 		return err
 	}
 
@@ -119,18 +148,14 @@ func (o *OpChangeType) Rollback(ctx context.Context, conn *sql.DB) error {
 		return err
 	}
 
-	// Remove the up function and trigger
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, o.Column)),
-	))
+	// Remove the up trigger
+	err = dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, o.Column))
 	if err != nil {
 		return err
 	}
 
-	// Remove the down function and trigger
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, TemporaryName(o.Column))),
-	))
+	// Remove the down trigger
+	err = dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, TemporaryName(o.Column)))
 
 	return err
 }
@@ -143,5 +168,51 @@ func (o *OpChangeType) Validate(ctx context.Context, s *schema.Schema) error {
 	if o.Down == "" {
 		return FieldRequiredError{Name: "down"}
 	}
+
+	if !typeExists(s, o.Type) {
+		return TypeDoesNotExistError{Name: o.Type}
+	}
+	return nil
+}
+
+// RecreatedViews returns the table of the column when its type is changed in
+// place, as postgres can't change the type of a column used by a view.
+func (o *OpChangeType) RecreatedViews(s *schema.Schema) []string {
+	if o.changesInPlace(s) {
+		return []string{o.Table}
+	}
 	return nil
 }
+
+func (o *OpChangeType) UpColumns() []UpColumn {
+	// an `up` that is the column itself copies the values unchanged, possibly
+	// to the same column if its type is changed in place
+	if isColumnReference(o.Up, o.Column) {
+		return nil
+	}
+	return []UpColumn{{Table: o.Table, Column: TemporaryName(o.Column), Up: o.Up}}
+}
+
+// changesInPlace returns true if the type of the column can be changed without
+// a rewrite of the table, with an `up` SQL that keeps the values unchanged. The
+// column is then altered on completion instead of being duplicated and
+// backfilled. Primary key columns are always duplicated, as changing them in
+// place would also alter the foreign keys referencing them.
+func (o *OpChangeType) changesInPlace(s *schema.Schema) bool {
+	table := s.GetTable(o.Table)
+	if table == nil || slices.Contains(table.PrimaryKey, o.Column) {
+		return false
+	}
+
+	// the change is already being made by duplicating the column
+	if table.GetColumn(TemporaryName(o.Column)) != nil {
+		return false
+	}
+
+	column := table.GetColumn(o.Column)
+	if column == nil {
+		return false
+	}
+
+	return isColumnReference(o.Up, o.Column) && IsBinaryCoercible(column.Type, o.Type)
+}
@@ -4,6 +4,9 @@ package migrations_test
 
 import (
 	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -61,6 +64,12 @@ func TestChangeColumnType(t *testing.T) {
 				},
 			},
 			afterStart: func(t *testing.T, db *sql.DB) {
+				// The trigger of the table runs the up and down expressions.
+				TableTriggerMustExist(t, db, "public", "reviews", map[string]string{
+					migrations.TriggerFunctionName("reviews", "rating"):                           "CAST (rating AS integer)",
+					migrations.TriggerFunctionName("reviews", migrations.TemporaryName("rating")): "CAST (rating AS text)",
+				})
+
 				newVersionSchema := roll.VersionedSchemaName("public", "02_change_type")
 
 				// The new (temporary) `rating` column should exist on the underlying table.
@@ -111,6 +120,8 @@ func TestChangeColumnType(t *testing.T) {
 				TriggerMustNotExist(t, db, "public", "reviews", migrations.TriggerName("reviews", "rating"))
 				// The down trigger no longer exists.
 				TriggerMustNotExist(t, db, "public", "reviews", migrations.TriggerName("reviews", migrations.TemporaryName("rating")))
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "reviews")
 			},
 			afterComplete: func(t *testing.T, db *sql.DB) {
 				newVersionSchema := roll.VersionedSchemaName("public", "02_change_type")
@@ -145,6 +156,8 @@ func TestChangeColumnType(t *testing.T) {
 				TriggerMustNotExist(t, db, "public", "reviews", migrations.TriggerName("reviews", "rating"))
 				// The down trigger no longer exists.
 				TriggerMustNotExist(t, db, "public", "reviews", migrations.TriggerName("reviews", migrations.TemporaryName("rating")))
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "reviews")
 			},
 		},
 		{
@@ -223,6 +236,47 @@ func TestChangeColumnType(t *testing.T) {
 				ValidatedForeignKeyMustExist(t, db, "public", "employees", "fk_employee_department")
 			},
 		},
+		{
+			name: "changing column type preserves the actions and deferrability of foreign keys on the column",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_add_tables",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: `CREATE TABLE departments (id serial PRIMARY KEY);
+								CREATE TABLE employees (
+									id serial PRIMARY KEY,
+									department_id integer CONSTRAINT fk_employee_department REFERENCES departments (id)
+										ON DELETE CASCADE ON UPDATE SET NULL DEFERRABLE INITIALLY DEFERRED
+								)`,
+						},
+					},
+				},
+				{
+					Name: "02_change_type",
+					Operations: migrations.Operations{
+						&migrations.OpAlterColumn{
+							Table:  "employees",
+							Column: "department_id",
+							Type:   ptr("bigint"),
+							Up:     ptr("department_id"),
+							Down:   ptr("department_id"),
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB) {
+				// The temporary FK constraint has the same actions and deferrability
+				ForeignKeyDefinitionMustBe(t, db, "public", "employees", migrations.DuplicationName("fk_employee_department"),
+					"FOREIGN KEY (_pgroll_new_department_id) REFERENCES departments(id) ON UPDATE SET NULL ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED")
+			},
+			afterRollback: func(t *testing.T, db *sql.DB) {
+			},
+			afterComplete: func(t *testing.T, db *sql.DB) {
+				ForeignKeyDefinitionMustBe(t, db, "public", "employees", "fk_employee_department",
+					"FOREIGN KEY (department_id) REFERENCES departments(id) ON UPDATE SET NULL ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED")
+			},
+		},
 		{
 			name: "changing column type preserves any defaults on the column",
 			migrations: []migrations.Migration{
@@ -396,6 +450,73 @@ func TestChangeColumnType(t *testing.T) {
 				}, testutils.NotNullViolationErrorCode)
 			},
 		},
+		{
+			name: "changing column type restores long constraint names",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_add_table",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTable{
+							Name: "users",
+							Columns: []migrations.Column{
+								{
+									Name: "id",
+									Type: "integer",
+									Pk:   ptr(true),
+								},
+								{
+									Name:     strings.Repeat("u", 60),
+									Type:     "text",
+									Nullable: ptr(true),
+									Check: &migrations.CheckConstraint{
+										Name:       strings.Repeat("c", 60),
+										Constraint: fmt.Sprintf("length(%s) > 3", strings.Repeat("u", 60)),
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Name: "02_change_type",
+					Operations: migrations.Operations{
+						&migrations.OpAlterColumn{
+							Table:  "users",
+							Column: strings.Repeat("u", 60),
+							Type:   ptr("varchar(255)"),
+							Up:     ptr(strings.Repeat("u", 60)),
+							Down:   ptr(strings.Repeat("u", 60)),
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB) {
+				// The new column and the duplicated check constraint have shortened names
+				ColumnMustExist(t, db, "public", "users", migrations.TemporaryName(strings.Repeat("u", 60)))
+				CheckConstraintMustExist(t, db, "public", "users", migrations.DuplicationName(strings.Repeat("c", 60)))
+
+				// Inserting a row that violates the check constraint should fail.
+				MustNotInsert(t, db, "public", "02_change_type", "users", map[string]string{
+					"id":                    "1",
+					strings.Repeat("u", 60): "a",
+				}, testutils.CheckViolationErrorCode)
+			},
+			afterRollback: func(t *testing.T, db *sql.DB) {
+				ColumnMustNotExist(t, db, "public", "users", migrations.TemporaryName(strings.Repeat("u", 60)))
+			},
+			afterComplete: func(t *testing.T, db *sql.DB) {
+				// The column and the check constraint have their original names
+				ColumnMustNotExist(t, db, "public", "users", migrations.TemporaryName(strings.Repeat("u", 60)))
+				CheckConstraintMustExist(t, db, "public", "users", strings.Repeat("c", 60))
+				CheckConstraintMustNotExist(t, db, "public", "users", migrations.DuplicationName(strings.Repeat("c", 60)))
+
+				// Inserting a row that violates the check constraint should fail.
+				MustNotInsert(t, db, "public", "02_change_type", "users", map[string]string{
+					"id":                    "2",
+					strings.Repeat("u", 60): "b",
+				}, testutils.CheckViolationErrorCode)
+			},
+		},
 		{
 			name: "changing column type preserves any unique constraints on the column",
 			migrations: []migrations.Migration{
@@ -457,6 +578,342 @@ func TestChangeColumnType(t *testing.T) {
 				})
 			},
 		},
+		{
+			name: "changing the type of a primary key column preserves the primary key and the foreign keys referencing it",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_add_tables",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTable{
+							Name: "users",
+							Columns: []migrations.Column{
+								{
+									Name: "id",
+									Type: "serial",
+									Pk:   ptr(true),
+								},
+								{
+									Name: "name",
+									Type: "text",
+								},
+							},
+						},
+						&migrations.OpCreateTable{
+							Name: "posts",
+							Columns: []migrations.Column{
+								{
+									Name: "id",
+									Type: "serial",
+									Pk:   ptr(true),
+								},
+								{
+									Name: "user_id",
+									Type: "integer",
+									References: &migrations.ForeignKeyReference{
+										Name:   "fk_users_id",
+										Table:  "users",
+										Column: "id",
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Name: "02_change_type",
+					Operations: migrations.Operations{
+						&migrations.OpAlterColumn{
+							Table:  "users",
+							Column: "id",
+							Type:   ptr("bigint"),
+							Up:     ptr("id"),
+							Down:   ptr("id"),
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB) {
+				// The new (temporary) `id` column should exist on the underlying table.
+				ColumnMustExist(t, db, "public", "users", migrations.TemporaryName("id"))
+
+				// The index backing the new primary key exists.
+				IndexMustExist(t, db, "public", "users", migrations.DuplicationName("users_pkey"))
+
+				// Inserting into the old and the new version works
+				MustInsert(t, db, "public", "01_add_tables", "users", map[string]string{
+					"name": "alice",
+				})
+				MustInsert(t, db, "public", "02_change_type", "users", map[string]string{
+					"name": "bob",
+				})
+
+				// Inserting a row with a duplicate `id` value fails
+				MustNotInsert(t, db, "public", "02_change_type", "users", map[string]string{
+					"id":   "1",
+					"name": "carl",
+				}, testutils.UniqueViolationErrorCode)
+
+				// Rows referencing the users can be inserted
+				MustInsert(t, db, "public", "02_change_type", "posts", map[string]string{
+					"user_id": "1",
+				})
+			},
+			afterRollback: func(t *testing.T, db *sql.DB) {
+				// The new (temporary) `id` column should not exist on the underlying table.
+				ColumnMustNotExist(t, db, "public", "users", migrations.TemporaryName("id"))
+
+				// The index backing the new primary key has been removed.
+				IndexMustNotExist(t, db, "public", "users", migrations.DuplicationName("users_pkey"))
+
+				// The foreign key referencing the column is untouched.
+				ValidatedForeignKeyMustExist(t, db, "public", "posts", "fk_users_id")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB) {
+				newVersionSchema := roll.VersionedSchemaName("public", "02_change_type")
+
+				// The new (temporary) `id` column should not exist on the underlying table.
+				ColumnMustNotExist(t, db, "public", "users", migrations.TemporaryName("id"))
+
+				// The `id` column has the new type.
+				ColumnMustHaveType(t, db, newVersionSchema, "users", "id", "bigint")
+
+				// The primary key has been moved to the new column.
+				IndexMustExist(t, db, "public", "users", "users_pkey")
+				IndexMustNotExist(t, db, "public", "users", migrations.DuplicationName("users_pkey"))
+
+				// The foreign key referencing the column has been re-created and validated.
+				ValidatedForeignKeyMustExist(t, db, "public", "posts", "fk_users_id")
+
+				// Inserting a row with a duplicate `id` value fails
+				MustNotInsert(t, db, "public", "02_change_type", "users", map[string]string{
+					"id":   "1",
+					"name": "dana",
+				}, testutils.UniqueViolationErrorCode)
+
+				// The sequence of the column still generates the values of new rows
+				MustInsert(t, db, "public", "02_change_type", "users", map[string]string{
+					"name": "erin",
+				})
+
+				// Rows referencing a missing user can't be inserted
+				MustNotInsert(t, db, "public", "02_change_type", "posts", map[string]string{
+					"user_id": "100",
+				}, testutils.FKViolationErrorCode)
+
+				// Rows referencing an existing user can be inserted
+				MustInsert(t, db, "public", "02_change_type", "posts", map[string]string{
+					"user_id": "2",
+				})
+			},
+		},
+		{
+			name: "changing the type of an identity primary key column keeps the identity",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_add_tables",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: `CREATE TABLE users (id integer GENERATED ALWAYS AS IDENTITY PRIMARY KEY, name text);
+								CREATE TABLE posts (id serial PRIMARY KEY, user_id integer CONSTRAINT fk_users_id REFERENCES users (id))`,
+						},
+					},
+				},
+				{
+					Name: "02_change_type",
+					Operations: migrations.Operations{
+						&migrations.OpAlterColumn{
+							Table:  "users",
+							Column: "id",
+							Type:   ptr("bigint"),
+							Up:     ptr("id"),
+							Down:   ptr("id"),
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB) {
+				// Inserting into the old and the new version works
+				MustInsert(t, db, "public", "01_add_tables", "users", map[string]string{
+					"name": "alice",
+				})
+				MustInsert(t, db, "public", "02_change_type", "users", map[string]string{
+					"name": "bob",
+				})
+			},
+			afterRollback: func(t *testing.T, db *sql.DB) {
+				ColumnMustNotExist(t, db, "public", "users", migrations.TemporaryName("id"))
+			},
+			afterComplete: func(t *testing.T, db *sql.DB) {
+				newVersionSchema := roll.VersionedSchemaName("public", "02_change_type")
+
+				// The `id` column has the new type, and the primary key and the
+				// foreign key referencing it have been moved over.
+				ColumnMustHaveType(t, db, newVersionSchema, "users", "id", "bigint")
+				IndexMustExist(t, db, "public", "users", "users_pkey")
+				ValidatedForeignKeyMustExist(t, db, "public", "posts", "fk_users_id")
+
+				// The column is still an identity column, which generates values
+				// that follow the existing ones
+				MustInsert(t, db, "public", "02_change_type", "users", map[string]string{
+					"name": "carl",
+				})
+
+				ids := map[string]int{}
+				for _, row := range MustSelect(t, db, "public", "02_change_type", "users") {
+					ids[row["name"].(string)] = row["id"].(int)
+				}
+				assert.Len(t, ids, 3)
+				assert.Greater(t, ids["carl"], ids["alice"])
+				assert.Greater(t, ids["carl"], ids["bob"])
+
+				// Rows referencing an existing user can be inserted
+				MustInsert(t, db, "public", "02_change_type", "posts", map[string]string{
+					"user_id": strconv.Itoa(ids["carl"]),
+				})
+			},
+		},
+		{
+			name: "changing the type of a primary key column leaves the foreign keys that were not valid unvalidated",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_add_tables",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: `CREATE TABLE users (id serial PRIMARY KEY, name text);
+								CREATE TABLE posts (id serial PRIMARY KEY, user_id integer);
+								CREATE TABLE comments (id serial PRIMARY KEY, user_id integer);
+								INSERT INTO comments (user_id) VALUES (100);
+								ALTER TABLE posts ADD CONSTRAINT fk_users_id FOREIGN KEY (user_id) REFERENCES users (id);
+								ALTER TABLE comments ADD CONSTRAINT fk_comments_users_id FOREIGN KEY (user_id) REFERENCES users (id) NOT VALID;
+								COMMENT ON CONSTRAINT fk_users_id ON posts IS 'the author of the post'`,
+						},
+					},
+				},
+				{
+					Name: "02_change_type",
+					Operations: migrations.Operations{
+						&migrations.OpAlterColumn{
+							Table:  "users",
+							Column: "id",
+							Type:   ptr("bigint"),
+							Up:     ptr("id"),
+							Down:   ptr("id"),
+						},
+					},
+				},
+			},
+			afterRollback: func(t *testing.T, db *sql.DB) {
+				ValidatedForeignKeyMustExist(t, db, "public", "posts", "fk_users_id")
+				NotValidatedForeignKeyMustExist(t, db, "public", "comments", "fk_comments_users_id")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB) {
+				// The foreign key that was valid has been validated again, and keeps
+				// its comment.
+				ValidatedForeignKeyMustExist(t, db, "public", "posts", "fk_users_id")
+
+				var comment sql.NullString
+				err := db.QueryRow(`SELECT obj_description(oid, 'pg_constraint')
+					FROM pg_catalog.pg_constraint
+					WHERE conrelid = 'public.posts'::regclass AND conname = 'fk_users_id'`).Scan(&comment)
+				assert.NoError(t, err)
+				assert.Equal(t, sql.NullString{String: "the author of the post", Valid: true}, comment)
+
+				// The foreign key that was left NOT VALID, which the existing rows
+				// violate, is still not valid.
+				NotValidatedForeignKeyMustExist(t, db, "public", "comments", "fk_comments_users_id")
+			},
+		},
+		{
+			name: "binary coercible type changes are made in place on completion",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_add_table",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTable{
+							Name: "users",
+							Columns: []migrations.Column{
+								{
+									Name: "id",
+									Type: "serial",
+									Pk:   ptr(true),
+								},
+								{
+									Name:     "username",
+									Type:     "varchar(50)",
+									Nullable: ptr(true),
+								},
+							},
+						},
+					},
+				},
+				{
+					Name: "02_change_type",
+					Operations: migrations.Operations{
+						&migrations.OpAlterColumn{
+							Table:  "users",
+							Column: "username",
+							Type:   ptr("text"),
+							Up:     ptr("username"),
+							Down:   ptr("username"),
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB) {
+				// The column is not duplicated and no triggers are created.
+				ColumnMustNotExist(t, db, "public", "users", migrations.TemporaryName("username"))
+				TriggerMustNotExist(t, db, "public", "users", migrations.TriggerName("users", "username"))
+				// No trigger is created for the table.
+				TableTriggerMustNotExist(t, db, "public", "users")
+
+				// The new view exposes the column with its old type until completion.
+				ColumnMustHaveType(t, db, roll.VersionedSchemaName("public", "02_change_type"), "users", "username", "character varying")
+
+				// Inserting into the old and the new version works
+				MustInsert(t, db, "public", "01_add_table", "users", map[string]string{
+					"username": "alice",
+				})
+				MustInsert(t, db, "public", "02_change_type", "users", map[string]string{
+					"username": "bob",
+				})
+
+				// Both versions see the rows inserted through the other one
+				rows := MustSelect(t, db, "public", "01_add_table", "users")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "username": "alice"},
+					{"id": 2, "username": "bob"},
+				}, rows)
+			},
+			afterRollback: func(t *testing.T, db *sql.DB) {
+				// The column keeps its type.
+				ColumnMustHaveType(t, db, "public", "users", "username", "character varying")
+
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "users")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB) {
+				newVersionSchema := roll.VersionedSchemaName("public", "02_change_type")
+
+				// The column and the new view have the new type.
+				ColumnMustHaveType(t, db, "public", "users", "username", "text")
+				ColumnMustHaveType(t, db, newVersionSchema, "users", "username", "text")
+
+				// Values longer than the old limit can be inserted into the new view
+				MustInsert(t, db, "public", "02_change_type", "users", map[string]string{
+					"username": strings.Repeat("c", 100),
+				})
+
+				rows := MustSelect(t, db, "public", "02_change_type", "users")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "username": "alice"},
+					{"id": 2, "username": "bob"},
+					{"id": 3, "username": strings.Repeat("c", 100)},
+				}, rows)
+
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "users")
+			},
+		},
 	})
 }
 
@@ -528,5 +985,24 @@ func TestChangeColumnTypeValidation(t *testing.T) {
 			},
 			wantStartErr: migrations.FieldRequiredError{Name: "down"},
 		},
+		{
+			name: "the new type must exist",
+			migrations: []migrations.Migration{
+				createTableMigration,
+				{
+					Name: "02_change_type",
+					Operations: migrations.Operations{
+						&migrations.OpAlterColumn{
+							Table:  "reviews",
+							Column: "rating",
+							Type:   ptr("rating_level"),
+							Up:     ptr("CAST (rating AS rating_level)"),
+							Down:   ptr("CAST (rating AS text)"),
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.TypeDoesNotExistError{Name: "rating_level"},
+		},
 	})
 }
@@ -36,7 +36,7 @@ const (
 const temporaryPrefix = "_pgroll_new_"
 
 func TemporaryName(name string) string {
-	return temporaryPrefix + name
+	return Identifier(temporaryPrefix, name)
 }
 
 func ReadMigration(r io.Reader) (*Migration, error) {
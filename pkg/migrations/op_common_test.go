@@ -5,6 +5,7 @@ package migrations_test
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"testing"
@@ -181,6 +182,29 @@ func TriggerMustNotExist(t *testing.T, db *sql.DB, schema, table, trigger string
 	}
 }
 
+// TableTriggerMustExist checks that the trigger keeping the columns of the
+// table in sync exists and runs exactly the given up and down expressions, by
+// name
+func TableTriggerMustExist(t *testing.T, db *sql.DB, schema, table string, expressions map[string]string) {
+	t.Helper()
+	if !triggerExists(t, db, schema, table, migrations.TableTriggerName(table)) {
+		t.Fatalf("Expected trigger %q to exist", migrations.TableTriggerName(table))
+	}
+	if got := tableTriggerExpressions(t, db, schema, table); !maps.Equal(got, expressions) {
+		t.Fatalf("Expected trigger %q to run expressions %v, got %v", migrations.TableTriggerName(table), expressions, got)
+	}
+}
+
+func TableTriggerMustNotExist(t *testing.T, db *sql.DB, schema, table string) {
+	t.Helper()
+	if triggerExists(t, db, schema, table, migrations.TableTriggerName(table)) {
+		t.Fatalf("Expected trigger %q to not exist", migrations.TableTriggerName(table))
+	}
+	if functionExists(t, db, schema, migrations.TableTriggerName(table)) {
+		t.Fatalf("Expected function %q to not exist", migrations.TableTriggerName(table))
+	}
+}
+
 func CheckConstraintMustNotExist(t *testing.T, db *sql.DB, schema, table, constraint string) {
 	t.Helper()
 	if checkConstraintExists(t, db, schema, table, constraint) {
@@ -195,6 +219,13 @@ func CheckConstraintMustExist(t *testing.T, db *sql.DB, schema, table, constrain
 	}
 }
 
+func UniqueConstraintMustExist(t *testing.T, db *sql.DB, schema, table, constraint string) {
+	t.Helper()
+	if !uniqueConstraintExists(t, db, schema, table, constraint) {
+		t.Fatalf("Expected unique constraint %q to exist", constraint)
+	}
+}
+
 func ValidatedForeignKeyMustExist(t *testing.T, db *sql.DB, schema, table, constraint string) {
 	t.Helper()
 	if !foreignKeyExists(t, db, schema, table, constraint, true) {
@@ -209,6 +240,61 @@ func NotValidatedForeignKeyMustExist(t *testing.T, db *sql.DB, schema, table, co
 	}
 }
 
+func ForeignKeyDefinitionMustBe(t *testing.T, db *sql.DB, schema, table, constraint, definition string) {
+	t.Helper()
+
+	var actualDefinition string
+	err := db.QueryRow(`
+    SELECT pg_get_constraintdef(oid)
+    FROM pg_catalog.pg_constraint
+    WHERE conrelid = $1::regclass
+    AND conname = $2
+    AND contype = 'f'`,
+		fmt.Sprintf("%s.%s", schema, table), constraint).Scan(&actualDefinition)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if definition != actualDefinition {
+		t.Fatalf("Expected foreign key %q to be defined as %q, got %q", constraint, definition, actualDefinition)
+	}
+}
+
+// ColumnAttributes are the attributes of a column that are kept when the
+// column is duplicated
+type ColumnAttributes struct {
+	Collation        string
+	Storage          string
+	StatisticsTarget int
+	Comment          string
+	PublicSelect     bool
+}
+
+func ColumnAttributesMustBe(t *testing.T, db *sql.DB, schema, table, column string, want ColumnAttributes) {
+	t.Helper()
+
+	var got ColumnAttributes
+	err := db.QueryRow(`
+    SELECT
+      attcollation::regcollation::text,
+      attstorage,
+      COALESCE(attstattarget, -1),
+      COALESCE(col_description(attrelid, attnum), ''),
+      has_column_privilege('public', attrelid, attnum, 'SELECT')
+    FROM pg_catalog.pg_attribute
+    WHERE attrelid = $1::regclass
+    AND attname = $2`,
+		fmt.Sprintf("%s.%s", schema, table), column).Scan(
+		&got.Collation, &got.Storage, &got.StatisticsTarget, &got.Comment, &got.PublicSelect)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != want {
+		t.Fatalf("Expected column %q to have attributes %+v, got %+v", column, want, got)
+	}
+}
+
 func IndexMustExist(t *testing.T, db *sql.DB, schema, table, index string) {
 	t.Helper()
 	if !indexExists(t, db, schema, table, index) {
@@ -284,6 +370,26 @@ func checkConstraintExists(t *testing.T, db *sql.DB, schema, table, constraint s
 	return exists
 }
 
+func uniqueConstraintExists(t *testing.T, db *sql.DB, schema, table, constraint string) bool {
+	t.Helper()
+
+	var exists bool
+	err := db.QueryRow(`
+    SELECT EXISTS (
+      SELECT 1
+      FROM pg_catalog.pg_constraint
+      WHERE conrelid = $1::regclass
+      AND conname = $2
+      AND contype = 'u'
+    )`,
+		fmt.Sprintf("%s.%s", schema, table), constraint).Scan(&exists)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return exists
+}
+
 func foreignKeyExists(t *testing.T, db *sql.DB, schema, table, constraint string, validated bool) bool {
 	t.Helper()
 
@@ -324,6 +430,34 @@ func triggerExists(t *testing.T, db *sql.DB, schema, table, trigger string) bool
 	return exists
 }
 
+// tableTriggerExpressions returns the SQL of the up and down expressions run
+// by the trigger of the table, by name, as recorded in the comment of its
+// function
+func tableTriggerExpressions(t *testing.T, db *sql.DB, schema, table string) map[string]string {
+	t.Helper()
+
+	var description sql.NullString
+	err := db.QueryRow("SELECT obj_description(to_regprocedure($1)::oid, 'pg_proc')",
+		fmt.Sprintf("%s.%s()", pq.QuoteIdentifier(schema), pq.QuoteIdentifier(migrations.TableTriggerName(table)))).Scan(&description)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var triggers []struct {
+		Name string `json:"name"`
+		SQL  string `json:"sql"`
+	}
+	if err := json.Unmarshal([]byte(description.String), &triggers); err != nil {
+		t.Fatalf("Failed to read the configuration of trigger %q: %v", migrations.TableTriggerName(table), err)
+	}
+
+	expressions := make(map[string]string, len(triggers))
+	for _, trigger := range triggers {
+		expressions[trigger.Name] = trigger.SQL
+	}
+	return expressions
+}
+
 func functionExists(t *testing.T, db *sql.DB, schema, functionName string) bool {
 	t.Helper()
 
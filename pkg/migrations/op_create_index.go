@@ -14,7 +14,11 @@ import (
 
 var _ Operation = (*OpCreateIndex)(nil)
 
-func (o *OpCreateIndex) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, cbs ...CallbackFn) error {
+func (o *OpCreateIndex) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, bf *BackfillConfig, cbs ...CallbackFn) error {
+	if err := dropInvalidIndex(ctx, conn, o.Name); err != nil {
+		return err
+	}
+
 	// create index concurrently
 	_, err := conn.ExecContext(ctx, fmt.Sprintf("CREATE INDEX CONCURRENTLY IF NOT EXISTS %s ON %s (%s)",
 		pq.QuoteIdentifier(o.Name),
@@ -40,6 +44,10 @@ func (o *OpCreateIndex) Validate(ctx context.Context, s *schema.Schema) error {
 		return FieldRequiredError{Name: "name"}
 	}
 
+	if err := ValidateIdentifier(o.Name); err != nil {
+		return err
+	}
+
 	table := s.GetTable(o.Table)
 	if table == nil {
 		return TableDoesNotExistError{Name: o.Table}
@@ -68,3 +76,7 @@ func quoteColumnNames(columns []string) (quoted []string) {
 	}
 	return quoted
 }
+
+func (o *OpCreateIndex) Tables() []string {
+	return []string{o.Table}
+}
@@ -13,9 +13,9 @@ import (
 
 var _ Operation = (*OpCreateTable)(nil)
 
-func (o *OpCreateTable) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, cbs ...CallbackFn) error {
+func (o *OpCreateTable) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, bf *BackfillConfig, cbs ...CallbackFn) error {
 	tempName := TemporaryName(o.Name)
-	_, err := conn.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (%s)",
+	_, err := conn.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)",
 		pq.QuoteIdentifier(tempName),
 		columnsToSQL(o.Columns)))
 	if err != nil {
@@ -39,9 +39,10 @@ func (o *OpCreateTable) Start(ctx context.Context, conn *sql.DB, stateSchema str
 	}
 
 	columns := make(map[string]schema.Column, len(o.Columns))
-	for _, col := range o.Columns {
+	for i, col := range o.Columns {
 		columns[col.Name] = schema.Column{
-			Name: col.Name,
+			Name:     col.Name,
+			Position: i + 1,
 		}
 	}
 
@@ -74,8 +75,19 @@ func (o *OpCreateTable) Validate(ctx context.Context, s *schema.Schema) error {
 	if table != nil {
 		return TableAlreadyExistsError{Name: o.Name}
 	}
+	if s.RelationExists(o.Name) {
+		return RelationAlreadyExistsError{Name: o.Name}
+	}
+
+	if err := ValidateIdentifier(o.Name); err != nil {
+		return err
+	}
 
 	for _, col := range o.Columns {
+		if err := ValidateIdentifier(col.Name); err != nil {
+			return err
+		}
+
 		// Ensure that any foreign key references are valid, ie. the referenced
 		// table and column exist.
 		if col.References != nil {
@@ -142,3 +154,7 @@ func ColumnToSQL(col Column) string {
 	}
 	return sql
 }
+
+func (o *OpCreateTable) Tables() []string {
+	return []string{o.Name}
+}
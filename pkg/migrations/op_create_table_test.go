@@ -4,6 +4,7 @@ package migrations_test
 
 import (
 	"database/sql"
+	"strings"
 	"testing"
 
 	"github.com/xataio/pgroll/pkg/migrations"
@@ -336,5 +337,57 @@ func TestCreateTableValidation(t *testing.T) {
 			Column: "user_id",
 			Err:    migrations.ColumnDoesNotExistError{Table: "users", Name: "doesntexist"},
 		},
+	}, TestCase{
+		name: "column names must fit in an identifier",
+		migrations: []migrations.Migration{
+			{
+				Name: "01_create_table",
+				Operations: migrations.Operations{
+					&migrations.OpCreateTable{
+						Name: "users",
+						Columns: []migrations.Column{
+							{
+								Name: "id",
+								Type: "serial",
+								Pk:   ptr(true),
+							},
+							{
+								Name: strings.Repeat("a", 64),
+								Type: "text",
+							},
+						},
+					},
+				},
+			},
+		},
+		wantStartErr: migrations.IdentifierTooLongError{Name: strings.Repeat("a", 64)},
+	}, TestCase{
+		name: "table name must not be taken by a type",
+		migrations: []migrations.Migration{
+			{
+				Name: "01_create_type",
+				Operations: migrations.Operations{
+					&migrations.OpRawSQL{
+						Up: "CREATE TYPE mood AS ENUM ('sad', 'happy')",
+					},
+				},
+			},
+			{
+				Name: "02_create_table",
+				Operations: migrations.Operations{
+					&migrations.OpCreateTable{
+						Name: "mood",
+						Columns: []migrations.Column{
+							{
+								Name: "id",
+								Type: "serial",
+								Pk:   ptr(true),
+							},
+						},
+					},
+				},
+			},
+		},
+		wantStartErr: migrations.RelationAlreadyExistsError{Name: "mood"},
 	}})
 }
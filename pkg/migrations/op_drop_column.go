@@ -13,10 +13,10 @@ import (
 
 var _ Operation = (*OpDropColumn)(nil)
 
-func (o *OpDropColumn) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, cbs ...CallbackFn) error {
+func (o *OpDropColumn) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, bf *BackfillConfig, cbs ...CallbackFn) error {
 	if o.Down != nil {
 		err := createTrigger(ctx, conn, triggerConfig{
-			Name:           TriggerName(o.Table, o.Column),
+			Name:           TriggerFunctionName(o.Table, o.Column),
 			Direction:      TriggerDirectionDown,
 			Columns:        s.GetTable(o.Table).Columns,
 			SchemaName:     s.Name,
@@ -35,22 +35,20 @@ func (o *OpDropColumn) Start(ctx context.Context, conn *sql.DB, stateSchema stri
 }
 
 func (o *OpDropColumn) Complete(ctx context.Context, conn *sql.DB, s *schema.Schema) error {
-	_, err := conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s",
+	_, err := conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s DROP COLUMN IF EXISTS %s",
 		pq.QuoteIdentifier(o.Table),
 		pq.QuoteIdentifier(o.Column)))
 	if err != nil {
 		return err
 	}
 
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, o.Column))))
+	err = dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, o.Column))
 
 	return err
 }
 
 func (o *OpDropColumn) Rollback(ctx context.Context, conn *sql.DB) error {
-	_, err := conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, o.Column))))
+	err := dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, o.Column))
 
 	return err
 }
@@ -66,3 +64,7 @@ func (o *OpDropColumn) Validate(ctx context.Context, s *schema.Schema) error {
 	}
 	return nil
 }
+
+func (o *OpDropColumn) Tables() []string {
+	return []string{o.Table}
+}
@@ -55,6 +55,11 @@ func TestDropColumnWithDownSQL(t *testing.T) {
 				},
 			},
 			afterStart: func(t *testing.T, db *sql.DB) {
+				// The trigger of the table runs the up and down expressions.
+				TableTriggerMustExist(t, db, "public", "users", map[string]string{
+					migrations.TriggerFunctionName("users", "name"): "UPPER(email)",
+				})
+
 				// The deleted column is not present on the view in the new version schema.
 				versionSchema := roll.VersionedSchemaName("public", "02_drop_column")
 				ColumnMustNotExist(t, db, versionSchema, "users", "name")
@@ -81,6 +86,8 @@ func TestDropColumnWithDownSQL(t *testing.T) {
 				// The trigger has been dropped.
 				triggerName := migrations.TriggerName("users", "name")
 				TriggerMustNotExist(t, db, "public", "users", triggerName)
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "users")
 			},
 			afterComplete: func(t *testing.T, db *sql.DB) {
 				// The column has been deleted from the underlying table.
@@ -93,6 +100,8 @@ func TestDropColumnWithDownSQL(t *testing.T) {
 				// The trigger has been dropped.
 				triggerName := migrations.TriggerName("users", "name")
 				TriggerMustNotExist(t, db, "public", "users", triggerName)
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "users")
 
 				// Inserting into the view in the new version schema should succeed.
 				MustInsert(t, db, "public", "02_drop_column", "users", map[string]string{
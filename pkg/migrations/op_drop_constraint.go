@@ -13,7 +13,7 @@ import (
 
 var _ Operation = (*OpDropConstraint)(nil)
 
-func (o *OpDropConstraint) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, cbs ...CallbackFn) error {
+func (o *OpDropConstraint) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, bf *BackfillConfig, cbs ...CallbackFn) error {
 	table := s.GetTable(o.Table)
 	column := table.GetColumn(o.Column)
 
@@ -25,7 +25,7 @@ func (o *OpDropConstraint) Start(ctx context.Context, conn *sql.DB, stateSchema
 
 	// Add a trigger to copy values from the old column to the new, rewriting values using the `up` SQL.
 	err := createTrigger(ctx, conn, triggerConfig{
-		Name:           TriggerName(o.Table, o.Column),
+		Name:           TriggerFunctionName(o.Table, o.Column),
 		Direction:      TriggerDirectionUp,
 		Columns:        table.Columns,
 		SchemaName:     s.Name,
@@ -39,7 +39,7 @@ func (o *OpDropConstraint) Start(ctx context.Context, conn *sql.DB, stateSchema
 	}
 
 	// Backfill the new column with values from the old column.
-	if err := backfill(ctx, conn, table, cbs...); err != nil {
+	if err := backfill(ctx, conn, table, bf, cbs...); err != nil {
 		return fmt.Errorf("failed to backfill column: %w", err)
 	}
 
@@ -52,7 +52,7 @@ func (o *OpDropConstraint) Start(ctx context.Context, conn *sql.DB, stateSchema
 
 	// Add a trigger to copy values from the new column to the old, rewriting values using the `down` SQL.
 	err = createTrigger(ctx, conn, triggerConfig{
-		Name:           TriggerName(o.Table, TemporaryName(o.Column)),
+		Name:           TriggerFunctionName(o.Table, TemporaryName(o.Column)),
 		Direction:      TriggerDirectionDown,
 		Columns:        table.Columns,
 		SchemaName:     s.Name,
@@ -68,32 +68,26 @@ func (o *OpDropConstraint) Start(ctx context.Context, conn *sql.DB, stateSchema
 }
 
 func (o *OpDropConstraint) Complete(ctx context.Context, conn *sql.DB, s *schema.Schema) error {
-	// Remove the up function and trigger
-	_, err := conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, o.Column))))
+	// Remove the up trigger
+	err := dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, o.Column))
 	if err != nil {
 		return err
 	}
 
-	// Remove the down function and trigger
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, TemporaryName(o.Column)))))
+	// Remove the down trigger
+	err = dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, TemporaryName(o.Column)))
 	if err != nil {
 		return err
 	}
 
 	// Drop the old column
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s DROP COLUMN IF EXISTS %s",
-		pq.QuoteIdentifier(o.Table),
-		pq.QuoteIdentifier(o.Column)))
-	if err != nil {
+	if err := dropOriginalColumn(ctx, conn, o.Table, o.Column); err != nil {
 		return err
 	}
 
 	// Rename the new column to the old column name
 	table := s.GetTable(o.Table)
-	column := table.GetColumn(o.Column)
-	if err := RenameDuplicatedColumn(ctx, conn, table, column); err != nil {
+	if err := RenameDuplicatedColumn(ctx, conn, table, o.Column); err != nil {
 		return err
 	}
 
@@ -110,18 +104,14 @@ func (o *OpDropConstraint) Rollback(ctx context.Context, conn *sql.DB) error {
 		return err
 	}
 
-	// Remove the up function and trigger
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, o.Column)),
-	))
+	// Remove the up trigger
+	err = dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, o.Column))
 	if err != nil {
 		return err
 	}
 
-	// Remove the down function and trigger
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, TemporaryName(o.Column))),
-	))
+	// Remove the down trigger
+	err = dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, TemporaryName(o.Column)))
 
 	return err
 }
@@ -159,3 +149,11 @@ func (o *OpDropConstraint) upSQL() string {
 
 	return pq.QuoteIdentifier(o.Column)
 }
+
+func (o *OpDropConstraint) UpColumns() []UpColumn {
+	return []UpColumn{{Table: o.Table, Column: TemporaryName(o.Column), Up: o.upSQL()}}
+}
+
+func (o *OpDropConstraint) Tables() []string {
+	return []string{o.Table}
+}
@@ -65,6 +65,12 @@ func TestDropConstraint(t *testing.T) {
 				},
 			},
 			afterStart: func(t *testing.T, db *sql.DB) {
+				// The trigger of the table runs the up and down expressions.
+				TableTriggerMustExist(t, db, "public", "posts", map[string]string{
+					migrations.TriggerFunctionName("posts", "title"):                           `"title"`,
+					migrations.TriggerFunctionName("posts", migrations.TemporaryName("title")): "(SELECT CASE WHEN length(title) <= 3 THEN LPAD(title, 4, '-') ELSE title END)",
+				})
+
 				// The new (temporary) `title` column should exist on the underlying table.
 				ColumnMustExist(t, db, "public", "posts", migrations.TemporaryName("title"))
 
@@ -117,6 +123,8 @@ func TestDropConstraint(t *testing.T) {
 				TriggerMustNotExist(t, db, "public", "posts", migrations.TriggerName("posts", "title"))
 				// The down trigger no longer exists.
 				TriggerMustNotExist(t, db, "public", "posts", migrations.TriggerName("posts", migrations.TemporaryName("title")))
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "posts")
 			},
 			afterComplete: func(t *testing.T, db *sql.DB) {
 				// Inserting a row that does not meet the check constraint into the new view works.
@@ -142,6 +150,8 @@ func TestDropConstraint(t *testing.T) {
 				TriggerMustNotExist(t, db, "public", "posts", migrations.TriggerName("posts", "title"))
 				// The down trigger no longer exists.
 				TriggerMustNotExist(t, db, "public", "posts", migrations.TriggerName("posts", migrations.TemporaryName("title")))
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "posts")
 			},
 		},
 		{
@@ -281,6 +291,12 @@ func TestDropConstraint(t *testing.T) {
 				},
 			},
 			afterStart: func(t *testing.T, db *sql.DB) {
+				// The trigger of the table runs the up and down expressions.
+				TableTriggerMustExist(t, db, "public", "posts", map[string]string{
+					migrations.TriggerFunctionName("posts", "user_id"):                           "user_id",
+					migrations.TriggerFunctionName("posts", migrations.TemporaryName("user_id")): "(SELECT CASE WHEN EXISTS (SELECT 1 FROM users WHERE users.id = user_id) THEN user_id ELSE NULL END)",
+				})
+
 				// The new (temporary) `user_id` column should exist on the underlying table.
 				ColumnMustExist(t, db, "public", "posts", migrations.TemporaryName("user_id"))
 
@@ -345,6 +361,8 @@ func TestDropConstraint(t *testing.T) {
 				TriggerMustNotExist(t, db, "public", "posts", migrations.TriggerName("posts", "user_id"))
 				// The down trigger no longer exists.
 				TriggerMustNotExist(t, db, "public", "posts", migrations.TriggerName("posts", migrations.TemporaryName("user_id")))
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "posts")
 			},
 			afterComplete: func(t *testing.T, db *sql.DB) {
 				// The new (temporary) `user_id` column should not exist on the underlying table.
@@ -374,6 +392,8 @@ func TestDropConstraint(t *testing.T) {
 				TriggerMustNotExist(t, db, "public", "posts", migrations.TriggerName("posts", "user_id"))
 				// The down trigger no longer exists.
 				TriggerMustNotExist(t, db, "public", "posts", migrations.TriggerName("posts", migrations.TemporaryName("user_id")))
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "posts")
 			},
 		},
 		{
@@ -413,6 +433,12 @@ func TestDropConstraint(t *testing.T) {
 				},
 			},
 			afterStart: func(t *testing.T, db *sql.DB) {
+				// The trigger of the table runs the up and down expressions.
+				TableTriggerMustExist(t, db, "public", "users", map[string]string{
+					migrations.TriggerFunctionName("users", "name"):                           "name",
+					migrations.TriggerFunctionName("users", migrations.TemporaryName("name")): "name || '-' || (random()*1000000)::integer",
+				})
+
 				// The new (temporary) `name` column should exist on the underlying table.
 				ColumnMustExist(t, db, "public", "users", migrations.TemporaryName("name"))
 
@@ -444,6 +470,8 @@ func TestDropConstraint(t *testing.T) {
 				TriggerMustNotExist(t, db, "public", "users", migrations.TriggerName("users", "name"))
 				// The down trigger no longer exists.
 				TriggerMustNotExist(t, db, "public", "users", migrations.TriggerName("users", migrations.TemporaryName("name")))
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "users")
 			},
 			afterComplete: func(t *testing.T, db *sql.DB) {
 				// The new (temporary) `name` column should not exist on the underlying table.
@@ -453,6 +481,9 @@ func TestDropConstraint(t *testing.T) {
 				MustInsert(t, db, "public", "02_drop_unique_constraint", "users", map[string]string{
 					"name": "alice",
 				})
+
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "users")
 			},
 		},
 		{
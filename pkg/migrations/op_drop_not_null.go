@@ -20,7 +20,7 @@ type OpDropNotNull struct {
 
 var _ Operation = (*OpDropNotNull)(nil)
 
-func (o *OpDropNotNull) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, cbs ...CallbackFn) error {
+func (o *OpDropNotNull) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, bf *BackfillConfig, cbs ...CallbackFn) error {
 	table := s.GetTable(o.Table)
 	column := table.GetColumn(o.Column)
 
@@ -32,7 +32,7 @@ func (o *OpDropNotNull) Start(ctx context.Context, conn *sql.DB, stateSchema str
 
 	// Add a trigger to copy values from the old column to the new, rewriting values using the `up` SQL.
 	err := createTrigger(ctx, conn, triggerConfig{
-		Name:           TriggerName(o.Table, o.Column),
+		Name:           TriggerFunctionName(o.Table, o.Column),
 		Direction:      TriggerDirectionUp,
 		Columns:        table.Columns,
 		SchemaName:     s.Name,
@@ -46,7 +46,7 @@ func (o *OpDropNotNull) Start(ctx context.Context, conn *sql.DB, stateSchema str
 	}
 
 	// Backfill the new column with values from the old column.
-	if err := backfill(ctx, conn, table, cbs...); err != nil {
+	if err := backfill(ctx, conn, table, bf, cbs...); err != nil {
 		return fmt.Errorf("failed to backfill column: %w", err)
 	}
 
@@ -59,7 +59,7 @@ func (o *OpDropNotNull) Start(ctx context.Context, conn *sql.DB, stateSchema str
 
 	// Add a trigger to copy values from the new column to the old.
 	err = createTrigger(ctx, conn, triggerConfig{
-		Name:           TriggerName(o.Table, TemporaryName(o.Column)),
+		Name:           TriggerFunctionName(o.Table, TemporaryName(o.Column)),
 		Direction:      TriggerDirectionDown,
 		Columns:        table.Columns,
 		SchemaName:     s.Name,
@@ -77,31 +77,25 @@ func (o *OpDropNotNull) Start(ctx context.Context, conn *sql.DB, stateSchema str
 
 func (o *OpDropNotNull) Complete(ctx context.Context, conn *sql.DB, s *schema.Schema) error {
 	// Drop the old column
-	_, err := conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s DROP COLUMN IF EXISTS %s",
-		pq.QuoteIdentifier(o.Table),
-		pq.QuoteIdentifier(o.Column)))
-	if err != nil {
+	if err := dropOriginalColumn(ctx, conn, o.Table, o.Column); err != nil {
 		return err
 	}
 
-	// Remove the up function and trigger
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, o.Column))))
+	// Remove the up trigger
+	err := dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, o.Column))
 	if err != nil {
 		return err
 	}
 
-	// Remove the down function and trigger
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, TemporaryName(o.Column)))))
+	// Remove the down trigger
+	err = dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, TemporaryName(o.Column)))
 	if err != nil {
 		return err
 	}
 
 	// Rename the new column to the old column name
 	table := s.GetTable(o.Table)
-	column := table.GetColumn(o.Column)
-	if err := RenameDuplicatedColumn(ctx, conn, table, column); err != nil {
+	if err := RenameDuplicatedColumn(ctx, conn, table, o.Column); err != nil {
 		return err
 	}
 
@@ -118,18 +112,14 @@ func (o *OpDropNotNull) Rollback(ctx context.Context, conn *sql.DB) error {
 		return err
 	}
 
-	// Remove the up function and trigger
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, o.Column)),
-	))
+	// Remove the up trigger
+	err = dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, o.Column))
 	if err != nil {
 		return err
 	}
 
-	// Remove the down function and trigger
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, TemporaryName(o.Column))),
-	))
+	// Remove the down trigger
+	err = dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, TemporaryName(o.Column)))
 
 	return err
 }
@@ -155,3 +145,7 @@ func (o *OpDropNotNull) upSQL() string {
 	}
 	return o.Up
 }
+
+func (o *OpDropNotNull) UpColumns() []UpColumn {
+	return []UpColumn{{Table: o.Table, Column: TemporaryName(o.Column), Up: o.upSQL()}}
+}
@@ -61,6 +61,12 @@ func TestDropNotNull(t *testing.T) {
 				},
 			},
 			afterStart: func(t *testing.T, db *sql.DB) {
+				// The trigger of the table runs the up and down expressions.
+				TableTriggerMustExist(t, db, "public", "reviews", map[string]string{
+					migrations.TriggerFunctionName("reviews", "review"):                           `"review"`,
+					migrations.TriggerFunctionName("reviews", migrations.TemporaryName("review")): "(SELECT CASE WHEN review IS NULL THEN product || ' is good' ELSE review END)",
+				})
+
 				// The new (temporary) `review` column should exist on the underlying table.
 				ColumnMustExist(t, db, "public", "reviews", migrations.TemporaryName("review"))
 
@@ -120,6 +126,8 @@ func TestDropNotNull(t *testing.T) {
 				TriggerMustNotExist(t, db, "public", "reviews", migrations.TriggerName("reviews", "review"))
 				// The down trigger no longer exists.
 				TriggerMustNotExist(t, db, "public", "reviews", migrations.TriggerName("reviews", migrations.TemporaryName("review")))
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "reviews")
 			},
 			afterComplete: func(t *testing.T, db *sql.DB) {
 				// The new (temporary) `review` column should not exist on the underlying table.
@@ -149,6 +157,8 @@ func TestDropNotNull(t *testing.T) {
 				TriggerMustNotExist(t, db, "public", "reviews", migrations.TriggerName("reviews", "review"))
 				// The down trigger no longer exists.
 				TriggerMustNotExist(t, db, "public", "reviews", migrations.TriggerName("reviews", migrations.TemporaryName("review")))
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "reviews")
 			},
 		},
 		{
@@ -13,7 +13,7 @@ import (
 
 var _ Operation = (*OpDropTable)(nil)
 
-func (o *OpDropTable) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, cbs ...CallbackFn) error {
+func (o *OpDropTable) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, bf *BackfillConfig, cbs ...CallbackFn) error {
 	s.RemoveTable(o.Name)
 	return nil
 }
@@ -36,3 +36,7 @@ func (o *OpDropTable) Validate(ctx context.Context, s *schema.Schema) error {
 	}
 	return nil
 }
+
+func (o *OpDropTable) Tables() []string {
+	return []string{o.Name}
+}
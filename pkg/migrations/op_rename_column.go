@@ -19,15 +19,25 @@ type OpRenameColumn struct {
 
 var _ Operation = (*OpRenameColumn)(nil)
 
-func (o *OpRenameColumn) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, cbs ...CallbackFn) error {
+func (o *OpRenameColumn) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, bf *BackfillConfig, cbs ...CallbackFn) error {
 	table := s.GetTable(o.Table)
 	table.RenameColumn(o.From, o.To)
 	return nil
 }
 
 func (o *OpRenameColumn) Complete(ctx context.Context, conn *sql.DB, s *schema.Schema) error {
+	// the column has already been renamed if a previous attempt to complete
+	// the migration was interrupted
+	exists, err := columnExists(ctx, conn, o.Table, o.From)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+
 	// rename the column in the underlying table
-	_, err := conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s",
+	_, err = conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s",
 		pq.QuoteIdentifier(o.Table),
 		pq.QuoteIdentifier(o.From),
 		pq.QuoteIdentifier(o.To)))
@@ -46,5 +56,9 @@ func (o *OpRenameColumn) Validate(ctx context.Context, s *schema.Schema) error {
 		return ColumnAlreadyExistsError{Table: o.Table, Name: o.From}
 	}
 
+	if err := ValidateIdentifier(o.To); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -13,7 +13,7 @@ import (
 
 var _ Operation = (*OpRenameTable)(nil)
 
-func (o *OpRenameTable) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, cbs ...CallbackFn) error {
+func (o *OpRenameTable) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, bf *BackfillConfig, cbs ...CallbackFn) error {
 	return s.RenameTable(o.From, o.To)
 }
 
@@ -35,6 +35,17 @@ func (o *OpRenameTable) Validate(ctx context.Context, s *schema.Schema) error {
 	if s.GetTable(o.To) != nil {
 		return TableAlreadyExistsError{Name: o.To}
 	}
+	if s.RelationExists(o.To) {
+		return RelationAlreadyExistsError{Name: o.To}
+	}
+
+	if err := ValidateIdentifier(o.To); err != nil {
+		return err
+	}
 
 	return nil
 }
+
+func (o *OpRenameTable) Tables() []string {
+	return []string{o.From, o.To}
+}
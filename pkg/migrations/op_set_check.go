@@ -22,7 +22,7 @@ type OpSetCheckConstraint struct {
 
 var _ Operation = (*OpSetCheckConstraint)(nil)
 
-func (o *OpSetCheckConstraint) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, cbs ...CallbackFn) error {
+func (o *OpSetCheckConstraint) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, bf *BackfillConfig, cbs ...CallbackFn) error {
 	table := s.GetTable(o.Table)
 	column := table.GetColumn(o.Column)
 
@@ -39,7 +39,7 @@ func (o *OpSetCheckConstraint) Start(ctx context.Context, conn *sql.DB, stateSch
 
 	// Add a trigger to copy values from the old column to the new, rewriting values using the `up` SQL.
 	err := createTrigger(ctx, conn, triggerConfig{
-		Name:           TriggerName(o.Table, o.Column),
+		Name:           TriggerFunctionName(o.Table, o.Column),
 		Direction:      TriggerDirectionUp,
 		Columns:        table.Columns,
 		SchemaName:     s.Name,
@@ -53,7 +53,7 @@ func (o *OpSetCheckConstraint) Start(ctx context.Context, conn *sql.DB, stateSch
 	}
 
 	// Backfill the new column with values from the old column.
-	if err := backfill(ctx, conn, table, cbs...); err != nil {
+	if err := backfill(ctx, conn, table, bf, cbs...); err != nil {
 		return fmt.Errorf("failed to backfill column: %w", err)
 	}
 
@@ -66,7 +66,7 @@ func (o *OpSetCheckConstraint) Start(ctx context.Context, conn *sql.DB, stateSch
 
 	// Add a trigger to copy values from the new column to the old, rewriting values using the `down` SQL.
 	err = createTrigger(ctx, conn, triggerConfig{
-		Name:           TriggerName(o.Table, TemporaryName(o.Column)),
+		Name:           TriggerFunctionName(o.Table, TemporaryName(o.Column)),
 		Direction:      TriggerDirectionDown,
 		Columns:        table.Columns,
 		SchemaName:     s.Name,
@@ -90,32 +90,26 @@ func (o *OpSetCheckConstraint) Complete(ctx context.Context, conn *sql.DB, s *sc
 		return err
 	}
 
-	// Remove the up function and trigger
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, o.Column))))
+	// Remove the up trigger
+	err = dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, o.Column))
 	if err != nil {
 		return err
 	}
 
-	// Remove the down function and trigger
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, TemporaryName(o.Column)))))
+	// Remove the down trigger
+	err = dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, TemporaryName(o.Column)))
 	if err != nil {
 		return err
 	}
 
 	// Drop the old column
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s DROP COLUMN IF EXISTS %s",
-		pq.QuoteIdentifier(o.Table),
-		pq.QuoteIdentifier(o.Column)))
-	if err != nil {
+	if err := dropOriginalColumn(ctx, conn, o.Table, o.Column); err != nil {
 		return err
 	}
 
 	// Rename the new column to the old column name
 	table := s.GetTable(o.Table)
-	column := table.GetColumn(o.Column)
-	if err := RenameDuplicatedColumn(ctx, conn, table, column); err != nil {
+	if err := RenameDuplicatedColumn(ctx, conn, table, o.Column); err != nil {
 		return err
 	}
 
@@ -132,18 +126,14 @@ func (o *OpSetCheckConstraint) Rollback(ctx context.Context, conn *sql.DB) error
 		return err
 	}
 
-	// Remove the up function and trigger
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, o.Column)),
-	))
+	// Remove the up trigger
+	err = dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, o.Column))
 	if err != nil {
 		return err
 	}
 
-	// Remove the down function and trigger
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, TemporaryName(o.Column))),
-	))
+	// Remove the down trigger
+	err = dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, TemporaryName(o.Column)))
 
 	return err
 }
@@ -169,7 +159,12 @@ func (o *OpSetCheckConstraint) Validate(ctx context.Context, s *schema.Schema) e
 }
 
 func (o *OpSetCheckConstraint) addCheckConstraint(ctx context.Context, conn *sql.DB) error {
-	_, err := conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s CHECK (%s) NOT VALID",
+	exists, err := constraintExists(ctx, conn, o.Table, o.Check.Name)
+	if err != nil || exists {
+		return err
+	}
+
+	_, err = conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s CHECK (%s) NOT VALID",
 		pq.QuoteIdentifier(o.Table),
 		pq.QuoteIdentifier(o.Check.Name),
 		rewriteCheckExpression(o.Check.Constraint, o.Column, TemporaryName(o.Column)),
@@ -186,3 +181,7 @@ func (o *OpSetCheckConstraint) addCheckConstraint(ctx context.Context, conn *sql
 func rewriteCheckExpression(check string, oldColumn, newColumn string) string {
 	return strings.ReplaceAll(check, oldColumn, newColumn)
 }
+
+func (o *OpSetCheckConstraint) UpColumns() []UpColumn {
+	return []UpColumn{{Table: o.Table, Column: TemporaryName(o.Column), Up: o.Up}}
+}
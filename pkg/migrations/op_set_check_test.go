@@ -54,6 +54,12 @@ func TestSetCheckConstraint(t *testing.T) {
 				},
 			},
 			afterStart: func(t *testing.T, db *sql.DB) {
+				// The trigger of the table runs the up and down expressions.
+				TableTriggerMustExist(t, db, "public", "posts", map[string]string{
+					migrations.TriggerFunctionName("posts", "title"):                           "(SELECT CASE WHEN length(title) <= 3 THEN LPAD(title, 4, '-') ELSE title END)",
+					migrations.TriggerFunctionName("posts", migrations.TemporaryName("title")): "title",
+				})
+
 				// The new (temporary) `title` column should exist on the underlying table.
 				ColumnMustExist(t, db, "public", "posts", migrations.TemporaryName("title"))
 
@@ -112,6 +118,8 @@ func TestSetCheckConstraint(t *testing.T) {
 				TriggerMustNotExist(t, db, "public", "posts", migrations.TriggerName("posts", "title"))
 				// The down trigger no longer exists.
 				TriggerMustNotExist(t, db, "public", "posts", migrations.TriggerName("posts", migrations.TemporaryName("title")))
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "posts")
 			},
 			afterComplete: func(t *testing.T, db *sql.DB) {
 				// The check constraint exists on the new table.
@@ -145,6 +153,8 @@ func TestSetCheckConstraint(t *testing.T) {
 				TriggerMustNotExist(t, db, "public", "posts", migrations.TriggerName("posts", "title"))
 				// The down trigger no longer exists.
 				TriggerMustNotExist(t, db, "public", "posts", migrations.TriggerName("posts", migrations.TemporaryName("title")))
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "posts")
 			},
 		},
 		{
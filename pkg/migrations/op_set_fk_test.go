@@ -74,6 +74,12 @@ func TestSetForeignKey(t *testing.T) {
 				},
 			},
 			afterStart: func(t *testing.T, db *sql.DB) {
+				// The trigger of the table runs the up and down expressions.
+				TableTriggerMustExist(t, db, "public", "posts", map[string]string{
+					migrations.TriggerFunctionName("posts", "user_id"):                           "(SELECT CASE WHEN EXISTS (SELECT 1 FROM users WHERE users.id = user_id) THEN user_id ELSE NULL END)",
+					migrations.TriggerFunctionName("posts", migrations.TemporaryName("user_id")): "user_id",
+				})
+
 				// The new (temporary) `user_id` column should exist on the underlying table.
 				ColumnMustExist(t, db, "public", "posts", migrations.TemporaryName("user_id"))
 
@@ -140,6 +146,8 @@ func TestSetForeignKey(t *testing.T) {
 				TriggerMustNotExist(t, db, "public", "posts", migrations.TriggerName("posts", "user_id"))
 				// The down trigger no longer exists.
 				TriggerMustNotExist(t, db, "public", "posts", migrations.TriggerName("posts", migrations.TemporaryName("user_id")))
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "posts")
 			},
 			afterComplete: func(t *testing.T, db *sql.DB) {
 				// The new (temporary) `user_id` column should not exist on the underlying table.
@@ -178,6 +186,8 @@ func TestSetForeignKey(t *testing.T) {
 				TriggerMustNotExist(t, db, "public", "posts", migrations.TriggerName("posts", "user_id"))
 				// The down trigger no longer exists.
 				TriggerMustNotExist(t, db, "public", "posts", migrations.TriggerName("posts", migrations.TemporaryName("user_id")))
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "posts")
 			},
 		},
 		{
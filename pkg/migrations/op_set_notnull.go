@@ -20,10 +20,29 @@ type OpSetNotNull struct {
 
 var _ Operation = (*OpSetNotNull)(nil)
 
-func (o *OpSetNotNull) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, cbs ...CallbackFn) error {
+func (o *OpSetNotNull) Start(ctx context.Context, conn *sql.DB, stateSchema string, s *schema.Schema, bf *BackfillConfig, cbs ...CallbackFn) error {
 	table := s.GetTable(o.Table)
 	column := table.GetColumn(o.Column)
 
+	// An `up` SQL that is the column itself leaves the values unchanged, so the
+	// existing column only needs an unchecked NOT NULL constraint until the
+	// migration is completed. Existing NULL values would make the completion
+	// fail, so the migration is refused before it starts.
+	if o.inPlace() {
+		hasNulls, err := columnHasNulls(ctx, conn, o.Table, o.Column)
+		if err != nil {
+			return fmt.Errorf("failed to check for null values: %w", err)
+		}
+		if hasNulls {
+			return ColumnHasNullValuesError{Table: o.Table, Name: o.Column}
+		}
+
+		if err := addNotNullConstraint(ctx, conn, o.Table, o.Column, o.Column); err != nil {
+			return fmt.Errorf("failed to add not null constraint: %w", err)
+		}
+		return nil
+	}
+
 	// Create a copy of the column on the underlying table.
 	d := NewColumnDuplicator(conn, table, column)
 	if err := d.Duplicate(ctx); err != nil {
@@ -37,7 +56,7 @@ func (o *OpSetNotNull) Start(ctx context.Context, conn *sql.DB, stateSchema stri
 
 	// Add a trigger to copy values from the old column to the new, rewriting values using the `up` SQL.
 	err := createTrigger(ctx, conn, triggerConfig{
-		Name:           TriggerName(o.Table, o.Column),
+		Name:           TriggerFunctionName(o.Table, o.Column),
 		Direction:      TriggerDirectionUp,
 		Columns:        table.Columns,
 		SchemaName:     s.Name,
@@ -51,7 +70,7 @@ func (o *OpSetNotNull) Start(ctx context.Context, conn *sql.DB, stateSchema stri
 	}
 
 	// Backfill the new column with values from the old column.
-	if err := backfill(ctx, conn, table, cbs...); err != nil {
+	if err := backfill(ctx, conn, table, bf, cbs...); err != nil {
 		return fmt.Errorf("failed to backfill column: %w", err)
 	}
 
@@ -64,7 +83,7 @@ func (o *OpSetNotNull) Start(ctx context.Context, conn *sql.DB, stateSchema stri
 
 	// Add a trigger to copy values from the new column to the old.
 	err = createTrigger(ctx, conn, triggerConfig{
-		Name:           TriggerName(o.Table, TemporaryName(o.Column)),
+		Name:           TriggerFunctionName(o.Table, TemporaryName(o.Column)),
 		Direction:      TriggerDirectionDown,
 		Columns:        table.Columns,
 		SchemaName:     s.Name,
@@ -81,59 +100,51 @@ func (o *OpSetNotNull) Start(ctx context.Context, conn *sql.DB, stateSchema stri
 }
 
 func (o *OpSetNotNull) Complete(ctx context.Context, conn *sql.DB, s *schema.Schema) error {
-	// Validate the NOT NULL constraint on the old column.
-	// The constraint must be valid because:
-	// * Existing NULL values in the old column were rewritten using the `up` SQL during backfill.
-	// * New NULL values written to the old column during the migration period were also rewritten using `up` SQL.
-	_, err := conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s VALIDATE CONSTRAINT %s",
-		pq.QuoteIdentifier(o.Table),
-		pq.QuoteIdentifier(NotNullConstraintName(o.Column))))
+	// The NOT NULL constraint is dropped once it has been used to set `NOT
+	// NULL` on the new column, so it only exists if this hasn't happened yet.
+	notNullExists, err := constraintExists(ctx, conn, o.Table, NotNullConstraintName(o.Column))
 	if err != nil {
 		return err
 	}
 
-	// Use the validated constraint to add `NOT NULL` to the new column
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s ALTER COLUMN %s SET NOT NULL",
-		pq.QuoteIdentifier(o.Table),
-		pq.QuoteIdentifier(TemporaryName(o.Column))))
-	if err != nil {
-		return err
+	if o.inPlace() {
+		// The constraint is valid, as the column had no NULL values when the
+		// migration was started and the constraint has rejected them since.
+		if notNullExists {
+			return setNotNull(ctx, conn, o.Table, o.Column, o.Column)
+		}
+		return nil
 	}
 
-	// Drop the NOT NULL constraint
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s DROP CONSTRAINT IF EXISTS %s",
-		pq.QuoteIdentifier(o.Table),
-		pq.QuoteIdentifier(NotNullConstraintName(o.Column))))
-	if err != nil {
-		return err
+	if notNullExists {
+		// The NOT NULL constraint on the new column must be valid because:
+		// * Existing NULL values in the old column were rewritten using the `up` SQL during backfill.
+		// * New NULL values written to the old column during the migration period were also rewritten using `up` SQL.
+		if err := setNotNull(ctx, conn, o.Table, o.Column, TemporaryName(o.Column)); err != nil {
+			return err
+		}
 	}
 
 	// Drop the old column
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s DROP COLUMN IF EXISTS %s",
-		pq.QuoteIdentifier(o.Table),
-		pq.QuoteIdentifier(o.Column)))
-	if err != nil {
+	if err := dropOriginalColumn(ctx, conn, o.Table, o.Column); err != nil {
 		return err
 	}
 
-	// Remove the up function and trigger
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, o.Column))))
+	// Remove the up trigger
+	err = dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, o.Column))
 	if err != nil {
 		return err
 	}
 
-	// Remove the down function and trigger
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, TemporaryName(o.Column)))))
+	// Remove the down trigger
+	err = dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, TemporaryName(o.Column)))
 	if err != nil {
 		return err
 	}
 
 	// Rename the new column to the old column name
 	table := s.GetTable(o.Table)
-	column := table.GetColumn(o.Column)
-	if err := RenameDuplicatedColumn(ctx, conn, table, column); err != nil {
+	if err := RenameDuplicatedColumn(ctx, conn, table, o.Column); err != nil {
 		return err
 	}
 
@@ -150,18 +161,22 @@ func (o *OpSetNotNull) Rollback(ctx context.Context, conn *sql.DB) error {
 		return err
 	}
 
-	// Remove the up function and trigger
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, o.Column)),
-	))
+	// Remove the up trigger
+	err = dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, o.Column))
 	if err != nil {
 		return err
 	}
 
-	// Remove the down function and trigger
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
-		pq.QuoteIdentifier(TriggerFunctionName(o.Table, TemporaryName(o.Column))),
-	))
+	// Remove the down trigger
+	err = dropTrigger(ctx, conn, o.Table, TriggerFunctionName(o.Table, TemporaryName(o.Column)))
+	if err != nil {
+		return err
+	}
+
+	// Drop the NOT NULL constraint added to the existing column
+	_, err = conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s DROP CONSTRAINT IF EXISTS %s",
+		pq.QuoteIdentifier(o.Table),
+		pq.QuoteIdentifier(NotNullConstraintName(o.Column))))
 
 	return err
 }
@@ -187,3 +202,49 @@ func (o *OpSetNotNull) downSQL() string {
 	}
 	return o.Down
 }
+
+// inPlace returns true if the `up` SQL is the column itself, in which case
+// NOT NULL is set on the existing column instead of a copy of it.
+func (o *OpSetNotNull) inPlace() bool {
+	return isColumnReference(o.Up, o.Column)
+}
+
+func (o *OpSetNotNull) UpColumns() []UpColumn {
+	if o.inPlace() {
+		return nil
+	}
+	return []UpColumn{{Table: o.Table, Column: TemporaryName(o.Column), Up: o.Up}}
+}
+
+// setNotNull validates the NOT NULL constraint of a column and uses it to set
+// `NOT NULL` on the physical column without scanning the table under an
+// exclusive lock, then drops the constraint.
+func setNotNull(ctx context.Context, conn *sql.DB, table, column, physicalColumn string) error {
+	_, err := conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s VALIDATE CONSTRAINT %s",
+		pq.QuoteIdentifier(table),
+		pq.QuoteIdentifier(NotNullConstraintName(column))))
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s ALTER COLUMN %s SET NOT NULL",
+		pq.QuoteIdentifier(table),
+		pq.QuoteIdentifier(physicalColumn)))
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s DROP CONSTRAINT IF EXISTS %s",
+		pq.QuoteIdentifier(table),
+		pq.QuoteIdentifier(NotNullConstraintName(column))))
+	return err
+}
+
+// columnHasNulls returns true if the column holds any NULL values
+func columnHasNulls(ctx context.Context, conn *sql.DB, table, column string) (bool, error) {
+	var hasNulls bool
+	err := conn.QueryRowContext(ctx, fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE %s IS NULL)",
+		pq.QuoteIdentifier(table),
+		pq.QuoteIdentifier(column))).Scan(&hasNulls)
+	return hasNulls, err
+}
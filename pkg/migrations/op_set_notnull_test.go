@@ -61,6 +61,12 @@ func TestSetNotNull(t *testing.T) {
 				},
 			},
 			afterStart: func(t *testing.T, db *sql.DB) {
+				// The trigger of the table runs the up and down expressions.
+				TableTriggerMustExist(t, db, "public", "reviews", map[string]string{
+					migrations.TriggerFunctionName("reviews", "review"):                           "(SELECT CASE WHEN review IS NULL THEN product || ' is good' ELSE review END)",
+					migrations.TriggerFunctionName("reviews", migrations.TemporaryName("review")): `NEW."_pgroll_new_review"`,
+				})
+
 				// The new (temporary) `review` column should exist on the underlying table.
 				ColumnMustExist(t, db, "public", "reviews", migrations.TemporaryName("review"))
 
@@ -127,6 +133,8 @@ func TestSetNotNull(t *testing.T) {
 				TriggerMustNotExist(t, db, "public", "reviews", migrations.TriggerName("reviews", "review"))
 				// The down trigger no longer exists.
 				TriggerMustNotExist(t, db, "public", "reviews", migrations.TriggerName("reviews", migrations.TemporaryName("review")))
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "reviews")
 			},
 			afterComplete: func(t *testing.T, db *sql.DB) {
 				// The new (temporary) `review` column should not exist on the underlying table.
@@ -155,6 +163,8 @@ func TestSetNotNull(t *testing.T) {
 				TriggerMustNotExist(t, db, "public", "reviews", migrations.TriggerName("reviews", "review"))
 				// The down trigger no longer exists.
 				TriggerMustNotExist(t, db, "public", "reviews", migrations.TriggerName("reviews", migrations.TemporaryName("review")))
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "reviews")
 			},
 		},
 		{
@@ -457,6 +467,9 @@ func TestSetNotNull(t *testing.T) {
 				},
 			},
 			afterStart: func(t *testing.T, db *sql.DB) {
+				// The unique constraint is duplicated on the new column
+				UniqueConstraintMustExist(t, db, "public", "users", migrations.DuplicationName("users_name_key"))
+
 				// Inserting an initial row succeeds
 				MustInsert(t, db, "public", "02_set_not_null", "users", map[string]string{
 					"name": "alice",
@@ -470,6 +483,10 @@ func TestSetNotNull(t *testing.T) {
 			afterRollback: func(t *testing.T, db *sql.DB) {
 			},
 			afterComplete: func(t *testing.T, db *sql.DB) {
+				// The unique constraint and its index have their original name
+				UniqueConstraintMustExist(t, db, "public", "users", "users_name_key")
+				IndexMustExist(t, db, "public", "users", "users_name_key")
+
 				// Inserting a row with a duplicate `name` value fails
 				MustNotInsert(t, db, "public", "02_set_not_null", "users", map[string]string{
 					"name": "alice",
@@ -481,6 +498,200 @@ func TestSetNotNull(t *testing.T) {
 				})
 			},
 		},
+		{
+			name: "setting a column to not null retains its indexes and other attributes",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_add_table",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: `CREATE TABLE users (id serial PRIMARY KEY, name text COLLATE "C");
+								ALTER TABLE users ALTER COLUMN name SET STORAGE EXTERNAL, ALTER COLUMN name SET STATISTICS 500;
+								COMMENT ON COLUMN users.name IS 'the name of the user';
+								GRANT SELECT (name) ON users TO PUBLIC;
+								CREATE INDEX idx_name ON users (name DESC);
+								CREATE UNIQUE INDEX idx_lower_name ON users (lower(name)) WHERE id > 0`,
+						},
+					},
+				},
+				{
+					Name: "02_set_not_null",
+					Operations: migrations.Operations{
+						&migrations.OpAlterColumn{
+							Table:    "users",
+							Column:   "name",
+							Nullable: ptr(false),
+							Up:       ptr("(SELECT CASE WHEN name IS NULL THEN 'anonymous' ELSE name END)"),
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB) {
+				// The indexes on the column are duplicated
+				IndexMustExist(t, db, "public", "users", migrations.DuplicationName("idx_name"))
+				IndexMustExist(t, db, "public", "users", migrations.DuplicationName("idx_lower_name"))
+
+				// The attributes of the column are duplicated
+				ColumnAttributesMustBe(t, db, "public", "users", migrations.TemporaryName("name"), ColumnAttributes{
+					Collation:        `"C"`,
+					Storage:          "e",
+					StatisticsTarget: 500,
+					Comment:          "the name of the user",
+					PublicSelect:     true,
+				})
+			},
+			afterRollback: func(t *testing.T, db *sql.DB) {
+				IndexMustNotExist(t, db, "public", "users", migrations.DuplicationName("idx_name"))
+				IndexMustNotExist(t, db, "public", "users", migrations.DuplicationName("idx_lower_name"))
+			},
+			afterComplete: func(t *testing.T, db *sql.DB) {
+				// The indexes have their original names
+				IndexMustExist(t, db, "public", "users", "idx_name")
+				IndexMustExist(t, db, "public", "users", "idx_lower_name")
+
+				ColumnAttributesMustBe(t, db, "public", "users", "name", ColumnAttributes{
+					Collation:        `"C"`,
+					Storage:          "e",
+					StatisticsTarget: 500,
+					Comment:          "the name of the user",
+					PublicSelect:     true,
+				})
+
+				// The expression index still enforces uniqueness
+				MustInsert(t, db, "public", "02_set_not_null", "users", map[string]string{
+					"name": "alice",
+				})
+				MustNotInsert(t, db, "public", "02_set_not_null", "users", map[string]string{
+					"name": "Alice",
+				}, testutils.UniqueViolationErrorCode)
+			},
+		},
+		{
+			name: "setting a column to not null duplicates only the indexes that use the column",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_add_table",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: `CREATE TABLE users (id serial PRIMARY KEY, a text, age integer, username text);
+								CREATE INDEX idx_a ON users (a text_pattern_ops) WHERE a <> 'age';
+								CREATE INDEX idx_age ON users (age) WHERE age > 18;
+								CREATE INDEX idx_username ON users (lower(username))`,
+						},
+					},
+				},
+				{
+					Name: "02_set_not_null",
+					Operations: migrations.Operations{
+						&migrations.OpAlterColumn{
+							Table:    "users",
+							Column:   "a",
+							Nullable: ptr(false),
+							Up:       ptr("(SELECT CASE WHEN a IS NULL THEN '' ELSE a END)"),
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB) {
+				// Only the index on the column is duplicated
+				IndexMustExist(t, db, "public", "users", migrations.DuplicationName("idx_a"))
+				IndexMustNotExist(t, db, "public", "users", migrations.DuplicationName("idx_age"))
+				IndexMustNotExist(t, db, "public", "users", migrations.DuplicationName("idx_username"))
+			},
+			afterRollback: func(t *testing.T, db *sql.DB) {
+				IndexMustNotExist(t, db, "public", "users", migrations.DuplicationName("idx_a"))
+			},
+			afterComplete: func(t *testing.T, db *sql.DB) {
+				IndexMustExist(t, db, "public", "users", "idx_a")
+				IndexMustExist(t, db, "public", "users", "idx_age")
+				IndexMustExist(t, db, "public", "users", "idx_username")
+				IndexMustNotExist(t, db, "public", "users", migrations.DuplicationName("idx_a"))
+			},
+		},
+		{
+			name: "set not null on the existing column when up is the column itself",
+			migrations: []migrations.Migration{
+				{
+					Name: "01_add_table",
+					Operations: migrations.Operations{
+						&migrations.OpCreateTable{
+							Name: "users",
+							Columns: []migrations.Column{
+								{
+									Name: "id",
+									Type: "serial",
+									Pk:   ptr(true),
+								},
+								{
+									Name:     "name",
+									Type:     "text",
+									Nullable: ptr(true),
+								},
+							},
+						},
+					},
+				},
+				{
+					Name: "02_set_not_null",
+					Operations: migrations.Operations{
+						&migrations.OpAlterColumn{
+							Table:    "users",
+							Column:   "name",
+							Nullable: ptr(false),
+							Up:       ptr("name"),
+						},
+					},
+				},
+			},
+			afterStart: func(t *testing.T, db *sql.DB) {
+				// The column is not duplicated and no triggers are created.
+				ColumnMustExist(t, db, "public", "users", "name")
+				ColumnMustNotExist(t, db, "public", "users", migrations.TemporaryName("name"))
+				TriggerMustNotExist(t, db, "public", "users", migrations.TriggerName("users", "name"))
+				// No trigger is created for the table.
+				TableTriggerMustNotExist(t, db, "public", "users")
+
+				// The existing column has an unchecked NOT NULL constraint.
+				CheckConstraintMustExist(t, db, "public", "users", migrations.NotNullConstraintName("name"))
+
+				// Inserting a NULL value fails in both versions.
+				MustNotInsert(t, db, "public", "01_add_table", "users", map[string]string{
+					"id": "1",
+				}, testutils.CheckViolationErrorCode)
+				MustNotInsert(t, db, "public", "02_set_not_null", "users", map[string]string{
+					"id": "1",
+				}, testutils.CheckViolationErrorCode)
+
+				// Inserting a non-NULL value works.
+				MustInsert(t, db, "public", "02_set_not_null", "users", map[string]string{
+					"name": "alice",
+				})
+			},
+			afterRollback: func(t *testing.T, db *sql.DB) {
+				// The NOT NULL constraint has been removed.
+				CheckConstraintMustNotExist(t, db, "public", "users", migrations.NotNullConstraintName("name"))
+
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "users")
+			},
+			afterComplete: func(t *testing.T, db *sql.DB) {
+				// The NOT NULL constraint has been replaced by `NOT NULL` on the column.
+				CheckConstraintMustNotExist(t, db, "public", "users", migrations.NotNullConstraintName("name"))
+
+				// Inserting a NULL value fails.
+				MustNotInsert(t, db, "public", "02_set_not_null", "users", map[string]string{
+					"id": "2",
+				}, testutils.NotNullViolationErrorCode)
+
+				rows := MustSelect(t, db, "public", "02_set_not_null", "users")
+				assert.Equal(t, []map[string]any{
+					{"id": 1, "name": "alice"},
+				}, rows)
+
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "users")
+			},
+		},
 	})
 }
 
@@ -519,6 +730,32 @@ func TestSetNotNullValidation(t *testing.T) {
 	}
 
 	ExecuteTests(t, TestCases{
+		{
+			name: "up SQL that is the column itself is rejected if the column has NULL values",
+			migrations: []migrations.Migration{
+				createTableMigration,
+				{
+					Name: "02_insert_review",
+					Operations: migrations.Operations{
+						&migrations.OpRawSQL{
+							Up: "INSERT INTO reviews (username, product) VALUES ('alice', 'apple')",
+						},
+					},
+				},
+				{
+					Name: "03_set_not_null",
+					Operations: migrations.Operations{
+						&migrations.OpAlterColumn{
+							Table:    "reviews",
+							Column:   "review",
+							Nullable: ptr(false),
+							Up:       ptr("review"),
+						},
+					},
+				},
+			},
+			wantStartErr: migrations.ColumnHasNullValuesError{Table: "reviews", Name: "review"},
+		},
 		{
 			name: "up SQL is mandatory",
 			migrations: []migrations.Migration{
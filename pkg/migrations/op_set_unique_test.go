@@ -63,6 +63,12 @@ func TestSetColumnUnique(t *testing.T) {
 				},
 			},
 			afterStart: func(t *testing.T, db *sql.DB) {
+				// The trigger of the table runs the up and down expressions.
+				TableTriggerMustExist(t, db, "public", "reviews", map[string]string{
+					migrations.TriggerFunctionName("reviews", "review"):                           "review || '-' || (random()*1000000)::integer",
+					migrations.TriggerFunctionName("reviews", migrations.TemporaryName("review")): "review",
+				})
+
 				// Inserting values into the old schema that violate uniqueness should succeed.
 				MustInsert(t, db, "public", "01_add_table", "reviews", map[string]string{
 					"username": "alice", "product": "apple", "review": "good",
@@ -92,6 +98,8 @@ func TestSetColumnUnique(t *testing.T) {
 				TriggerMustNotExist(t, db, "public", "reviews", migrations.TriggerName("reviews", "review"))
 				// The down trigger no longer exists.
 				TriggerMustNotExist(t, db, "public", "reviews", migrations.TriggerName("reviews", migrations.TemporaryName("review")))
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "reviews")
 			},
 			afterComplete: func(t *testing.T, db *sql.DB) {
 				// The new (temporary) `review` column should not exist on the underlying table.
@@ -106,6 +114,8 @@ func TestSetColumnUnique(t *testing.T) {
 				TriggerMustNotExist(t, db, "public", "reviews", migrations.TriggerName("reviews", "review"))
 				// The down trigger no longer exists.
 				TriggerMustNotExist(t, db, "public", "reviews", migrations.TriggerName("reviews", migrations.TemporaryName("review")))
+				// The trigger of the table no longer exists.
+				TableTriggerMustNotExist(t, db, "public", "reviews")
 
 				// Inserting values into the new schema that violate uniqueness should fail.
 				MustInsert(t, db, "public", "02_set_unique", "reviews", map[string]string{
@@ -0,0 +1,370 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+// referencingForeignKey is a foreign key on another table (or the same one)
+// that references a column of a table
+type referencingForeignKey struct {
+	// table is the referencing table, quoted as needed
+	table string
+
+	// name is the name of the foreign key
+	name string
+
+	// definition is the definition of the foreign key, as given by
+	// pg_get_constraintdef
+	definition string
+
+	// validated is false for a foreign key that was created, or left, NOT VALID
+	validated bool
+
+	// comment is the comment of the foreign key, if any
+	comment sql.NullString
+}
+
+// validationPendingPrefix prefixes the comment of the foreign keys that
+// `replacePrimaryKeyColumn` re-created NOT VALID although they were valid
+// before, followed by their original comment. It marks the foreign keys that
+// `validateReferencingForeignKeys` has to validate, even if the call that
+// re-created them was interrupted.
+const validationPendingPrefix = "_pgroll_validation_pending:"
+
+// primaryKeyName returns the name of the primary key constraint of the
+// table, or an empty string if it has none
+func primaryKeyName(ctx context.Context, conn *sql.DB, table string) (string, error) {
+	var name string
+	err := conn.QueryRowContext(ctx, `SELECT conname
+		FROM pg_catalog.pg_constraint
+		WHERE conrelid = to_regclass($1)
+		AND contype = 'p'`, pq.QuoteIdentifier(table)).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	return name, err
+}
+
+// duplicatePrimaryKey creates the unique index that backs the new primary key
+// when a primary key column is duplicated: the same columns as the primary
+// key, with the duplicated column in place of the original one. The index is
+// created concurrently and turned into the primary key by `swapPrimaryKey`.
+func duplicatePrimaryKey(ctx context.Context, conn *sql.DB, table *schema.Table, column string) error {
+	pk, err := primaryKeyName(ctx, conn, table.Name)
+	if err != nil {
+		return err
+	}
+	if pk == "" {
+		return nil
+	}
+
+	if err := dropInvalidIndex(ctx, conn, DuplicationName(pk)); err != nil {
+		return err
+	}
+
+	_, err = conn.ExecContext(ctx, fmt.Sprintf("CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS %s ON %s (%s)",
+		pq.QuoteIdentifier(DuplicationName(pk)),
+		pq.QuoteIdentifier(table.Name),
+		strings.Join(quoteColumnNames(copyAndReplace(table.PrimaryKey, column, TemporaryName(column))), ", ")))
+
+	return err
+}
+
+// swapPrimaryKey replaces a primary key column with its duplicate, in place of
+// `dropOriginalColumn` and the rename of the duplicated column:
+// * the NOT NULL constraint of the duplicated column is validated;
+// * in a single transaction, foreign keys referencing the column are dropped,
+// the column's sequence is moved to the duplicated column (or, for an identity
+// column, the identity is re-created on it, starting from the next value of
+// its sequence), the original column
+// is dropped (along with the primary key), the duplicated column is renamed,
+// the primary key is re-created from the index built by `duplicatePrimaryKey`
+// and the foreign keys are re-created without being validated;
+// * the re-created foreign keys that were valid before are validated.
+//
+// Each step is skipped if a previous, interrupted call already performed it.
+func swapPrimaryKey(ctx context.Context, conn *sql.DB, table *schema.Table, column, newType string) error {
+	tempExists, err := columnExists(ctx, conn, table.Name, TemporaryName(column))
+	if err != nil {
+		return err
+	}
+	if tempExists {
+		if err := replacePrimaryKeyColumn(ctx, conn, table, column, newType); err != nil {
+			return err
+		}
+	}
+
+	return validateReferencingForeignKeys(ctx, conn, table.Name, column)
+}
+
+func replacePrimaryKeyColumn(ctx context.Context, conn *sql.DB, table *schema.Table, column, newType string) error {
+	pk, err := primaryKeyName(ctx, conn, table.Name)
+	if err != nil {
+		return err
+	}
+
+	// Validate the NOT NULL constraint of the duplicated column before taking
+	// any strong lock, so that setting NOT NULL below doesn't scan the table.
+	notNull := DuplicationName(NotNullConstraintName(column))
+	exists, err := constraintExists(ctx, conn, table.Name, notNull)
+	if err != nil {
+		return err
+	}
+	if exists {
+		_, err = conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s VALIDATE CONSTRAINT %s",
+			pq.QuoteIdentifier(table.Name),
+			pq.QuoteIdentifier(notNull)))
+		if err != nil {
+			return fmt.Errorf("failed to validate not null constraint: %w", err)
+		}
+	}
+
+	fks, err := referencingForeignKeys(ctx, conn, table.Name, column)
+	if err != nil {
+		return err
+	}
+
+	sequence, err := serialSequence(ctx, conn, table.Name, column)
+	if err != nil {
+		return err
+	}
+
+	// The sequence of an identity column can't be moved to another column, so
+	// the identity is re-created on the duplicated column instead
+	var identity string
+	if c := table.GetColumn(column); c != nil && sequence.Valid {
+		identity = strings.ToUpper(c.Identity)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The identity of the duplicated column starts where the sequence of the
+	// original column stopped, so no more rows can be inserted until the
+	// identity is re-created
+	var start, increment int64
+	if identity != "" {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("LOCK TABLE %s IN ACCESS EXCLUSIVE MODE", pq.QuoteIdentifier(table.Name)))
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx, fmt.Sprintf(`SELECT
+				CASE WHEN sq.is_called THEN sq.last_value + ps.seqincrement ELSE sq.last_value END,
+				ps.seqincrement
+			FROM %s AS sq, pg_catalog.pg_sequence AS ps
+			WHERE ps.seqrelid = $1::regclass`, sequence.String), sequence.String).Scan(&start, &increment)
+		if err != nil {
+			return fmt.Errorf("failed to read sequence %s: %w", sequence.String, err)
+		}
+
+		// The duplicated column took its values from the sequence until now
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT",
+			pq.QuoteIdentifier(table.Name),
+			pq.QuoteIdentifier(TemporaryName(column))))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Drop the foreign keys referencing the column, as they depend on the
+	// index of the primary key
+	for _, fk := range fks {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", fk.table, pq.QuoteIdentifier(fk.name)))
+		if err != nil {
+			return fmt.Errorf("failed to drop foreign key %q: %w", fk.name, err)
+		}
+	}
+
+	// Move the sequence of the column to the duplicated column, so that it
+	// isn't dropped along with the original column
+	if sequence.Valid && identity == "" {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s.%s",
+			sequence.String,
+			pq.QuoteIdentifier(table.Name),
+			pq.QuoteIdentifier(TemporaryName(column))))
+		if err != nil {
+			return fmt.Errorf("failed to move sequence %s: %w", sequence.String, err)
+		}
+
+		if isIntegerType(newType) {
+			_, err = tx.ExecContext(ctx, fmt.Sprintf("ALTER SEQUENCE %s AS %s", sequence.String, newType))
+			if err != nil {
+				return fmt.Errorf("failed to change the type of sequence %s: %w", sequence.String, err)
+			}
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s",
+		pq.QuoteIdentifier(table.Name),
+		pq.QuoteIdentifier(column)))
+	if err != nil {
+		return fmt.Errorf("failed to drop column %q: %w", column, err)
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s",
+		pq.QuoteIdentifier(table.Name),
+		pq.QuoteIdentifier(TemporaryName(column)),
+		pq.QuoteIdentifier(column)))
+	if err != nil {
+		return fmt.Errorf("failed to rename duplicated column %q: %w", column, err)
+	}
+
+	if pk != "" || identity != "" {
+		// The validated NOT NULL constraint spares a scan of the table
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL",
+			pq.QuoteIdentifier(table.Name),
+			pq.QuoteIdentifier(column)))
+		if err != nil {
+			return fmt.Errorf("failed to set column not null: %w", err)
+		}
+	}
+
+	if identity != "" {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s ADD GENERATED %s AS IDENTITY (START WITH %d INCREMENT BY %d)",
+			pq.QuoteIdentifier(table.Name),
+			pq.QuoteIdentifier(column),
+			identity,
+			start,
+			increment))
+		if err != nil {
+			return fmt.Errorf("failed to re-create identity of column %q: %w", column, err)
+		}
+	}
+
+	if pk != "" {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s PRIMARY KEY USING INDEX %s",
+			pq.QuoteIdentifier(table.Name),
+			pq.QuoteIdentifier(pk),
+			pq.QuoteIdentifier(DuplicationName(pk))))
+		if err != nil {
+			return fmt.Errorf("failed to create primary key: %w", err)
+		}
+	}
+
+	// Re-create the foreign keys against the new primary key, along with their
+	// comments. The ones that were valid are validated once the transaction is
+	// committed, and marked as such until then. The ones that were left NOT
+	// VALID stay so.
+	for _, fk := range fks {
+		definition := strings.TrimSuffix(fk.definition, " NOT VALID")
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s NOT VALID",
+			fk.table,
+			pq.QuoteIdentifier(fk.name),
+			definition))
+		if err != nil {
+			return fmt.Errorf("failed to re-create foreign key %q: %w", fk.name, err)
+		}
+
+		comment := fk.comment
+		if fk.validated {
+			comment = sql.NullString{String: validationPendingPrefix + fk.comment.String, Valid: true}
+		}
+		_, err = tx.ExecContext(ctx, commentOnForeignKeySQL(fk, comment))
+		if err != nil {
+			return fmt.Errorf("failed to set comment of foreign key %q: %w", fk.name, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// serialSequence returns the sequence of a serial or identity column, if any,
+// as a qualified and quoted name
+func serialSequence(ctx context.Context, conn *sql.DB, table, column string) (sql.NullString, error) {
+	var sequence sql.NullString
+	err := conn.QueryRowContext(ctx, "SELECT pg_get_serial_sequence($1, $2)", pq.QuoteIdentifier(table), column).Scan(&sequence)
+
+	return sequence, err
+}
+
+// referencingForeignKeys returns the foreign keys that reference the given
+// column of the table
+func referencingForeignKeys(ctx context.Context, conn *sql.DB, table, column string) ([]referencingForeignKey, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT c.conrelid::regclass::text, c.conname, pg_get_constraintdef(c.oid),
+		c.convalidated, obj_description(c.oid, 'pg_constraint')
+		FROM pg_catalog.pg_constraint c
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = c.confrelid AND a.attnum = ANY (c.confkey)
+		WHERE c.contype = 'f'
+		AND c.confrelid = to_regclass($1)
+		AND a.attname = $2
+		ORDER BY 1, 2`, pq.QuoteIdentifier(table), column)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fks []referencingForeignKey
+	for rows.Next() {
+		var fk referencingForeignKey
+		if err := rows.Scan(&fk.table, &fk.name, &fk.definition, &fk.validated, &fk.comment); err != nil {
+			return nil, err
+		}
+		fks = append(fks, fk)
+	}
+
+	return fks, rows.Err()
+}
+
+// validateReferencingForeignKeys validates the foreign keys referencing the
+// given column of the table that `replacePrimaryKeyColumn` re-created NOT
+// VALID although they were valid before, and restores their comments. Foreign
+// keys that were left NOT VALID by the user aren't validated.
+func validateReferencingForeignKeys(ctx context.Context, conn *sql.DB, table, column string) error {
+	fks, err := referencingForeignKeys(ctx, conn, table, column)
+	if err != nil {
+		return err
+	}
+
+	for _, fk := range fks {
+		original, pending := strings.CutPrefix(fk.comment.String, validationPendingPrefix)
+		if !fk.comment.Valid || !pending {
+			continue
+		}
+
+		if !fk.validated {
+			_, err = conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s VALIDATE CONSTRAINT %s", fk.table, pq.QuoteIdentifier(fk.name)))
+			if err != nil {
+				return fmt.Errorf("failed to validate foreign key %q: %w", fk.name, err)
+			}
+		}
+
+		comment := sql.NullString{String: original, Valid: original != ""}
+		_, err = conn.ExecContext(ctx, commentOnForeignKeySQL(fk, comment))
+		if err != nil {
+			return fmt.Errorf("failed to set comment of foreign key %q: %w", fk.name, err)
+		}
+	}
+
+	return nil
+}
+
+// commentOnForeignKeySQL returns the statement that sets the comment of the
+// foreign key, or removes it if the comment is NULL
+func commentOnForeignKeySQL(fk referencingForeignKey, comment sql.NullString) string {
+	value := "NULL"
+	if comment.Valid {
+		value = pq.QuoteLiteral(comment.String)
+	}
+
+	return fmt.Sprintf("COMMENT ON CONSTRAINT %s ON %s IS %s", pq.QuoteIdentifier(fk.name), fk.table, value)
+}
+
+// isIntegerType returns true if the type is one that sequences can have
+func isIntegerType(t string) bool {
+	return slices.Contains([]string{"smallint", "int2", "integer", "int", "int4", "bigint", "int8"}, strings.ToLower(strings.TrimSpace(t)))
+}
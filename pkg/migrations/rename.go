@@ -16,7 +16,12 @@ import (
 // * renames a duplicated column to its original name
 // * renames any foreign keys on the duplicated column to their original name.
 // * Validates and renames any temporary `CHECK` constraints on the duplicated column.
-func RenameDuplicatedColumn(ctx context.Context, conn *sql.DB, table *schema.Table, column *schema.Column) error {
+// * renames any indexes and `UNIQUE` constraints on the duplicated column to
+// their original name.
+//
+// Each step is skipped if a previous, interrupted call already performed it,
+// so the function can be retried until it succeeds.
+func RenameDuplicatedColumn(ctx context.Context, conn *sql.DB, table *schema.Table, column string) error {
 	const (
 		cRenameColumnSQL           = `ALTER TABLE IF EXISTS %s RENAME COLUMN %s TO %s`
 		cRenameConstraintSQL       = `ALTER TABLE IF EXISTS %s RENAME CONSTRAINT %s TO %s`
@@ -28,14 +33,20 @@ func RenameDuplicatedColumn(ctx context.Context, conn *sql.DB, table *schema.Tab
 	)
 
 	// Rename the old column to the new column name
-	renameColumnSQL := fmt.Sprintf(cRenameColumnSQL,
-		pq.QuoteIdentifier(table.Name),
-		pq.QuoteIdentifier(TemporaryName(column.Name)),
-		pq.QuoteIdentifier(column.Name))
-
-	_, err := conn.ExecContext(ctx, renameColumnSQL)
+	tempExists, err := columnExists(ctx, conn, table.Name, TemporaryName(column))
 	if err != nil {
-		return fmt.Errorf("failed to rename duplicated column %q: %w", column.Name, err)
+		return err
+	}
+	if tempExists {
+		renameColumnSQL := fmt.Sprintf(cRenameColumnSQL,
+			pq.QuoteIdentifier(table.Name),
+			pq.QuoteIdentifier(TemporaryName(column)),
+			pq.QuoteIdentifier(column))
+
+		_, err = conn.ExecContext(ctx, renameColumnSQL)
+		if err != nil {
+			return fmt.Errorf("failed to rename duplicated column %q: %w", column, err)
+		}
 	}
 
 	// Rename any foreign keys on the duplicated column from their temporary name
@@ -45,11 +56,19 @@ func RenameDuplicatedColumn(ctx context.Context, conn *sql.DB, table *schema.Tab
 			continue
 		}
 
-		if slices.Contains(fk.Columns, TemporaryName(column.Name)) {
+		if isOnDuplicatedColumn(fk.Columns, column) {
+			exists, err := constraintExists(ctx, conn, table.Name, fk.Name)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				continue
+			}
+
 			renameConstraintSQL := fmt.Sprintf(cRenameConstraintSQL,
 				pq.QuoteIdentifier(table.Name),
 				pq.QuoteIdentifier(fk.Name),
-				pq.QuoteIdentifier(StripDuplicationPrefix(fk.Name)),
+				pq.QuoteIdentifier(originalName(table, column, fk.Name)),
 			)
 
 			_, err = conn.ExecContext(ctx, renameConstraintSQL)
@@ -66,7 +85,15 @@ func RenameDuplicatedColumn(ctx context.Context, conn *sql.DB, table *schema.Tab
 			continue
 		}
 
-		if slices.Contains(cc.Columns, TemporaryName(column.Name)) {
+		if isOnDuplicatedColumn(cc.Columns, column) {
+			exists, err := constraintExists(ctx, conn, table.Name, cc.Name)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				continue
+			}
+
 			validateConstraintSQL := fmt.Sprintf(cValidateConstraintSQL,
 				pq.QuoteIdentifier(table.Name),
 				pq.QuoteIdentifier(cc.Name),
@@ -80,78 +107,201 @@ func RenameDuplicatedColumn(ctx context.Context, conn *sql.DB, table *schema.Tab
 			renameConstraintSQL := fmt.Sprintf(cRenameConstraintSQL,
 				pq.QuoteIdentifier(table.Name),
 				pq.QuoteIdentifier(cc.Name),
-				pq.QuoteIdentifier(StripDuplicationPrefix(cc.Name)),
+				pq.QuoteIdentifier(originalName(table, column, cc.Name)),
 			)
 
 			_, err = conn.ExecContext(ctx, renameConstraintSQL)
 			if err != nil {
 				return fmt.Errorf("failed to rename check constraint %q: %w", cc.Name, err)
 			}
-
-			// If the constraint is a `NOT NULL` constraint, convert the duplicated
-			// unchecked `NOT NULL` constraint into a `NOT NULL` attribute on the
-			// column.
-			if IsNotNullConstraintName(StripDuplicationPrefix(cc.Name)) {
-				// Apply `NOT NULL` attribute to the column. This uses the validated constraint
-				setNotNullSQL := fmt.Sprintf(cSetNotNullSQL,
-					pq.QuoteIdentifier(table.Name),
-					pq.QuoteIdentifier(column.Name),
-				)
-
-				_, err = conn.ExecContext(ctx, setNotNullSQL)
-				if err != nil {
-					return fmt.Errorf("failed to set column not null: %w", err)
-				}
-
-				// Drop the constraint
-				dropConstraintSQL := fmt.Sprintf(cDropConstraintSQL,
-					pq.QuoteIdentifier(table.Name),
-					pq.QuoteIdentifier(NotNullConstraintName(column.Name)),
-				)
-
-				_, err = conn.ExecContext(ctx, dropConstraintSQL)
-				if err != nil {
-					return fmt.Errorf("failed to drop not null constraint: %w", err)
-				}
-			}
 		}
 	}
 
-	// Rename any `UNIQUE` indexes on the duplicated column and use them to
-	// create `UNIQUE` constraints.
-	for _, ui := range table.Indexes {
-		if !IsDuplicatedName(ui.Name) {
-			continue
+	// If the column had a `NOT NULL` constraint, convert the duplicated
+	// unchecked `NOT NULL` constraint (validated and renamed above) into a `NOT
+	// NULL` attribute on the column. The constraint is looked up by name rather
+	// than through the schema so that an interrupted conversion is picked up
+	// again.
+	notNullExists, err := constraintExists(ctx, conn, table.Name, NotNullConstraintName(column))
+	if err != nil {
+		return err
+	}
+	if notNullExists {
+		// Apply `NOT NULL` attribute to the column. This uses the validated constraint
+		setNotNullSQL := fmt.Sprintf(cSetNotNullSQL,
+			pq.QuoteIdentifier(table.Name),
+			pq.QuoteIdentifier(column),
+		)
+
+		_, err = conn.ExecContext(ctx, setNotNullSQL)
+		if err != nil {
+			return fmt.Errorf("failed to set column not null: %w", err)
 		}
-		if !ui.Unique {
+
+		// Drop the constraint
+		dropConstraintSQL := fmt.Sprintf(cDropConstraintSQL,
+			pq.QuoteIdentifier(table.Name),
+			pq.QuoteIdentifier(NotNullConstraintName(column)),
+		)
+
+		_, err = conn.ExecContext(ctx, dropConstraintSQL)
+		if err != nil {
+			return fmt.Errorf("failed to drop not null constraint: %w", err)
+		}
+	}
+
+	// Rename any indexes and `UNIQUE` constraints on the duplicated column.
+	// Renaming a constraint renames its index, and turning an index into a
+	// constraint gives the index the name of the constraint, so each index is
+	// renamed in a single statement: an interrupted call never leaves an index
+	// with its original name but without its constraint.
+	// The indexes are looked up by the column they use, so that indexes renamed
+	// by a previous, interrupted call are skipped.
+	indexes, err := indexesOnColumn(ctx, conn, table.Name, column)
+	if err != nil {
+		return err
+	}
+	for _, name := range indexes {
+		ui, ok := table.Indexes[name]
+		if !ok || !IsDuplicatedName(ui.Name) {
 			continue
 		}
 
-		if slices.Contains(ui.Columns, TemporaryName(column.Name)) {
-			// Rename the unique index to its original name
-			renameIndexSQL := fmt.Sprintf(cRenameIndexSQL,
+		original := originalName(table, column, ui.Name)
+
+		switch {
+		case isUniqueConstraint(table, ui.Name):
+			// The duplicate of a `UNIQUE` constraint, created as a constraint
+			exists, err := constraintExists(ctx, conn, table.Name, ui.Name)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				continue
+			}
+
+			renameConstraintSQL := fmt.Sprintf(cRenameConstraintSQL,
+				pq.QuoteIdentifier(table.Name),
 				pq.QuoteIdentifier(ui.Name),
-				pq.QuoteIdentifier(StripDuplicationPrefix(ui.Name)),
+				pq.QuoteIdentifier(original),
 			)
 
-			_, err = conn.ExecContext(ctx, renameIndexSQL)
+			_, err = conn.ExecContext(ctx, renameConstraintSQL)
+			if err != nil {
+				return fmt.Errorf("failed to rename unique constraint %q: %w", ui.Name, err)
+			}
+
+		case duplicatesUniqueConstraint(table, &ui, original):
+			// The duplicate of a `UNIQUE` constraint, created as an index only by
+			// migrations started before duplicates were made constraints. Create
+			// the unique constraint using the index, unless a previous attempt
+			// already did so
+			exists, err := constraintExists(ctx, conn, table.Name, original)
 			if err != nil {
-				return fmt.Errorf("failed to rename unique index %q: %w", ui.Name, err)
+				return err
+			}
+			if exists {
+				continue
 			}
 
-			// Create a unique constraint using the unique index
 			createUniqueConstraintSQL := fmt.Sprintf(cCreateUniqueConstraintSQL,
 				pq.QuoteIdentifier(table.Name),
-				pq.QuoteIdentifier(StripDuplicationPrefix(ui.Name)),
-				pq.QuoteIdentifier(StripDuplicationPrefix(ui.Name)),
+				pq.QuoteIdentifier(original),
+				pq.QuoteIdentifier(ui.Name),
 			)
 
 			_, err = conn.ExecContext(ctx, createUniqueConstraintSQL)
 			if err != nil {
 				return fmt.Errorf("failed to create unique constraint from index %q: %w", ui.Name, err)
 			}
+
+		default:
+			// Rename the index to its original name
+			renameIndexSQL := fmt.Sprintf(cRenameIndexSQL,
+				pq.QuoteIdentifier(ui.Name),
+				pq.QuoteIdentifier(original),
+			)
+
+			_, err = conn.ExecContext(ctx, renameIndexSQL)
+			if err != nil {
+				return fmt.Errorf("failed to rename index %q: %w", ui.Name, err)
+			}
 		}
 	}
 
 	return nil
 }
+
+// originalName returns the name of the object that was duplicated under the
+// given name
+func originalName(table *schema.Table, column, name string) string {
+	if original, ok := table.GeneratedNames[name]; ok {
+		return original
+	}
+
+	// The NOT NULL constraint is created on the duplicated column only, so its
+	// name is never recorded
+	if name == DuplicationName(NotNullConstraintName(column)) {
+		return NotNullConstraintName(column)
+	}
+
+	return StripDuplicationPrefix(name)
+}
+
+// isUniqueConstraint returns true if the table has a `UNIQUE` constraint with
+// the given name
+func isUniqueConstraint(table *schema.Table, name string) bool {
+	_, ok := table.UniqueConstraints[name]
+	return ok
+}
+
+// duplicatesUniqueConstraint returns true if the duplicated index was created
+// for a `UNIQUE` constraint rather than for an index. Once a previous,
+// interrupted completion has dropped the original column, the original is no
+// longer in the schema, and any unique index that can back a constraint is
+// taken to duplicate one.
+func duplicatesUniqueConstraint(table *schema.Table, idx *schema.Index, original string) bool {
+	if _, ok := table.UniqueConstraints[original]; ok {
+		return true
+	}
+	if _, ok := table.Indexes[original]; ok {
+		return false
+	}
+	if !idx.Unique || idx.Predicate != nil {
+		return false
+	}
+	for _, key := range idx.Columns {
+		if _, ok := table.Columns[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// isOnDuplicatedColumn returns true if the columns of a duplicated constraint
+// or index include the duplicated column. The column is matched by both its
+// temporary and its final name, as an interrupted completion may have renamed
+// the column before renaming the constraints defined on it.
+func isOnDuplicatedColumn(columns []string, column string) bool {
+	return slices.Contains(columns, TemporaryName(column)) || slices.Contains(columns, column)
+}
+
+// dropOriginalColumn drops the original column of a duplicated column. The
+// column is only dropped while the duplicate still exists under its temporary
+// name: once the duplicate has been renamed, the column with the original name
+// is the duplicate itself and must be kept.
+func dropOriginalColumn(ctx context.Context, conn *sql.DB, table, column string) error {
+	tempExists, err := columnExists(ctx, conn, table, TemporaryName(column))
+	if err != nil {
+		return err
+	}
+	if !tempExists {
+		return nil
+	}
+
+	_, err = conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE IF EXISTS %s DROP COLUMN IF EXISTS %s",
+		pq.QuoteIdentifier(table),
+		pq.QuoteIdentifier(column)))
+
+	return err
+}
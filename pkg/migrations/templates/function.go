@@ -7,29 +7,33 @@ const Function = `CREATE OR REPLACE FUNCTION {{ .Name | qi }}()
     LANGUAGE PLPGSQL
     AS $$
     DECLARE
-      {{- $schemaName := .SchemaName  }}
-      {{- $tableName := .TableName  }}
-      {{ range $name, $col := .Columns }} 
-      {{- $name | qi }} {{ $schemaName | qi }}.{{ $tableName | qi}}.{{ $col.Name | qi }}%TYPE := NEW.{{ $col.Name | qi }};
-      {{ end -}}
       latest_schema text;
-      search_path text;
+      version_schema text;
     BEGIN
-      SELECT {{ .SchemaName | ql }} || '_' || latest_version
+      SELECT {{ .StateSchema | qi }}.identifier({{ .SchemaName | ql }} || '_', latest_version)
         INTO latest_schema
         FROM {{ .StateSchema | qi }}.latest_version({{ .SchemaName | ql }});
 
-      SELECT current_setting
-        INTO search_path
-        FROM current_setting('search_path');
+      SELECT COALESCE(NULLIF(current_setting({{ .VersionSetting | ql }}, true), ''), (current_schemas(false))[1])
+        INTO version_schema;
+      {{- $schemaName := .SchemaName }}
+      {{- $tableName := .TableName }}
+      {{- range .Triggers }}
 
-      IF search_path {{- if eq .Direction "up" }} != {{- else }} = {{- end }} latest_schema {{ if .TestExpr  -}} AND {{ .TestExpr }} {{ end -}} THEN
-        NEW.{{ .PhysicalColumn | qi  }} = {{ .SQL }};
+      IF version_schema {{- if eq .Direction "up" }} IS DISTINCT FROM {{- else }} = {{- end }} latest_schema {{ if .TestExpr -}} AND {{ .TestExpr }} {{ end -}} THEN
+        DECLARE
+          {{- range $name, $col := .Columns }}
+          {{ $name | qi }} {{ $schemaName | qi }}.{{ $tableName | qi }}.{{ $col.Name | qi }}%TYPE := NEW.{{ $col.Name | qi }};
+          {{- end }}
+        BEGIN
+          NEW.{{ .PhysicalColumn | qi }} = {{ .SQL }};
+        END;
       {{- if .ElseExpr }}
       ELSE
         {{ .ElseExpr }};
       {{- end }}
       END IF;
+      {{- end }}
 
       RETURN NEW;
     END; $$
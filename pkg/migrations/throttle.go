@@ -0,0 +1,185 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const (
+	// minThrottleDelay is the delay between batches once a backfill starts
+	// slowing down, if no batch delay is configured
+	minThrottleDelay = 100 * time.Millisecond
+
+	// defaultMaxThrottleDelay is the longest delay between batches when no
+	// maximum is configured
+	defaultMaxThrottleDelay = 10 * time.Second
+)
+
+// ThrottleConfig configures how backfills adapt their pace to the health of
+// the database. A zero threshold disables the corresponding signal, and
+// throttling is disabled altogether when all thresholds are zero.
+type ThrottleConfig struct {
+	// MaxReplicationLag is the replication lag of the standbys, as reported by
+	// `pg_stat_replication`, above which backfills slow down and pause
+	MaxReplicationLag time.Duration
+
+	// MaxWaitingLocks is the number of lock requests waiting to be granted, as
+	// reported by `pg_locks`, above which backfills slow down and pause
+	MaxWaitingLocks int
+
+	// MaxBatchDuration is the duration of a batch above which backfills slow
+	// down
+	MaxBatchDuration time.Duration
+
+	// MaxDelay is the longest delay between two batches when slowing down
+	MaxDelay time.Duration
+}
+
+func (c ThrottleConfig) enabled() bool {
+	return c.MaxReplicationLag > 0 || c.MaxWaitingLocks > 0 || c.MaxBatchDuration > 0
+}
+
+func (c ThrottleConfig) maxDelay() time.Duration {
+	if c.MaxDelay <= 0 {
+		return defaultMaxThrottleDelay
+	}
+	return c.MaxDelay
+}
+
+type load int
+
+const (
+	// the database is busy, but not beyond any threshold
+	normalLoad load = iota
+	// all signals are below half of their threshold
+	idleLoad
+	// the duration of the last batch is above its threshold
+	slowBatchLoad
+	// replication lag or waiting locks are above their threshold
+	overLoad
+)
+
+// throttler spaces out the batches of a backfill. Without throttling, it
+// waits for the configured batch delay between batches. With throttling, the
+// delay doubles each time a threshold is exceeded, up to the maximum delay,
+// and halves back towards the configured batch delay while the database is
+// idle. While replication lag or waiting locks exceed their threshold, the
+// backfill is paused until they recover.
+type throttler struct {
+	conn  *sql.DB
+	cfg   ThrottleConfig
+	base  time.Duration
+	delay time.Duration
+
+	// sleep waits between batches, and is replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func newThrottler(conn *sql.DB, bf *BackfillConfig) *throttler {
+	t := &throttler{conn: conn, sleep: sleep}
+	if bf != nil {
+		t.cfg = bf.Throttle
+		t.base = bf.BatchDelay
+	}
+	t.delay = t.base
+	return t
+}
+
+// wait waits before running the next batch, given the duration of the
+// previous one. It returns early with the context's error if the context is
+// cancelled.
+func (t *throttler) wait(ctx context.Context, lastBatch time.Duration) error {
+	if !t.cfg.enabled() {
+		return t.sleep(ctx, t.base)
+	}
+
+	for {
+		l, err := t.sample(ctx, lastBatch)
+		if err != nil {
+			return err
+		}
+
+		switch l {
+		case overLoad, slowBatchLoad:
+			t.delay = min(max(2*t.delay, minThrottleDelay), max(t.cfg.maxDelay(), t.base))
+		case idleLoad:
+			t.delay = max(t.delay/2, t.base)
+		}
+
+		if err := t.sleep(ctx, t.delay); err != nil {
+			return err
+		}
+
+		if l != overLoad {
+			return nil
+		}
+
+		// pause until the database recovers; the duration of the last batch
+		// is not relevant anymore
+		lastBatch = 0
+	}
+}
+
+// sample measures the load of the database
+func (t *throttler) sample(ctx context.Context, lastBatch time.Duration) (load, error) {
+	idle := true
+
+	if t.cfg.MaxReplicationLag > 0 {
+		var lagSeconds float64
+		err := t.conn.QueryRowContext(ctx, `SELECT COALESCE(EXTRACT(EPOCH FROM max(GREATEST(write_lag, flush_lag, replay_lag))), 0)
+			FROM pg_catalog.pg_stat_replication`).Scan(&lagSeconds)
+		if err != nil {
+			return normalLoad, err
+		}
+		lag := time.Duration(lagSeconds * float64(time.Second))
+		if lag > t.cfg.MaxReplicationLag {
+			return overLoad, nil
+		}
+		idle = idle && lag < t.cfg.MaxReplicationLag/2
+	}
+
+	if t.cfg.MaxWaitingLocks > 0 {
+		var waiting int
+		err := t.conn.QueryRowContext(ctx, `SELECT count(*) FROM pg_catalog.pg_locks WHERE NOT granted`).Scan(&waiting)
+		if err != nil {
+			return normalLoad, err
+		}
+		if waiting > t.cfg.MaxWaitingLocks {
+			return overLoad, nil
+		}
+		idle = idle && 2*waiting < t.cfg.MaxWaitingLocks
+	}
+
+	if t.cfg.MaxBatchDuration > 0 {
+		if lastBatch > t.cfg.MaxBatchDuration {
+			return slowBatchLoad, nil
+		}
+		idle = idle && lastBatch < t.cfg.MaxBatchDuration/2
+	}
+
+	if idle {
+		return idleLoad, nil
+	}
+	return normalLoad, nil
+}
+
+// sleep waits for the given duration, returning early with the context's
+// error if the context is cancelled
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
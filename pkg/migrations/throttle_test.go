@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottlerAdaptsDelayToBatchDuration(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	th := newThrottler(nil, &BackfillConfig{
+		BatchDelay: 10 * time.Millisecond,
+		Throttle: ThrottleConfig{
+			MaxBatchDuration: 50 * time.Millisecond,
+			MaxDelay:         300 * time.Millisecond,
+		},
+	})
+
+	// record the waits instead of sleeping
+	var slept []time.Duration
+	th.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+
+	// slow batches double the delay, up to the maximum delay
+	for _, want := range []time.Duration{100, 200, 300, 300} {
+		assert.NoError(t, th.wait(ctx, 60*time.Millisecond))
+		assert.Equal(t, want*time.Millisecond, th.delay)
+	}
+
+	// batches that are neither slow nor fast keep the delay as it is
+	assert.NoError(t, th.wait(ctx, 40*time.Millisecond))
+	assert.Equal(t, 300*time.Millisecond, th.delay)
+
+	// fast batches halve the delay, down to the configured batch delay
+	assert.NoError(t, th.wait(ctx, time.Millisecond))
+	assert.Equal(t, 150*time.Millisecond, th.delay)
+	assert.NoError(t, th.wait(ctx, time.Millisecond))
+	assert.Equal(t, 75*time.Millisecond, th.delay)
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, th.wait(ctx, time.Millisecond))
+	}
+	assert.Equal(t, 10*time.Millisecond, th.delay)
+
+	// each batch waits for the delay in effect after it
+	assert.Equal(t, []time.Duration{
+		100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond,
+		300 * time.Millisecond,
+		150 * time.Millisecond, 75 * time.Millisecond,
+		37500 * time.Microsecond, 18750 * time.Microsecond, 10 * time.Millisecond, 10 * time.Millisecond, 10 * time.Millisecond,
+	}, slept)
+}
+
+func TestThrottlerWaitIsInterruptible(t *testing.T) {
+	t.Parallel()
+
+	th := newThrottler(nil, &BackfillConfig{BatchDelay: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, th.wait(ctx, 0), context.Canceled)
+}
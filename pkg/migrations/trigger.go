@@ -6,6 +6,10 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
 	"text/template"
 
 	"github.com/lib/pq"
@@ -20,26 +24,130 @@ const (
 	TriggerDirectionDown TriggerDirection = "down"
 )
 
+// VersionSchemaSetting is the name of the session setting that tells the
+// triggers which version schema performed a write. Clients that don't set
+// the search_path to a version schema, or that access the views through
+// schema-qualified names, must set it to the name of the version schema they
+// use, eg. `SET pgroll.version_schema = 'public_02_add_column'`. When it is
+// unset, the version schema is the first schema in the search_path.
+const VersionSchemaSetting = "pgroll.version_schema"
+
+const triggerPrefix = "_pgroll_trigger_"
+
+// triggerConfig is the configuration of a single up or down expression that
+// keeps a column in sync during a migration. All the expressions on a table
+// are run by a single trigger on the table.
 type triggerConfig struct {
-	Name           string
-	Direction      TriggerDirection
-	Columns        map[string]schema.Column
-	SchemaName     string
-	TableName      string
-	PhysicalColumn string
-	StateSchema    string
-	TestExpr       string
-	ElseExpr       string
-	SQL            string
+	Name           string                   `json:"name"`
+	Direction      TriggerDirection         `json:"direction"`
+	Columns        map[string]schema.Column `json:"columns"`
+	SchemaName     string                   `json:"schema_name"`
+	TableName      string                   `json:"table_name"`
+	PhysicalColumn string                   `json:"physical_column"`
+	StateSchema    string                   `json:"state_schema"`
+	TestExpr       string                   `json:"test_expr,omitempty"`
+	ElseExpr       string                   `json:"else_expr,omitempty"`
+	SQL            string                   `json:"sql"`
+}
+
+// tableTriggerConfig is the configuration of the trigger that runs all the
+// up and down expressions on a table
+type tableTriggerConfig struct {
+	Name        string
+	SchemaName  string
+	TableName   string
+	StateSchema string
+	Triggers    []triggerConfig
+}
+
+// VersionSetting is the name of the setting that identifies the version
+// schema of a session in the trigger function
+func (tableTriggerConfig) VersionSetting() string {
+	return VersionSchemaSetting
 }
 
+// createTrigger adds the up or down expression to the trigger of the table,
+// creating the trigger if needed. An expression with the same name as an
+// existing one replaces it.
 func createTrigger(ctx context.Context, conn *sql.DB, cfg triggerConfig) error {
-	funcSQL, err := buildFunction(cfg)
+	triggers, err := loadTriggers(ctx, conn, cfg.TableName)
 	if err != nil {
 		return err
 	}
 
-	_, err = conn.ExecContext(ctx, funcSQL)
+	triggers = slices.DeleteFunc(triggers, func(t triggerConfig) bool {
+		return t.Name == cfg.Name
+	})
+	triggers = append(triggers, cfg)
+
+	return writeTriggers(ctx, conn, cfg.TableName, triggers, true)
+}
+
+// dropTrigger removes the up or down expression with the given name from the
+// trigger of the table. The trigger is dropped along with its last
+// expression.
+func dropTrigger(ctx context.Context, conn *sql.DB, table, name string) error {
+	triggers, err := loadTriggers(ctx, conn, table)
+	if err != nil {
+		return err
+	}
+
+	remaining := slices.DeleteFunc(slices.Clone(triggers), func(t triggerConfig) bool {
+		return t.Name == name
+	})
+	if len(remaining) == len(triggers) {
+		return nil
+	}
+
+	if len(remaining) == 0 {
+		_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s CASCADE",
+			pq.QuoteIdentifier(TableTriggerName(table))))
+		return err
+	}
+
+	// Columns used by the remaining expressions may already have been dropped
+	// or renamed by the completion of other operations, so the function body
+	// isn't checked when it is re-created.
+	return writeTriggers(ctx, conn, table, remaining, false)
+}
+
+// loadTriggers returns the up and down expressions run by the trigger of the
+// table. They are stored as JSON in the comment of the trigger function.
+func loadTriggers(ctx context.Context, conn *sql.DB, table string) ([]triggerConfig, error) {
+	var description sql.NullString
+	err := conn.QueryRowContext(ctx, "SELECT obj_description(to_regprocedure($1)::oid, 'pg_proc')",
+		pq.QuoteIdentifier(TableTriggerName(table))+"()").Scan(&description)
+	if err != nil {
+		return nil, err
+	}
+	if !description.Valid {
+		return nil, nil
+	}
+
+	var triggers []triggerConfig
+	if err := json.Unmarshal([]byte(description.String), &triggers); err != nil {
+		return nil, fmt.Errorf("failed to read the configuration of trigger %q: %w", TableTriggerName(table), err)
+	}
+
+	return triggers, nil
+}
+
+// writeTriggers (re-)creates the trigger of the table to run the given up and
+// down expressions. The expressions are run in the order of their names.
+func writeTriggers(ctx context.Context, conn *sql.DB, table string, triggers []triggerConfig, checkBody bool) error {
+	slices.SortFunc(triggers, func(a, b triggerConfig) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	cfg := tableTriggerConfig{
+		Name:        TableTriggerName(table),
+		SchemaName:  triggers[0].SchemaName,
+		TableName:   table,
+		StateSchema: triggers[0].StateSchema,
+		Triggers:    triggers,
+	}
+
+	funcSQL, err := buildFunction(cfg)
 	if err != nil {
 		return err
 	}
@@ -49,31 +157,58 @@ func createTrigger(ctx context.Context, conn *sql.DB, cfg triggerConfig) error {
 		return err
 	}
 
-	_, err = conn.ExecContext(ctx, triggerSQL)
+	config, err := json.Marshal(triggers)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	stmts := []string{
+		funcSQL,
+		fmt.Sprintf("COMMENT ON FUNCTION %s() IS %s", pq.QuoteIdentifier(cfg.Name), pq.QuoteLiteral(string(config))),
+		triggerSQL,
+	}
+	if !checkBody {
+		stmts = append([]string{"SET LOCAL check_function_bodies = off"}, stmts...)
+	}
+
+	// The statements are sent together so that they run in a single
+	// transaction
+	_, err = conn.ExecContext(ctx, strings.Join(stmts, ";\n"))
+
+	return err
 }
 
-func buildFunction(cfg triggerConfig) (string, error) {
+func buildFunction(cfg tableTriggerConfig) (string, error) {
 	return executeTemplate("function", templates.Function, cfg)
 }
 
-func buildTrigger(cfg triggerConfig) (string, error) {
+func buildTrigger(cfg tableTriggerConfig) (string, error) {
 	return executeTemplate("trigger", templates.Trigger, cfg)
 }
 
+// TableTriggerName returns the name of the trigger, and of its function, that
+// keeps the columns of a table in sync during a migration
+func TableTriggerName(tableName string) string {
+	return Identifier(triggerPrefix, tableName)
+}
+
+// TriggerFunctionName returns the name of the up or down expression that keeps
+// the given column in sync, within the trigger function of the table. Before
+// the columns of a table shared a trigger, each expression had a function of
+// its own under this name.
 func TriggerFunctionName(tableName, columnName string) string {
-	return "_pgroll_trigger_" + tableName + "_" + columnName
+	return Identifier(triggerPrefix, tableName+"_"+columnName)
 }
 
+// TriggerName returns the name of the up or down expression that keeps the
+// given column in sync, within the trigger of the table.
+//
+// Deprecated: use TriggerFunctionName
 func TriggerName(tableName, columnName string) string {
 	return TriggerFunctionName(tableName, columnName)
 }
 
-func executeTemplate(name, content string, cfg triggerConfig) (string, error) {
+func executeTemplate(name, content string, cfg tableTriggerConfig) (string, error) {
 	tmpl := template.Must(template.
 		New(name).
 		Funcs(template.FuncMap{
@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/xataio/pgroll/pkg/migrations"
+	"github.com/xataio/pgroll/pkg/roll"
+	"github.com/xataio/pgroll/pkg/testutils"
+)
+
+// BenchmarkSyncTrigger measures the cost of inserting rows through the old
+// version of the schema while a migration alters some of the columns of the
+// table, so that the trigger of the table syncs them to the new version.
+func BenchmarkSyncTrigger(b *testing.B) {
+	const tableColumns = 10
+
+	for _, alteredColumns := range []int{0, 1, tableColumns} {
+		alteredColumns := alteredColumns
+
+		b.Run(fmt.Sprintf("%d altered columns", alteredColumns), func(b *testing.B) {
+			testutils.WithMigratorAndConnectionToContainer(b, func(mig *roll.Roll, db *sql.DB) {
+				ctx := context.Background()
+
+				columns := []migrations.Column{{Name: "id", Type: "serial", Pk: ptr(true)}}
+				names := make([]string, 0, tableColumns)
+				values := make([]string, 0, tableColumns)
+				for i := 0; i < tableColumns; i++ {
+					name := fmt.Sprintf("col%d", i)
+					columns = append(columns, migrations.Column{Name: name, Type: "text", Nullable: ptr(true)})
+					names = append(names, name)
+					values = append(values, pq.QuoteLiteral(fmt.Sprintf("value %d", i)))
+				}
+
+				err := mig.Start(ctx, &migrations.Migration{
+					Name:       "01_create_table",
+					Operations: migrations.Operations{&migrations.OpCreateTable{Name: "items", Columns: columns}},
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := mig.Complete(ctx); err != nil {
+					b.Fatal(err)
+				}
+
+				if alteredColumns > 0 {
+					ops := make(migrations.Operations, 0, alteredColumns)
+					for _, name := range names[:alteredColumns] {
+						ops = append(ops, &migrations.OpAlterColumn{
+							Table:  "items",
+							Column: name,
+							Type:   ptr("varchar(255)"),
+							Up:     ptr(name),
+							Down:   ptr(name),
+						})
+					}
+
+					err = mig.Start(ctx, &migrations.Migration{Name: "02_alter_columns", Operations: ops})
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+
+				conn, err := db.Conn(ctx)
+				if err != nil {
+					b.Fatal(err)
+				}
+				defer conn.Close()
+
+				_, err = conn.ExecContext(ctx, fmt.Sprintf("SET search_path = %s",
+					pq.QuoteIdentifier(roll.VersionedSchemaName("public", "01_create_table"))))
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				//nolint:gosec // the statement is built from constants
+				insert := fmt.Sprintf("INSERT INTO items (%s) VALUES (%s)", strings.Join(names, ", "), strings.Join(values, ", "))
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := conn.ExecContext(ctx, insert); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
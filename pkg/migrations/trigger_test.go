@@ -12,48 +12,55 @@ import (
 func TestBuildFunction(t *testing.T) {
 	testCases := []struct {
 		name     string
-		config   triggerConfig
+		config   tableTriggerConfig
 		expected string
 	}{
 		{
 			name: "simple up trigger",
-			config: triggerConfig{
-				Name:      "triggerName",
-				Direction: TriggerDirectionUp,
-				Columns: map[string]schema.Column{
-					"id":       {Name: "id", Type: "int"},
-					"username": {Name: "username", Type: "text"},
-					"product":  {Name: "product", Type: "text"},
-					"review":   {Name: "review", Type: "text"},
+			config: tableTriggerConfig{
+				Name:        "triggerName",
+				SchemaName:  "public",
+				TableName:   "reviews",
+				StateSchema: "pgroll",
+				Triggers: []triggerConfig{
+					{
+						Name:      "_pgroll_trigger_reviews_review",
+						Direction: TriggerDirectionUp,
+						Columns: map[string]schema.Column{
+							"id":       {Name: "id", Type: "int"},
+							"username": {Name: "username", Type: "text"},
+							"product":  {Name: "product", Type: "text"},
+							"review":   {Name: "review", Type: "text"},
+						},
+						PhysicalColumn: "_pgroll_new_review",
+						SQL:            "product || 'is good'",
+					},
 				},
-				SchemaName:     "public",
-				TableName:      "reviews",
-				StateSchema:    "pgroll",
-				PhysicalColumn: "_pgroll_new_review",
-				SQL:            "product || 'is good'",
 			},
 			expected: `CREATE OR REPLACE FUNCTION "triggerName"()
     RETURNS TRIGGER
     LANGUAGE PLPGSQL
     AS $$
     DECLARE
-      "id" "public"."reviews"."id"%TYPE := NEW."id";
-      "product" "public"."reviews"."product"%TYPE := NEW."product";
-      "review" "public"."reviews"."review"%TYPE := NEW."review";
-      "username" "public"."reviews"."username"%TYPE := NEW."username";
       latest_schema text;
-      search_path text;
+      version_schema text;
     BEGIN
-      SELECT 'public' || '_' || latest_version
+      SELECT "pgroll".identifier('public' || '_', latest_version)
         INTO latest_schema
         FROM "pgroll".latest_version('public');
 
-      SELECT current_setting
-        INTO search_path
-        FROM current_setting('search_path');
+      SELECT COALESCE(NULLIF(current_setting('pgroll.version_schema', true), ''), (current_schemas(false))[1])
+        INTO version_schema;
 
-      IF search_path != latest_schema THEN
-        NEW."_pgroll_new_review" = product || 'is good';
+      IF version_schema IS DISTINCT FROM latest_schema THEN
+        DECLARE
+          "id" "public"."reviews"."id"%TYPE := NEW."id";
+          "product" "public"."reviews"."product"%TYPE := NEW."product";
+          "review" "public"."reviews"."review"%TYPE := NEW."review";
+          "username" "public"."reviews"."username"%TYPE := NEW."username";
+        BEGIN
+          NEW."_pgroll_new_review" = product || 'is good';
+        END;
       END IF;
 
       RETURN NEW;
@@ -62,45 +69,48 @@ func TestBuildFunction(t *testing.T) {
 		},
 		{
 			name: "complete up trigger",
-			config: triggerConfig{
-				Name:      "triggerName",
-				Direction: TriggerDirectionUp,
-				Columns: map[string]schema.Column{
-					"id":       {Name: "id", Type: "int"},
-					"username": {Name: "username", Type: "text"},
-					"product":  {Name: "product", Type: "text"},
-					"review":   {Name: "review", Type: "text"},
+			config: tableTriggerConfig{
+				Name:        "triggerName",
+				SchemaName:  "public",
+				TableName:   "reviews",
+				StateSchema: "pgroll",
+				Triggers: []triggerConfig{
+					{
+						Name:      "_pgroll_trigger_reviews_review",
+						Direction: TriggerDirectionUp,
+						Columns: map[string]schema.Column{
+							"id":     {Name: "id", Type: "int"},
+							"review": {Name: "review", Type: "text"},
+						},
+						TestExpr:       `NEW."review" IS NULL`,
+						PhysicalColumn: "_pgroll_new_review",
+						ElseExpr:       `NEW."_pgroll_new_review" = NEW."review"`,
+						SQL:            "product || 'is good'",
+					},
 				},
-				SchemaName:     "public",
-				TableName:      "reviews",
-				StateSchema:    "pgroll",
-				TestExpr:       `NEW."review" IS NULL`,
-				PhysicalColumn: "_pgroll_new_review",
-				ElseExpr:       `NEW."_pgroll_new_review" = NEW."review"`,
-				SQL:            "product || 'is good'",
 			},
 			expected: `CREATE OR REPLACE FUNCTION "triggerName"()
     RETURNS TRIGGER
     LANGUAGE PLPGSQL
     AS $$
     DECLARE
-      "id" "public"."reviews"."id"%TYPE := NEW."id";
-      "product" "public"."reviews"."product"%TYPE := NEW."product";
-      "review" "public"."reviews"."review"%TYPE := NEW."review";
-      "username" "public"."reviews"."username"%TYPE := NEW."username";
       latest_schema text;
-      search_path text;
+      version_schema text;
     BEGIN
-      SELECT 'public' || '_' || latest_version
+      SELECT "pgroll".identifier('public' || '_', latest_version)
         INTO latest_schema
         FROM "pgroll".latest_version('public');
 
-      SELECT current_setting
-        INTO search_path
-        FROM current_setting('search_path');
+      SELECT COALESCE(NULLIF(current_setting('pgroll.version_schema', true), ''), (current_schemas(false))[1])
+        INTO version_schema;
 
-      IF search_path != latest_schema AND NEW."review" IS NULL THEN
-        NEW."_pgroll_new_review" = product || 'is good';
+      IF version_schema IS DISTINCT FROM latest_schema AND NEW."review" IS NULL THEN
+        DECLARE
+          "id" "public"."reviews"."id"%TYPE := NEW."id";
+          "review" "public"."reviews"."review"%TYPE := NEW."review";
+        BEGIN
+          NEW."_pgroll_new_review" = product || 'is good';
+        END;
       ELSE
         NEW."_pgroll_new_review" = NEW."review";
       END IF;
@@ -111,43 +121,46 @@ func TestBuildFunction(t *testing.T) {
 		},
 		{
 			name: "simple down trigger",
-			config: triggerConfig{
-				Name:      "triggerName",
-				Direction: TriggerDirectionDown,
-				Columns: map[string]schema.Column{
-					"id":       {Name: "id", Type: "int"},
-					"username": {Name: "username", Type: "text"},
-					"product":  {Name: "product", Type: "text"},
-					"review":   {Name: "review", Type: "text"},
+			config: tableTriggerConfig{
+				Name:        "triggerName",
+				SchemaName:  "public",
+				TableName:   "reviews",
+				StateSchema: "pgroll",
+				Triggers: []triggerConfig{
+					{
+						Name:      "_pgroll_trigger_reviews__pgroll_new_review",
+						Direction: TriggerDirectionDown,
+						Columns: map[string]schema.Column{
+							"id":     {Name: "id", Type: "int"},
+							"review": {Name: "review", Type: "text"},
+						},
+						PhysicalColumn: "review",
+						SQL:            `NEW."_pgroll_new_review"`,
+					},
 				},
-				SchemaName:     "public",
-				TableName:      "reviews",
-				StateSchema:    "pgroll",
-				PhysicalColumn: "review",
-				SQL:            `NEW."_pgroll_new_review"`,
 			},
 			expected: `CREATE OR REPLACE FUNCTION "triggerName"()
     RETURNS TRIGGER
     LANGUAGE PLPGSQL
     AS $$
     DECLARE
-      "id" "public"."reviews"."id"%TYPE := NEW."id";
-      "product" "public"."reviews"."product"%TYPE := NEW."product";
-      "review" "public"."reviews"."review"%TYPE := NEW."review";
-      "username" "public"."reviews"."username"%TYPE := NEW."username";
       latest_schema text;
-      search_path text;
+      version_schema text;
     BEGIN
-      SELECT 'public' || '_' || latest_version
+      SELECT "pgroll".identifier('public' || '_', latest_version)
         INTO latest_schema
         FROM "pgroll".latest_version('public');
 
-      SELECT current_setting
-        INTO search_path
-        FROM current_setting('search_path');
+      SELECT COALESCE(NULLIF(current_setting('pgroll.version_schema', true), ''), (current_schemas(false))[1])
+        INTO version_schema;
 
-      IF search_path = latest_schema THEN
-        NEW."review" = NEW."_pgroll_new_review";
+      IF version_schema = latest_schema THEN
+        DECLARE
+          "id" "public"."reviews"."id"%TYPE := NEW."id";
+          "review" "public"."reviews"."review"%TYPE := NEW."review";
+        BEGIN
+          NEW."review" = NEW."_pgroll_new_review";
+        END;
       END IF;
 
       RETURN NEW;
@@ -155,46 +168,85 @@ func TestBuildFunction(t *testing.T) {
 `,
 		},
 		{
-			name: "down trigger with aliased column",
-			config: triggerConfig{
-				Name:      "triggerName",
-				Direction: TriggerDirectionDown,
-				Columns: map[string]schema.Column{
-					"id":       {Name: "id", Type: "int"},
-					"username": {Name: "username", Type: "text"},
-					"product":  {Name: "product", Type: "text"},
-					"review":   {Name: "review", Type: "text"},
-					"rating":   {Name: "_pgroll_new_rating", Type: "integer"},
+			name: "up and down triggers on several columns",
+			config: tableTriggerConfig{
+				Name:        "triggerName",
+				SchemaName:  "public",
+				TableName:   "reviews",
+				StateSchema: "pgroll",
+				Triggers: []triggerConfig{
+					{
+						Name:      "_pgroll_trigger_reviews__pgroll_new_rating",
+						Direction: TriggerDirectionDown,
+						Columns: map[string]schema.Column{
+							"id":     {Name: "id", Type: "int"},
+							"rating": {Name: "_pgroll_new_rating", Type: "integer"},
+						},
+						PhysicalColumn: "rating",
+						SQL:            `CAST(rating as text)`,
+					},
+					{
+						Name:      "_pgroll_trigger_reviews_rating",
+						Direction: TriggerDirectionUp,
+						Columns: map[string]schema.Column{
+							"id":     {Name: "id", Type: "int"},
+							"rating": {Name: "rating", Type: "text"},
+						},
+						PhysicalColumn: "_pgroll_new_rating",
+						SQL:            `CAST(rating as integer)`,
+					},
+					{
+						Name:      "_pgroll_trigger_reviews_review",
+						Direction: TriggerDirectionUp,
+						Columns: map[string]schema.Column{
+							"id":     {Name: "id", Type: "int"},
+							"rating": {Name: "_pgroll_new_rating", Type: "integer"},
+						},
+						PhysicalColumn: "_pgroll_new_review",
+						SQL:            `'rated ' || rating`,
+					},
 				},
-				SchemaName:     "public",
-				TableName:      "reviews",
-				StateSchema:    "pgroll",
-				PhysicalColumn: "rating",
-				SQL:            `CAST(rating as text)`,
 			},
 			expected: `CREATE OR REPLACE FUNCTION "triggerName"()
     RETURNS TRIGGER
     LANGUAGE PLPGSQL
     AS $$
     DECLARE
-      "id" "public"."reviews"."id"%TYPE := NEW."id";
-      "product" "public"."reviews"."product"%TYPE := NEW."product";
-      "rating" "public"."reviews"."_pgroll_new_rating"%TYPE := NEW."_pgroll_new_rating";
-      "review" "public"."reviews"."review"%TYPE := NEW."review";
-      "username" "public"."reviews"."username"%TYPE := NEW."username";
       latest_schema text;
-      search_path text;
+      version_schema text;
     BEGIN
-      SELECT 'public' || '_' || latest_version
+      SELECT "pgroll".identifier('public' || '_', latest_version)
         INTO latest_schema
         FROM "pgroll".latest_version('public');
 
-      SELECT current_setting
-        INTO search_path
-        FROM current_setting('search_path');
+      SELECT COALESCE(NULLIF(current_setting('pgroll.version_schema', true), ''), (current_schemas(false))[1])
+        INTO version_schema;
+
+      IF version_schema = latest_schema THEN
+        DECLARE
+          "id" "public"."reviews"."id"%TYPE := NEW."id";
+          "rating" "public"."reviews"."_pgroll_new_rating"%TYPE := NEW."_pgroll_new_rating";
+        BEGIN
+          NEW."rating" = CAST(rating as text);
+        END;
+      END IF;
+
+      IF version_schema IS DISTINCT FROM latest_schema THEN
+        DECLARE
+          "id" "public"."reviews"."id"%TYPE := NEW."id";
+          "rating" "public"."reviews"."rating"%TYPE := NEW."rating";
+        BEGIN
+          NEW."_pgroll_new_rating" = CAST(rating as integer);
+        END;
+      END IF;
 
-      IF search_path = latest_schema THEN
-        NEW."rating" = CAST(rating as text);
+      IF version_schema IS DISTINCT FROM latest_schema THEN
+        DECLARE
+          "id" "public"."reviews"."id"%TYPE := NEW."id";
+          "rating" "public"."reviews"."_pgroll_new_rating"%TYPE := NEW."_pgroll_new_rating";
+        BEGIN
+          NEW."_pgroll_new_review" = 'rated ' || rating;
+        END;
       END IF;
 
       RETURN NEW;
@@ -218,12 +270,12 @@ func TestBuildFunction(t *testing.T) {
 func TestBuildTrigger(t *testing.T) {
 	testCases := []struct {
 		name     string
-		config   triggerConfig
+		config   tableTriggerConfig
 		expected string
 	}{
 		{
 			name: "trigger",
-			config: triggerConfig{
+			config: tableTriggerConfig{
 				Name:      "triggerName",
 				TableName: "reviews",
 			},
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+// builtinTypes are the names of the types of pg_catalog that columns are
+// commonly given, including the names the SQL standard gives them. Type
+// modifiers, array bounds and interval fields are not part of the names.
+var builtinTypes = []string{
+	"bigint", "int8",
+	"bit", "bit varying", "varbit",
+	"boolean", "bool",
+	"box", "bytea",
+	"character", "char",
+	"character varying", "varchar",
+	"cidr", "circle", "date",
+	"double precision", "float8", "float",
+	"real", "float4",
+	"inet",
+	"integer", "int", "int4",
+	"smallint", "int2",
+	"interval",
+	"json", "jsonb", "jsonpath",
+	"line", "lseg", "macaddr", "macaddr8", "money",
+	"name",
+	"numeric", "decimal",
+	"oid", "path", "pg_lsn", "pg_snapshot",
+	"point", "polygon",
+	"regclass", "regconfig", "regdictionary", "regnamespace",
+	"regoper", "regoperator", "regproc", "regprocedure",
+	"regrole", "regtype",
+	"text",
+	"time", "time without time zone",
+	"time with time zone", "timetz",
+	"timestamp", "timestamp without time zone",
+	"timestamp with time zone", "timestamptz",
+	"tsquery", "tsvector", "txid_snapshot",
+	"uuid", "xml",
+	"int4range", "int8range", "numrange",
+	"tsrange", "tstzrange", "daterange",
+	"int4multirange", "int8multirange", "nummultirange",
+	"tsmultirange", "tstzmultirange", "datemultirange",
+}
+
+// typeExists returns true if the type is a type of the schema, or a built-in
+// type. Migrations run with a search_path of the schema only, so a type
+// given without a schema must be one of these. Types qualified with another
+// schema can't be checked, and are assumed to exist.
+func typeExists(s *schema.Schema, name string) bool {
+	var words []string
+	var qualifier *string
+	quoted := false
+	depth := 0
+
+	for _, t := range tokenizeSQL(name) {
+		switch {
+		case t.text == "(" || t.text == "[":
+			depth++
+		case t.text == ")" || t.text == "]":
+			depth--
+		case depth > 0 || t.kind == tokenSpace:
+			// type modifiers and array bounds
+		case t.text == ".":
+			if len(words) != 1 || qualifier != nil {
+				return false
+			}
+			qualifier = &words[0]
+			words = nil
+			quoted = false
+		case t.kind == tokenQuotedIdentifier:
+			words = append(words, identifierName(t))
+			quoted = true
+		case t.kind == tokenIdentifier:
+			words = append(words, strings.ToLower(t.text))
+		default:
+			return false
+		}
+	}
+
+	// arrays can also be given by the ARRAY keyword
+	if !quoted && len(words) > 1 && words[len(words)-1] == "array" {
+		words = words[:len(words)-1]
+	}
+	if len(words) == 0 {
+		return false
+	}
+
+	isSchemaType := len(words) == 1 && s.GetType(words[0]) != nil
+	isBuiltin := isBuiltinType(strings.Join(words, " "))
+
+	switch {
+	case qualifier == nil:
+		return isSchemaType || isBuiltin
+	case *qualifier == s.Name:
+		return isSchemaType
+	case *qualifier == "pg_catalog":
+		return isBuiltin
+	}
+	return true
+}
+
+// isBuiltinType returns true if the words of the type name are those of a
+// built-in type. Interval types may be followed by the fields they hold.
+func isBuiltinType(name string) bool {
+	name = strings.Join(strings.Fields(strings.ToLower(name)), " ")
+	return slices.Contains(builtinTypes, name) || strings.HasPrefix(name, "interval ")
+}
@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+func TestTypeExists(t *testing.T) {
+	t.Parallel()
+
+	s := &schema.Schema{
+		Name: "public",
+		Types: map[string]schema.Type{
+			"mood":   {Name: "mood", Kind: schema.EnumType},
+			"Status": {Name: "Status", Kind: schema.EnumType},
+			"citext": {Name: "citext", Kind: schema.BaseType},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		exists bool
+	}{
+		{"integer", true},
+		{"BIGINT", true},
+		{"varchar(255)", true},
+		{"character varying(255)", true},
+		{"numeric(10, 2)", true},
+		{"double precision", true},
+		{"timestamp(3) with time zone", true},
+		{"interval day to second", true},
+		{"text[]", true},
+		{"integer[3][3]", true},
+		{"text ARRAY", true},
+		{"pg_catalog.int4", true},
+		{"mood", true},
+		{"mood[]", true},
+		{"public.mood", true},
+		{`"Status"`, true},
+		{"citext", true},
+		{"extensions.vector(3)", true},
+
+		{"rating_level", false},
+		{"status", false},
+		{"public.rating_level", false},
+		{"pg_catalog.mood", false},
+		{"double", false},
+		{"", false},
+		{"a.b.c", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.exists, typeExists(s, tt.name), tt.name)
+	}
+}
@@ -3,6 +3,18 @@
 
 package migrations
 
+// Settings for the backfills run by the migration
+type BackfillSettings struct {
+	// Time to wait between two backfill batches, in milliseconds
+	BatchDelayMs *int `json:"batch_delay_ms,omitempty"`
+
+	// Number of rows updated by each backfill batch
+	BatchSize *int `json:"batch_size,omitempty"`
+
+	// Number of partitions of a table backfilled concurrently
+	Workers *int `json:"workers,omitempty"`
+}
+
 // Check constraint definition
 type CheckConstraint struct {
 	// Constraint expression
@@ -195,6 +207,9 @@ type OpSetReplicaIdentity struct {
 
 // PgRoll migration definition
 type PgRollMigration struct {
+	// Backfill corresponds to the JSON schema field "backfill".
+	Backfill *BackfillSettings `json:"backfill,omitempty"`
+
 	// Name of the migration
 	Name string `json:"name"`
 
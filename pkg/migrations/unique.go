@@ -7,5 +7,9 @@ func (c *UniqueConstraint) Validate() error {
 		return FieldRequiredError{Name: "name"}
 	}
 
+	if err := ValidateIdentifier(c.Name); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,205 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+// DefaultVerifyMaxMismatches is the number of mismatching rows reported by a
+// verification, unless configured otherwise
+const DefaultVerifyMaxMismatches = 100
+
+// UpColumn is a column that an operation fills with the result of an `up`
+// SQL expression, evaluated over the other columns of the row
+type UpColumn struct {
+	// Table is the name of the table
+	Table string
+
+	// Column is the physical name of the column filled by the expression
+	Column string
+
+	// Up is the `up` SQL expression
+	Up string
+}
+
+// VerifyConfig configures how the values of up columns are verified
+type VerifyConfig struct {
+	// SamplePercent is the percentage of the pages of each table that are
+	// checked, or 0 to check all rows
+	SamplePercent float64
+
+	// MaxMismatches is the maximum number of mismatching rows reported for
+	// each column
+	MaxMismatches int
+}
+
+// VerifyResult is the result of verifying the values of an up column
+type VerifyResult struct {
+	// Table is the name of the table
+	Table string `json:"table"`
+
+	// Column is the physical name of the verified column
+	Column string `json:"column"`
+
+	// Up is the `up` SQL expression the column was checked against
+	Up string `json:"up"`
+
+	// Checked is the number of rows checked
+	Checked int64 `json:"checked"`
+
+	// Mismatches is the number of checked rows whose value differs from the
+	// result of the up expression
+	Mismatches int64 `json:"mismatches"`
+
+	// MismatchedKeys holds the keys of the first mismatching rows, by key
+	// column. Rows of tables without a primary key or unique key are
+	// identified by their `ctid`.
+	MismatchedKeys []map[string]*string `json:"mismatched_keys,omitempty"`
+}
+
+// VerifyUpColumn checks that the value of an up column equals the result of
+// its up expression evaluated over the row, for all rows of the table or for
+// a sample of them.
+// The expression is evaluated the way the up trigger evaluates it: the names
+// it refers to are bound to the columns of the row as the trigger binds them,
+// and its result is cast to the type of the column. Rows written through the
+// new version of the schema set the column directly and fill the column it
+// replaces with the down expression instead, so rows whose replaced column
+// equals the result of the down expression are not reported either. Columns
+// that replace no column, such as added columns, have no down expression, so
+// rows written through the new version can't be told apart and are checked
+// against the up expression.
+func VerifyUpColumn(ctx context.Context, conn *sql.DB, table *schema.Table, col UpColumn, cfg *VerifyConfig) (*VerifyResult, error) {
+	column := table.GetColumn(col.Column)
+	if column == nil {
+		return nil, ColumnDoesNotExistError{Table: table.Name, Name: col.Column}
+	}
+
+	// Identify rows by the same key as backfills, or by their location
+	keyColumns := []string{"ctid"}
+	if key := backfillKey(table); key != nil {
+		keyColumns = keyColumns[:0]
+		for _, c := range key {
+			keyColumns = append(keyColumns, c.Name)
+		}
+	}
+
+	// The rows are selected as `new`, so that expressions referring to the
+	// row through `NEW` work as they do in the trigger
+	from := pq.QuoteIdentifier(table.Name) + " AS new"
+	if cfg != nil && cfg.SamplePercent > 0 && cfg.SamplePercent < 100 {
+		// Use the same sample for both queries
+		from += fmt.Sprintf(" TABLESAMPLE SYSTEM (%g) REPEATABLE (%d)", cfg.SamplePercent, rand.Int31())
+	}
+
+	triggers, err := loadTriggers(ctx, conn, table.Name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the triggers of table %q: %w", table.Name, err)
+	}
+
+	// Without an up trigger, the names in the expression refer to the columns
+	// of the table
+	variables := make(map[string]schema.Column, len(table.Columns))
+	for _, c := range table.Columns {
+		variables[c.Name] = c
+	}
+	var down *triggerConfig
+	for i, t := range triggers {
+		switch {
+		case t.Direction == TriggerDirectionUp && t.PhysicalColumn == col.Column:
+			variables = t.Columns
+		case t.Direction == TriggerDirectionDown && t.Name == TriggerFunctionName(table.Name, col.Column):
+			down = &triggers[i]
+		}
+	}
+
+	mismatch := fmt.Sprintf("new.%s IS DISTINCT FROM %s",
+		pq.QuoteIdentifier(col.Column),
+		evaluateSQL(col.Up, column.Type, variables))
+	if down != nil {
+		if replaced := table.GetColumn(down.PhysicalColumn); replaced != nil {
+			mismatch += fmt.Sprintf(" AND new.%s IS DISTINCT FROM %s",
+				pq.QuoteIdentifier(down.PhysicalColumn),
+				evaluateSQL(down.SQL, replaced.Type, down.Columns))
+		}
+	}
+
+	result := &VerifyResult{Table: table.Name, Column: col.Column, Up: col.Up}
+	err = conn.QueryRowContext(ctx, fmt.Sprintf("SELECT count(*), count(*) FILTER (WHERE %s) FROM %s", mismatch, from)).
+		Scan(&result.Checked, &result.Mismatches)
+	if err != nil {
+		return nil, fmt.Errorf("unable to verify column %q of table %q: %w", col.Column, table.Name, err)
+	}
+	if result.Mismatches == 0 {
+		return result, nil
+	}
+
+	maxMismatches := DefaultVerifyMaxMismatches
+	if cfg != nil && cfg.MaxMismatches > 0 {
+		maxMismatches = cfg.MaxMismatches
+	}
+
+	quotedKey := make([]string, len(keyColumns))
+	selectKey := make([]string, len(keyColumns))
+	for i, c := range keyColumns {
+		quotedKey[i] = "new." + pq.QuoteIdentifier(c)
+		selectKey[i] = quotedKey[i] + "::text"
+	}
+
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s LIMIT %d",
+		strings.Join(selectKey, ", "),
+		from,
+		mismatch,
+		strings.Join(quotedKey, ", "),
+		maxMismatches))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list mismatching rows of table %q: %w", table.Name, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		values := make([]*string, len(keyColumns))
+		dest := make([]any, len(values))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		key := make(map[string]*string, len(keyColumns))
+		for i, c := range keyColumns {
+			key[c] = values[i]
+		}
+		result.MismatchedKeys = append(result.MismatchedKeys, key)
+	}
+
+	return result, rows.Err()
+}
+
+// evaluateSQL returns the SQL that evaluates an up or down expression over a
+// row selected as `new`, with the names in the expression bound to the columns
+// of the row as in the trigger, and casts the result to the given type
+func evaluateSQL(expr, typ string, variables map[string]schema.Column) string {
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	bindings := make([]string, len(names))
+	for i, name := range names {
+		bindings[i] = fmt.Sprintf("new.%s AS %s", pq.QuoteIdentifier(variables[name].Name), pq.QuoteIdentifier(name))
+	}
+
+	return fmt.Sprintf("(SELECT CAST((%s) AS %s) FROM (SELECT %s) AS variables)", expr, typ, strings.Join(bindings, ", "))
+}
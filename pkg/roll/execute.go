@@ -11,39 +11,113 @@ import (
 	"github.com/lib/pq"
 	"github.com/xataio/pgroll/pkg/migrations"
 	"github.com/xataio/pgroll/pkg/schema"
+	"github.com/xataio/pgroll/pkg/state"
 )
 
 // Start will apply the required changes to enable supporting the new schema version
+//
+// If the context is cancelled while the migration is being started, the
+// migration is rolled back, or left in progress if the roll was created with
+// `WithKeepOnInterrupt`. A migration whose backfill fails is always left in
+// progress. Calling Start again with the same migration then resumes it from
+// the first operation that wasn't started, and its backfills resume from their
+// last checkpoint.
 func (m *Roll) Start(ctx context.Context, migration *migrations.Migration, cbs ...migrations.CallbackFn) error {
+	// refuse to start a migration that was modified since it was first started
+	if err := m.state.VerifyChecksum(ctx, m.schema, migration); err != nil {
+		return err
+	}
+
 	// check if there is an active migration, create one otherwise
 	active, err := m.state.IsActiveMigrationPeriod(ctx, m.schema)
 	if err != nil {
 		return err
 	}
+
+	var newSchema *schema.Schema
+	var started int
 	if active {
-		return fmt.Errorf("a migration for schema %q is already in progress", m.schema)
+		// the active migration can only be resumed if its start was interrupted
+		newSchema, started, err = m.state.ResumeStart(ctx, m.schema, migration.Name)
+		if errors.Is(err, state.ErrNoInterruptedMigration) {
+			return fmt.Errorf("a migration for schema %q is already in progress", m.schema)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to resume migration: %w", err)
+		}
+	} else {
+		// create a new active migration (guaranteed to be unique by constraints)
+		newSchema, err = m.state.Start(ctx, m.schema, migration)
+		if err != nil {
+			return fmt.Errorf("unable to start migration: %w", err)
+		}
 	}
 
-	// create a new active migration (guaranteed to be unique by constraints)
-	newSchema, err := m.state.Start(ctx, m.schema, migration)
-	if err != nil {
-		return fmt.Errorf("unable to start migration: %w", err)
+	// validate migration. A resumed migration only validates the operations
+	// that weren't started, against the schema as left by the others.
+	pending := &migrations.Migration{Name: migration.Name, Operations: migration.Operations[started:]}
+	if started == 0 {
+		pending = migration
 	}
-
-	// validate migration
-	err = migration.Validate(ctx, newSchema)
+	err = pending.Validate(ctx, newSchema)
 	if err != nil {
-		if err := m.state.Rollback(ctx, m.schema, migration.Name); err != nil {
+		if active {
+			// keep the interrupted migration so that it can still be rolled back
+			if err := m.state.InterruptStart(ctx, m.schema, migration.Name, newSchema, started); err != nil {
+				fmt.Printf("failed to keep interrupted migration: %s\n", err)
+			}
+		} else if err := m.state.Fail(ctx, m.schema, migration.Name, state.ValidationMigrationPhase, err); err != nil {
 			fmt.Printf("failed to rollback migration: %s\n", err)
 		}
 		return fmt.Errorf("migration is invalid: %w", err)
 	}
 
+	// keep a copy of the schema as left by the operations started so far, in
+	// case the start is interrupted and has to be resumed later
+	resumeSchema, err := newSchema.Clone()
+	if err != nil {
+		return fmt.Errorf("unable to copy schema: %w", err)
+	}
+
+	// settings given in the migration take precedence over the configured ones
+	backfillConfig := m.backfillConfig.WithSettings(migration.Backfill)
+
 	// execute operations
-	for _, op := range migration.Operations {
-		err := op.Start(ctx, m.pgConn, m.state.Schema(), newSchema, cbs...)
+	for i, op := range migration.Operations {
+		// operations started before the migration was interrupted are done
+		if i < started {
+			continue
+		}
+
+		// save the progress of the operation's backfills, so that a resumed
+		// start doesn't backfill the rows already done again
+		opBackfillConfig := *backfillConfig
+		opBackfillConfig.Checkpoints = m.state.BackfillCheckpoints(m.schema, migration.Name, i)
+
+		err := ctx.Err()
+		if err == nil {
+			err = op.Start(ctx, m.pgConn, m.state.Schema(), newSchema, &opBackfillConfig, withOperation(op, cbs)...)
+		}
 		if err != nil {
-			errRollback := m.Rollback(ctx)
+			// the start was interrupted, so the context is already cancelled and
+			// can't be used to clean up
+			if ctx.Err() != nil {
+				return m.interruptStart(context.WithoutCancel(ctx), migration, resumeSchema, i, err)
+			}
+
+			// a failed backfill keeps the migration in progress, along with the
+			// checkpoints of its backfills, so that starting it again resumes the
+			// backfill rather than starting over
+			if errors.As(err, &migrations.BackfillError{}) {
+				if errKeep := m.state.InterruptStart(ctx, m.schema, migration.Name, resumeSchema, i); errKeep != nil {
+					return errors.Join(
+						fmt.Errorf("unable to execute start operation: %w", err),
+						fmt.Errorf("unable to record interrupted migration: %w", errKeep))
+				}
+				return fmt.Errorf("unable to execute start operation, migration %q kept in progress: %w", migration.Name, err)
+			}
+
+			errRollback := m.rollback(ctx, err)
 
 			return errors.Join(
 				fmt.Errorf("unable to execute start operation: %w", err),
@@ -51,12 +125,42 @@ func (m *Roll) Start(ctx context.Context, migration *migrations.Migration, cbs .
 		}
 
 		if _, ok := op.(migrations.RequiresSchemaRefreshOperation); ok {
-			// refresh schema
-			newSchema, err = m.state.ReadSchema(ctx, m.schema)
+			// refresh the schema, only reading again the tables changed by the
+			// operation if it tells which they are
+			var tables []string
+			if op, ok := op.(migrations.TablesOperation); ok {
+				tables = op.Tables()
+			}
+			newSchema, err = m.state.NewSchemaCacheFrom(newSchema).Refresh(ctx, tables)
 			if err != nil {
 				return fmt.Errorf("unable to refresh schema: %w", err)
 			}
 		}
+
+		resumeSchema, err = newSchema.Clone()
+		if err != nil {
+			return fmt.Errorf("unable to copy schema: %w", err)
+		}
+	}
+
+	// record the original names of the duplicated objects whose names had to
+	// be shortened, as they can't be recovered from the shortened names once
+	// the originals are dropped on completion. Only the tables changed by the
+	// migration can hold duplicates.
+	physicalSchema, err := m.state.ReadTables(ctx, m.schema, migration.ChangedTables())
+	if err != nil {
+		return fmt.Errorf("unable to read schema: %w", err)
+	}
+	err = m.state.SaveGeneratedNames(ctx, m.schema, migration.Name, migrations.ShortenedDuplicationNames(physicalSchema))
+	if err != nil {
+		return fmt.Errorf("unable to save generated names: %w", err)
+	}
+
+	// record the schema of the new version, whose columns have the logical
+	// positions to carry to the resulting schema on completion
+	err = m.state.SaveVersionSchema(ctx, m.schema, migration.Name, newSchema)
+	if err != nil {
+		return fmt.Errorf("unable to save version schema: %w", err)
 	}
 
 	if m.disableVersionSchemas {
@@ -82,6 +186,133 @@ func (m *Roll) Start(ctx context.Context, migration *migrations.Migration, cbs .
 	return nil
 }
 
+// Backfill runs the backfills of the active migration that haven't finished,
+// typically because they were deferred with `WithDeferredBackfill` when the
+// migration was started. Backfills resume from their last checkpoint if a
+// previous attempt was interrupted.
+func (m *Roll) Backfill(ctx context.Context, cbs ...migrations.CallbackFn) error {
+	migration, err := m.state.GetActiveMigration(ctx, m.schema)
+	if err != nil {
+		return fmt.Errorf("unable to get active migration: %w", err)
+	}
+
+	pending, err := m.state.PendingBackfills(ctx, m.schema, migration.Name)
+	if err != nil {
+		return fmt.Errorf("unable to get pending backfills: %w", err)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	// backfills run against the tables as they are in the database
+	tables := make([]string, 0, len(pending))
+	for _, b := range pending {
+		tables = append(tables, b.Table)
+	}
+	schema, err := m.state.ReadTables(ctx, m.schema, tables)
+	if err != nil {
+		return fmt.Errorf("unable to read schema: %w", err)
+	}
+
+	// settings given in the migration take precedence over the configured ones
+	backfillConfig := m.backfillConfig.WithSettings(migration.Backfill)
+
+	for _, b := range pending {
+		if b.Operation >= len(migration.Operations) {
+			return fmt.Errorf("backfill of table %q belongs to unknown operation %d", b.Table, b.Operation)
+		}
+		table := schema.GetTable(b.Table)
+		if table == nil {
+			return fmt.Errorf("table %q to backfill not found", b.Table)
+		}
+
+		opBackfillConfig := *backfillConfig
+		opBackfillConfig.Checkpoints = m.state.BackfillCheckpoints(m.schema, migration.Name, b.Operation)
+
+		op := migration.Operations[b.Operation]
+		err := migrations.Backfill(ctx, m.pgConn, table, &opBackfillConfig, withOperation(op, cbs)...)
+		if err != nil {
+			return fmt.Errorf("unable to backfill table %q: %w", b.Table, err)
+		}
+	}
+
+	return nil
+}
+
+// Verify checks that the columns filled by the `up` expressions of the active
+// migration's operations hold the result of those expressions for every row,
+// or for a sample of rows.
+func (m *Roll) Verify(ctx context.Context, cfg *migrations.VerifyConfig) ([]migrations.VerifyResult, error) {
+	migration, err := m.state.GetActiveMigration(ctx, m.schema)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get active migration: %w", err)
+	}
+
+	// up columns are compared against the tables as they are in the database
+	schema, err := m.state.ReadTables(ctx, m.schema, migration.ChangedTables())
+	if err != nil {
+		return nil, fmt.Errorf("unable to read schema: %w", err)
+	}
+
+	results := []migrations.VerifyResult{}
+	for _, op := range migration.Operations {
+		op, ok := op.(migrations.UpColumnsOperation)
+		if !ok {
+			continue
+		}
+
+		for _, col := range op.UpColumns() {
+			table := schema.GetTable(col.Table)
+			if table == nil {
+				return nil, migrations.TableDoesNotExistError{Name: col.Table}
+			}
+
+			result, err := migrations.VerifyUpColumn(ctx, m.pgConn, table, col, cfg)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, *result)
+		}
+	}
+
+	return results, nil
+}
+
+// withOperation wraps the callbacks so that the progress they receive names
+// the operation running the backfill
+func withOperation(op migrations.Operation, cbs []migrations.CallbackFn) []migrations.CallbackFn {
+	name := migrations.OperationName(op)
+
+	wrapped := make([]migrations.CallbackFn, len(cbs))
+	for i, cb := range cbs {
+		wrapped[i] = func(p migrations.BackfillProgress) {
+			p.Operation = name
+			cb(p)
+		}
+	}
+	return wrapped
+}
+
+// interruptStart handles a migration whose start was interrupted, either by
+// rolling it back or by keeping it in progress so that it can be resumed.
+func (m *Roll) interruptStart(ctx context.Context, migration *migrations.Migration, resumeSchema *schema.Schema, started int, cause error) error {
+	if m.keepOnInterrupt {
+		if err := m.state.InterruptStart(ctx, m.schema, migration.Name, resumeSchema, started); err != nil {
+			return errors.Join(
+				fmt.Errorf("migration start interrupted: %w", cause),
+				fmt.Errorf("unable to record interrupted migration: %w", err))
+		}
+		return fmt.Errorf("migration start interrupted, migration %q kept in progress: %w", migration.Name, cause)
+	}
+
+	if err := m.rollback(ctx, cause); err != nil {
+		return errors.Join(
+			fmt.Errorf("migration start interrupted: %w", cause),
+			err)
+	}
+	return fmt.Errorf("migration start interrupted, migration %q rolled back: %w", migration.Name, cause)
+}
+
 // Complete will update the database schema to match the current version
 func (m *Roll) Complete(ctx context.Context) error {
 	// get current ongoing migration
@@ -90,6 +321,33 @@ func (m *Roll) Complete(ctx context.Context) error {
 		return fmt.Errorf("unable to get active migration: %w", err)
 	}
 
+	// refuse to complete the migration until all of its backfills are done
+	pending, err := m.state.PendingBackfills(ctx, m.schema, migration.Name)
+	if err != nil {
+		return fmt.Errorf("unable to get pending backfills: %w", err)
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("unable to complete migration: %w", state.ErrPendingBackfills)
+	}
+
+	// refuse to complete the migration if its up columns don't match
+	if m.completeVerification != nil {
+		results, err := m.Verify(ctx, m.completeVerification)
+		if err != nil {
+			return fmt.Errorf("unable to verify migration: %w", err)
+		}
+
+		var mismatches []error
+		for _, r := range results {
+			if r.Mismatches > 0 {
+				mismatches = append(mismatches, migrations.UpColumnMismatchError{Table: r.Table, Column: r.Column, Mismatches: r.Mismatches})
+			}
+		}
+		if len(mismatches) > 0 {
+			return fmt.Errorf("unable to complete migration: %w", errors.Join(mismatches...))
+		}
+	}
+
 	// Drop the old schema
 	if !m.disableVersionSchemas {
 		prevVersion, err := m.state.PreviousVersion(ctx, m.schema)
@@ -105,22 +363,84 @@ func (m *Roll) Complete(ctx context.Context) error {
 		}
 	}
 
-	// read the current schema
-	schema, err := m.state.ReadSchema(ctx, m.schema)
+	// read the current schema, keeping it so that only the tables changed by
+	// the migration have to be read again once it is complete
+	cache := m.state.NewSchemaCache(m.schema)
+	schema, err := cache.Read(ctx)
 	if err != nil {
 		return fmt.Errorf("unable to read schema: %w", err)
 	}
 
-	// execute operations
-	for _, op := range migration.Operations {
+	// the original names of duplicated objects are needed to rename the
+	// duplicates
+	names, err := m.state.GeneratedNames(ctx, m.schema, migration.Name)
+	if err != nil {
+		return fmt.Errorf("unable to read generated names: %w", err)
+	}
+	for name, table := range schema.Tables {
+		table.GeneratedNames = names[table.Name]
+		schema.Tables[name] = table
+	}
+
+	// find out how many operations a previous, interrupted attempt completed
+	completed, err := m.state.CompletedOperations(ctx, m.schema, migration.Name)
+	if err != nil {
+		return fmt.Errorf("unable to get completed operations: %w", err)
+	}
+
+	// execute operations, skipping the ones that are already complete
+	for i, op := range migration.Operations {
+		if i < completed {
+			continue
+		}
+
+		// drop the views that would prevent the operation from altering columns
+		if op, ok := op.(migrations.RecreatesViewsOperation); ok && !m.disableVersionSchemas {
+			for _, name := range op.RecreatedViews(schema) {
+				err := m.dropView(ctx, migration.Name, name)
+				if err != nil {
+					return fmt.Errorf("unable to drop view: %w", err)
+				}
+			}
+		}
+
 		err := op.Complete(ctx, m.pgConn, schema)
 		if err != nil {
 			return fmt.Errorf("unable to execute complete operation: %w", err)
 		}
+
+		// record the progress so that a retry resumes from the next operation
+		err = m.state.CompleteOperation(ctx, m.schema, migration.Name, i)
+		if err != nil {
+			return fmt.Errorf("unable to record completed operation: %w", err)
+		}
+	}
+
+	resultingSchema, err := cache.Refresh(ctx, migration.ChangedTables())
+	if err != nil {
+		return fmt.Errorf("unable to read schema: %w", err)
+	}
+
+	// columns keep the positions they have in the new version, rather than
+	// the positions of their duplicates in the catalog
+	versionSchema, err := m.state.VersionSchema(ctx, m.schema, migration.Name)
+	if err != nil {
+		return fmt.Errorf("unable to read version schema: %w", err)
+	}
+	if versionSchema != nil {
+		resultingSchema.CarryColumnPositions(versionSchema)
+	}
+
+	// create the views dropped by the operations again, on top of the
+	// completed tables
+	if !m.disableVersionSchemas {
+		if err := m.createMissingViews(ctx, migration.Name, resultingSchema); err != nil {
+			return fmt.Errorf("unable to create view: %w", err)
+		}
 	}
 
 	// mark as completed
-	err = m.state.Complete(ctx, m.schema, migration.Name)
+	err = m.state.Complete(ctx, m.schema, migration.Name, resultingSchema)
 	if err != nil {
 		return fmt.Errorf("unable to complete migration: %w", err)
 	}
@@ -129,12 +449,29 @@ func (m *Roll) Complete(ctx context.Context) error {
 }
 
 func (m *Roll) Rollback(ctx context.Context) error {
+	return m.rollback(ctx, nil)
+}
+
+// rollback reverts the active migration. If the migration is rolled back
+// because starting it failed with the given cause, the failure is recorded in
+// the migration history.
+func (m *Roll) rollback(ctx context.Context, cause error) error {
 	// get current ongoing migration
 	migration, err := m.state.GetActiveMigration(ctx, m.schema)
 	if err != nil {
 		return fmt.Errorf("unable to get active migration: %w", err)
 	}
 
+	// a partially completed migration has already removed parts of the old
+	// schema, so it can only be completed
+	completed, err := m.state.CompletedOperations(ctx, m.schema, migration.Name)
+	if err != nil {
+		return fmt.Errorf("unable to get completed operations: %w", err)
+	}
+	if completed > 0 {
+		return fmt.Errorf("migration %q is partially completed and cannot be rolled back, run complete again to finish it", migration.Name)
+	}
+
 	if !m.disableVersionSchemas {
 		// delete the schema and view for the new version
 		versionSchema := VersionedSchemaName(m.schema, migration.Name)
@@ -153,7 +490,11 @@ func (m *Roll) Rollback(ctx context.Context) error {
 	}
 
 	// roll back the migration
-	err = m.state.Rollback(ctx, m.schema, migration.Name)
+	if cause != nil {
+		err = m.state.Fail(ctx, m.schema, migration.Name, state.StartMigrationPhase, cause)
+	} else {
+		err = m.state.Rollback(ctx, m.schema, migration.Name)
+	}
 	if err != nil {
 		return fmt.Errorf("unable to rollback migration: %w", err)
 	}
@@ -163,9 +504,11 @@ func (m *Roll) Rollback(ctx context.Context) error {
 
 // create view creates a view for the new version of the schema
 func (m *Roll) createView(ctx context.Context, version, name string, table schema.Table) error {
+	// columns are listed in the order of their positions, so that they are in
+	// the same order in every version of the schema
 	columns := make([]string, 0, len(table.Columns))
-	for k, v := range table.Columns {
-		columns = append(columns, fmt.Sprintf("%s AS %s", pq.QuoteIdentifier(v.Name), pq.QuoteIdentifier(k)))
+	for _, k := range table.ColumnNames() {
+		columns = append(columns, fmt.Sprintf("%s AS %s", pq.QuoteIdentifier(table.Columns[k].Name), pq.QuoteIdentifier(k)))
 	}
 
 	// Create view with security_invoker option for PG 15+
@@ -191,6 +534,61 @@ func (m *Roll) createView(ctx context.Context, version, name string, table schem
 	return nil
 }
 
+// dropView drops the view of a table in the given version of the schema
+func (m *Roll) dropView(ctx context.Context, version, name string) error {
+	_, err := m.pgConn.ExecContext(ctx, fmt.Sprintf("DROP VIEW IF EXISTS %s.%s",
+		pq.QuoteIdentifier(VersionedSchemaName(m.schema, version)),
+		pq.QuoteIdentifier(name)))
+	return err
+}
+
+// createMissingViews creates the views of the tables that have no view in the
+// given version of the schema, such as the views dropped while completing a
+// migration. The tables must have their final names.
+func (m *Roll) createMissingViews(ctx context.Context, version string, schema *schema.Schema) error {
+	versionSchema := VersionedSchemaName(m.schema, version)
+
+	// the version may have been started without a version schema
+	var exists bool
+	err := m.pgConn.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM pg_catalog.pg_namespace WHERE nspname = $1)",
+		versionSchema).Scan(&exists)
+	if err != nil || !exists {
+		return err
+	}
+
+	rows, err := m.pgConn.QueryContext(ctx, "SELECT viewname FROM pg_catalog.pg_views WHERE schemaname = $1",
+		versionSchema)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	views := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		views[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for name, table := range schema.Tables {
+		if views[name] {
+			continue
+		}
+		if err := m.createView(ctx, version, name, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VersionedSchemaName returns the name of the schema that holds the views of
+// the given version of a schema. Long names are shortened to fit in an
+// identifier.
 func VersionedSchemaName(schema string, version string) string {
-	return schema + "_" + version
+	return migrations.Identifier(schema+"_", version)
 }
@@ -97,6 +97,23 @@ func schemaExists(t *testing.T, db *sql.DB, schema string) bool {
 	return exists
 }
 
+func columnExists(t *testing.T, db *sql.DB, table, column string) bool {
+	t.Helper()
+	var exists bool
+	err := db.QueryRow(`
+	SELECT EXISTS(
+		SELECT 1
+		FROM information_schema.columns
+		WHERE table_schema = 'public'
+		AND table_name = $1
+		AND column_name = $2
+	)`, table, column).Scan(&exists)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return exists
+}
+
 func TestPreviousVersionIsDroppedAfterMigrationCompletion(t *testing.T) {
 	t.Parallel()
 
@@ -302,6 +319,143 @@ func TestLockTimeoutIsEnforced(t *testing.T) {
 	})
 }
 
+func TestCompleteCanBeRetriedAfterFailure(t *testing.T) {
+	t.Parallel()
+
+	opts := []roll.Option{roll.WithLockTimeoutMs(100), roll.WithDisableViewsManagement()}
+	testutils.WithMigratorInSchemaAndConnectionToContainerWithOptions(t, "public", opts, func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		// Create two tables
+		err := mig.Start(ctx, &migrations.Migration{
+			Name:       "01_create_tables",
+			Operations: migrations.Operations{createTableOp("table1"), createTableOp("table2")},
+		})
+		assert.NoError(t, err)
+		err = mig.Complete(ctx)
+		assert.NoError(t, err)
+
+		// Start a migration that drops a column from each table
+		err = mig.Start(ctx, &migrations.Migration{
+			Name: "02_drop_columns",
+			Operations: migrations.Operations{
+				&migrations.OpDropColumn{Table: "table1", Column: "name"},
+				&migrations.OpDropColumn{Table: "table2", Column: "name"},
+			},
+		})
+		assert.NoError(t, err)
+
+		// Take an ACCESS_EXCLUSIVE lock on the second table so that completing
+		// the second operation fails due to a lock timeout
+		tx, err := db.Begin()
+		assert.NoError(t, err)
+		_, err = tx.ExecContext(ctx, "LOCK TABLE table2 IN ACCESS EXCLUSIVE MODE")
+		assert.NoError(t, err)
+
+		err = mig.Complete(ctx)
+		assert.Error(t, err)
+
+		// The first operation was completed, the second one was not
+		assert.False(t, columnExists(t, db, "table1", "name"))
+		assert.True(t, columnExists(t, db, "table2", "name"))
+
+		// The partially completed migration can't be rolled back
+		err = mig.Rollback(ctx)
+		assert.Error(t, err)
+
+		// Release the lock and retry the completion
+		err = tx.Commit()
+		assert.NoError(t, err)
+
+		err = mig.Complete(ctx)
+		assert.NoError(t, err)
+
+		assert.False(t, columnExists(t, db, "table2", "name"))
+
+		status, err := mig.Status(ctx, "public")
+		assert.NoError(t, err)
+		assert.Equal(t, state.CompleteMigrationStatus, status.Status)
+	})
+}
+
+func TestInterruptedStart(t *testing.T) {
+	t.Parallel()
+
+	addColumnWithUp := &migrations.OpAddColumn{
+		Table: "table1",
+		Up:    ptr("'unknown'"),
+		Column: migrations.Column{
+			Name:     "description",
+			Type:     "text",
+			Nullable: ptr(false),
+		},
+	}
+
+	t.Run("an interrupted migration is rolled back", func(t *testing.T) {
+		testutils.WithMigratorAndConnectionToContainer(t, func(mig *roll.Roll, db *sql.DB) {
+			ctx := context.Background()
+
+			err := mig.Start(ctx, &migrations.Migration{Name: "01_create_table", Operations: migrations.Operations{createTableOp("table1")}})
+			assert.NoError(t, err)
+			err = mig.Complete(ctx)
+			assert.NoError(t, err)
+
+			// Cancel the context as soon as the backfill begins
+			cancelCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			err = mig.Start(cancelCtx, &migrations.Migration{Name: "02_add_column", Operations: migrations.Operations{addColumnWithUp}},
+				func(migrations.BackfillProgress) { cancel() })
+			assert.ErrorIs(t, err, context.Canceled)
+
+			// The migration has been rolled back
+			status, err := mig.Status(ctx, "public")
+			assert.NoError(t, err)
+			assert.Equal(t, "01_create_table", status.Version)
+			assert.Equal(t, state.CompleteMigrationStatus, status.Status)
+			assert.False(t, columnExists(t, db, "table1", migrations.TemporaryName("description")))
+		})
+	})
+
+	t.Run("an interrupted migration can be kept and resumed", func(t *testing.T) {
+		opts := []roll.Option{roll.WithLockTimeoutMs(500), roll.WithKeepOnInterrupt()}
+		testutils.WithMigratorInSchemaAndConnectionToContainerWithOptions(t, "public", opts, func(mig *roll.Roll, db *sql.DB) {
+			ctx := context.Background()
+
+			err := mig.Start(ctx, &migrations.Migration{Name: "01_create_table", Operations: migrations.Operations{createTableOp("table1")}})
+			assert.NoError(t, err)
+			err = mig.Complete(ctx)
+			assert.NoError(t, err)
+
+			// Cancel the context as soon as the backfill begins
+			migration := &migrations.Migration{Name: "02_add_column", Operations: migrations.Operations{addColumnWithUp}}
+			cancelCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			err = mig.Start(cancelCtx, migration, func(migrations.BackfillProgress) { cancel() })
+			assert.ErrorIs(t, err, context.Canceled)
+
+			// The migration is kept in progress
+			status, err := mig.Status(ctx, "public")
+			assert.NoError(t, err)
+			assert.Equal(t, "02_add_column", status.Version)
+			assert.Equal(t, state.InProgressMigrationStatus, status.Status)
+			assert.True(t, columnExists(t, db, "table1", migrations.TemporaryName("description")))
+
+			// A different migration can't be started
+			err = mig.Start(ctx, &migrations.Migration{Name: "03_create_table", Operations: migrations.Operations{createTableOp("table2")}})
+			assert.Error(t, err)
+
+			// Resume and complete the interrupted migration
+			err = mig.Start(ctx, migration)
+			assert.NoError(t, err)
+			assert.True(t, schemaExists(t, db, roll.VersionedSchemaName(schema, "02_add_column")))
+
+			err = mig.Complete(ctx)
+			assert.NoError(t, err)
+			assert.True(t, columnExists(t, db, "table1", "description"))
+		})
+	})
+}
+
 func TestViewsAreCreatedWithSecurityInvokerTrue(t *testing.T) {
 	t.Parallel()
 
@@ -384,6 +538,113 @@ func TestViewsAreCreatedWithSecurityInvokerTrue(t *testing.T) {
 	})
 }
 
+func TestViewColumnsAreOrderedByPosition(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithMigratorAndConnectionToContainer(t, func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		viewColumns := func(version string) []string {
+			rows, err := db.QueryContext(ctx, `SELECT column_name FROM information_schema.columns
+				WHERE table_schema = $1 AND table_name = 'users' ORDER BY ordinal_position`,
+				roll.VersionedSchemaName("public", version))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rows.Close()
+
+			var columns []string
+			for rows.Next() {
+				var column string
+				if err := rows.Scan(&column); err != nil {
+					t.Fatal(err)
+				}
+				columns = append(columns, column)
+			}
+			if err := rows.Err(); err != nil {
+				t.Fatal(err)
+			}
+			return columns
+		}
+
+		err := mig.Start(ctx, &migrations.Migration{
+			Name: "01_create_table",
+			Operations: migrations.Operations{
+				&migrations.OpCreateTable{
+					Name: "users",
+					Columns: []migrations.Column{
+						{Name: "id", Type: "serial", Pk: ptr(true)},
+						{Name: "name", Type: "text", Nullable: ptr(true)},
+						{Name: "email", Type: "text", Nullable: ptr(true)},
+						{Name: "city", Type: "text", Nullable: ptr(true)},
+						{Name: "age", Type: "integer", Nullable: ptr(true)},
+					},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to start migration: %v", err)
+		}
+
+		// the columns of the view are in the order they were defined in
+		assert.Equal(t, []string{"id", "name", "email", "city", "age"}, viewColumns("01_create_table"))
+
+		if err := mig.Complete(ctx); err != nil {
+			t.Fatalf("Failed to complete migration: %v", err)
+		}
+
+		err = mig.Start(ctx, &migrations.Migration{
+			Name: "02_alter_table",
+			Operations: migrations.Operations{
+				&migrations.OpAddColumn{
+					Table:  "users",
+					Column: migrations.Column{Name: "zip", Type: "text", Nullable: ptr(true)},
+				},
+				&migrations.OpAlterColumn{
+					Table:  "users",
+					Column: "email",
+					Check:  &migrations.CheckConstraint{Name: "email_check", Constraint: "email LIKE '%@%'"},
+					Up:     ptr("email"),
+					Down:   ptr("email"),
+				},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to start migration: %v", err)
+		}
+
+		// altered columns keep their position and added columns come last
+		assert.Equal(t, []string{"id", "name", "email", "city", "age", "zip"}, viewColumns("02_alter_table"))
+		assert.Equal(t, []string{"id", "name", "email", "city", "age"}, viewColumns("01_create_table"))
+
+		if err := mig.Complete(ctx); err != nil {
+			t.Fatalf("Failed to complete migration: %v", err)
+		}
+
+		// the duplicate of the altered column replaced it at the end of the table
+		// in the catalog, and a column is added outside of pgroll
+		if _, err := db.ExecContext(ctx, "ALTER TABLE users ADD COLUMN country text"); err != nil {
+			t.Fatal(err)
+		}
+
+		err = mig.Start(ctx, &migrations.Migration{
+			Name: "03_add_column",
+			Operations: migrations.Operations{
+				&migrations.OpAddColumn{
+					Table:  "users",
+					Column: migrations.Column{Name: "phone", Type: "text", Nullable: ptr(true)},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to start migration: %v", err)
+		}
+
+		// columns keep their positions across migrations
+		assert.Equal(t, []string{"id", "name", "email", "city", "age", "zip", "country", "phone"}, viewColumns("03_add_column"))
+	})
+}
+
 func TestStatusMethodReturnsCorrectStatus(t *testing.T) {
 	t.Parallel()
 
@@ -456,6 +717,631 @@ func TestStatusMethodReturnsCorrectStatus(t *testing.T) {
 	})
 }
 
+func TestHistoryIncludesFailedAndRolledBackMigrations(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithMigratorAndConnectionToContainer(t, func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		// Start and complete a create table migration
+		err := mig.Start(ctx, &migrations.Migration{
+			Name:       "01_create_table",
+			Operations: migrations.Operations{createTableOp("table1")},
+		})
+		assert.NoError(t, err)
+		err = mig.Complete(ctx)
+		assert.NoError(t, err)
+
+		// Start a migration that fails to start
+		err = mig.Start(ctx, &migrations.Migration{
+			Name:       "02_failing_sql",
+			Operations: migrations.Operations{&migrations.OpRawSQL{Up: "SELECT * FROM doesntexist"}},
+		})
+		assert.Error(t, err)
+
+		// Start a migration and roll it back
+		err = mig.Start(ctx, &migrations.Migration{
+			Name:       "03_add_column",
+			Operations: migrations.Operations{addColumnOp("table1")},
+		})
+		assert.NoError(t, err)
+		err = mig.Rollback(ctx)
+		assert.NoError(t, err)
+
+		// The default history only contains the applied migration
+		history, err := mig.History(ctx, "public", false)
+		assert.NoError(t, err)
+		assert.Len(t, history, 1)
+		assert.Equal(t, "01_create_table", history[0].Name)
+		assert.Equal(t, state.CompleteHistoryStatus, history[0].Status)
+
+		// The full history contains every migration attempt, in order
+		history, err = mig.History(ctx, "public", true)
+		assert.NoError(t, err)
+		assert.Len(t, history, 3)
+
+		assert.Equal(t, "02_failing_sql", history[1].Name)
+		assert.Equal(t, state.FailedHistoryStatus, history[1].Status)
+		assert.Equal(t, state.StartMigrationPhase, history[1].Phase)
+		assert.Contains(t, history[1].Error, "doesntexist")
+
+		assert.Equal(t, "03_add_column", history[2].Name)
+		assert.Equal(t, state.RolledBackHistoryStatus, history[2].Status)
+		assert.Empty(t, history[2].Error)
+
+		// The status is unaffected by the failed and rolled back migrations
+		status, err := mig.Status(ctx, "public")
+		assert.NoError(t, err)
+		assert.Equal(t, "01_create_table", status.Version)
+	})
+}
+
+func TestModifiedMigrationIsRejected(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithMigratorAndConnectionToContainer(t, func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		// Start and complete a create table migration
+		err := mig.Start(ctx, &migrations.Migration{
+			Name:       "01_create_table",
+			Operations: migrations.Operations{createTableOp("table1")},
+		})
+		assert.NoError(t, err)
+		err = mig.Complete(ctx)
+		assert.NoError(t, err)
+
+		// Start the same migration again, after modifying its operations
+		err = mig.Start(ctx, &migrations.Migration{
+			Name:       "01_create_table",
+			Operations: migrations.Operations{createTableOp("table2")},
+		})
+
+		// The modified migration is rejected
+		var mismatchErr state.ChecksumMismatchError
+		assert.ErrorAs(t, err, &mismatchErr)
+		assert.Equal(t, "01_create_table", mismatchErr.Name)
+	})
+}
+
+func TestBackfillBatchSizeIsRespected(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithMigratorInSchemaAndConnectionToContainerWithOptions(t, "public", []roll.Option{roll.WithBackfillBatchSize(100)}, func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		// Create a table with some rows
+		err := mig.Start(ctx, &migrations.Migration{
+			Name:       "01_create_table",
+			Operations: migrations.Operations{createTableOp("table1")},
+		})
+		assert.NoError(t, err)
+		err = mig.Complete(ctx)
+		assert.NoError(t, err)
+
+		_, err = db.ExecContext(ctx, "INSERT INTO table1 (id, name) SELECT i, 'name ' || i FROM generate_series(1, 25) AS i")
+		assert.NoError(t, err)
+
+		// Start a migration that backfills the table, overriding the batch size
+		var progress []migrations.BackfillProgress
+		err = mig.Start(ctx, &migrations.Migration{
+			Name:     "02_add_column",
+			Backfill: &migrations.BackfillSettings{BatchSize: ptr(10)},
+			Operations: migrations.Operations{
+				&migrations.OpAddColumn{
+					Table: "table1",
+					Up:    ptr("length(name)"),
+					Column: migrations.Column{
+						Name:     "name_length",
+						Type:     "integer",
+						Nullable: ptr(true),
+					},
+				},
+			},
+		}, func(p migrations.BackfillProgress) { progress = append(progress, p) })
+		assert.NoError(t, err)
+
+		// The backfill ran in batches of the size given by the migration
+		var rows []int64
+		for _, p := range progress {
+			rows = append(rows, p.Rows)
+		}
+		assert.Equal(t, []int64{0, 10, 20, 25, 25}, rows)
+
+		// Progress names the operation and table, and ends when all rows are done
+		last := progress[len(progress)-1]
+		assert.Equal(t, migrations.OpNameAddColumn, last.Operation)
+		assert.Equal(t, "table1", last.Table)
+		assert.Equal(t, int64(25), last.Total)
+		assert.True(t, last.Done)
+	})
+}
+
+func TestInterruptedBackfillIsResumedFromCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	opts := []roll.Option{roll.WithKeepOnInterrupt(), roll.WithBackfillBatchSize(10)}
+	testutils.WithMigratorInSchemaAndConnectionToContainerWithOptions(t, "public", opts, func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		// Create a table with some rows
+		err := mig.Start(ctx, &migrations.Migration{
+			Name:       "01_create_table",
+			Operations: migrations.Operations{createTableOp("table1")},
+		})
+		assert.NoError(t, err)
+		err = mig.Complete(ctx)
+		assert.NoError(t, err)
+
+		_, err = db.ExecContext(ctx, "INSERT INTO table1 (id, name) SELECT i, 'name ' || i FROM generate_series(1, 25) AS i")
+		assert.NoError(t, err)
+
+		migration := &migrations.Migration{
+			Name: "02_add_column",
+			Operations: migrations.Operations{
+				&migrations.OpAddColumn{
+					Table: "table1",
+					Up:    ptr("length(name)"),
+					Column: migrations.Column{
+						Name:     "name_length",
+						Type:     "integer",
+						Nullable: ptr(true),
+					},
+				},
+			},
+		}
+
+		// Interrupt the backfill after its first batch
+		cancelCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		err = mig.Start(cancelCtx, migration, func(p migrations.BackfillProgress) {
+			if p.Rows > 0 {
+				cancel()
+			}
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+
+		// Resuming the migration only backfills the remaining rows
+		var progress []int64
+		err = mig.Start(ctx, migration, func(p migrations.BackfillProgress) { progress = append(progress, p.Rows) })
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{0, 10, 15, 15}, progress)
+
+		// All rows have been backfilled
+		var missing int
+		err = db.QueryRowContext(ctx, fmt.Sprintf("SELECT count(*) FROM table1 WHERE %s IS NULL",
+			pq.QuoteIdentifier(migrations.TemporaryName("name_length")))).Scan(&missing)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, missing)
+
+		// Checkpoints are cleared once the migration is complete
+		err = mig.Complete(ctx)
+		assert.NoError(t, err)
+
+		var checkpoints int
+		err = db.QueryRowContext(ctx, "SELECT count(*) FROM pgroll.backfill_checkpoints").Scan(&checkpoints)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, checkpoints)
+	})
+}
+
+func TestFailedBackfillIsKeptAndResumed(t *testing.T) {
+	t.Parallel()
+
+	opts := []roll.Option{roll.WithBackfillBatchSize(10)}
+	testutils.WithMigratorInSchemaAndConnectionToContainerWithOptions(t, "public", opts, func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		// Create a table with some rows
+		err := mig.Start(ctx, &migrations.Migration{
+			Name:       "01_create_table",
+			Operations: migrations.Operations{createTableOp("table1")},
+		})
+		assert.NoError(t, err)
+		err = mig.Complete(ctx)
+		assert.NoError(t, err)
+
+		_, err = db.ExecContext(ctx, `INSERT INTO table1 (id, name) SELECT i, 'name ' || i FROM generate_series(1, 25) AS i;
+			CREATE TABLE divisor (d integer);
+			INSERT INTO divisor VALUES (0)`)
+		assert.NoError(t, err)
+
+		// The `up` SQL fails for the rows after the first batch until the divisor
+		// is fixed
+		migration := &migrations.Migration{
+			Name: "02_add_column",
+			Operations: migrations.Operations{
+				&migrations.OpAddColumn{
+					Table: "table1",
+					Up:    ptr("CASE WHEN id <= 10 THEN length(name) ELSE length(name) / (SELECT d FROM public.divisor) END"),
+					Column: migrations.Column{
+						Name:     "name_length",
+						Type:     "integer",
+						Nullable: ptr(true),
+					},
+				},
+			},
+		}
+
+		err = mig.Start(ctx, migration)
+		assert.ErrorAs(t, err, &migrations.BackfillError{})
+
+		// The migration is kept in progress, along with the checkpoint of its
+		// backfill
+		status, err := mig.Status(ctx, "public")
+		assert.NoError(t, err)
+		assert.Equal(t, "02_add_column", status.Version)
+		assert.Equal(t, state.InProgressMigrationStatus, status.Status)
+
+		var checkpoints int
+		err = db.QueryRowContext(ctx, "SELECT count(*) FROM pgroll.backfill_checkpoints").Scan(&checkpoints)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, checkpoints)
+
+		// Starting the migration again resumes the backfill
+		_, err = db.ExecContext(ctx, "UPDATE divisor SET d = 1")
+		assert.NoError(t, err)
+
+		var progress []int64
+		err = mig.Start(ctx, migration, func(p migrations.BackfillProgress) { progress = append(progress, p.Rows) })
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{0, 10, 15, 15}, progress)
+
+		err = mig.Complete(ctx)
+		assert.NoError(t, err)
+
+		var missing int
+		err = db.QueryRowContext(ctx, "SELECT count(*) FROM table1 WHERE name_length IS NULL").Scan(&missing)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, missing)
+	})
+}
+
+func TestResumedStartSkipsStartedOperations(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithMigratorAndConnectionToContainer(t, func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		// Create two tables with some rows
+		err := mig.Start(ctx, &migrations.Migration{
+			Name:       "01_create_tables",
+			Operations: migrations.Operations{createTableOp("table1"), createTableOp("table2")},
+		})
+		assert.NoError(t, err)
+		err = mig.Complete(ctx)
+		assert.NoError(t, err)
+
+		_, err = db.ExecContext(ctx, `INSERT INTO table1 (id, name) SELECT i, 'name ' || i FROM generate_series(1, 5) AS i;
+			INSERT INTO table2 (id, name) SELECT i, 'name ' || i FROM generate_series(1, 5) AS i;
+			CREATE TABLE divisor (d integer);
+			INSERT INTO divisor VALUES (0)`)
+		assert.NoError(t, err)
+
+		// The first operation is started, the backfill of the second one fails
+		// until the divisor is fixed
+		migration := &migrations.Migration{
+			Name: "02_add_columns",
+			Operations: migrations.Operations{
+				addColumnOp("table2"),
+				&migrations.OpAddColumn{
+					Table: "table1",
+					Up:    ptr("length(name) / (SELECT d FROM public.divisor)"),
+					Column: migrations.Column{
+						Name:     "name_length",
+						Type:     "integer",
+						Nullable: ptr(true),
+					},
+				},
+			},
+		}
+
+		err = mig.Start(ctx, migration)
+		assert.ErrorAs(t, err, &migrations.BackfillError{})
+
+		_, err = db.ExecContext(ctx, "UPDATE divisor SET d = 1")
+		assert.NoError(t, err)
+
+		// Starting the migration again only starts the second operation
+		var tables []string
+		err = mig.Start(ctx, migration, func(p migrations.BackfillProgress) { tables = append(tables, p.Table) })
+		assert.NoError(t, err)
+		assert.NotEmpty(t, tables)
+		for _, table := range tables {
+			assert.Equal(t, "table1", table)
+		}
+
+		err = mig.Complete(ctx)
+		assert.NoError(t, err)
+
+		// Both columns were added
+		var columns int
+		err = db.QueryRowContext(ctx, `SELECT count(*) FROM information_schema.columns
+			WHERE table_schema = 'public' AND (table_name, column_name) IN (('table2', 'age'), ('table1', 'name_length'))`).Scan(&columns)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, columns)
+	})
+}
+
+func TestDeferredBackfill(t *testing.T) {
+	t.Parallel()
+
+	opts := []roll.Option{roll.WithDeferredBackfill()}
+	testutils.WithMigratorInSchemaAndConnectionToContainerWithOptions(t, "public", opts, func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		// Create a table with some rows
+		err := mig.Start(ctx, &migrations.Migration{
+			Name:       "01_create_table",
+			Operations: migrations.Operations{createTableOp("table1")},
+		})
+		assert.NoError(t, err)
+		err = mig.Complete(ctx)
+		assert.NoError(t, err)
+
+		_, err = db.ExecContext(ctx, "INSERT INTO table1 (id, name) SELECT i, 'name ' || i FROM generate_series(1, 25) AS i")
+		assert.NoError(t, err)
+
+		countMissing := func() int {
+			var missing int
+			err := db.QueryRowContext(ctx, fmt.Sprintf("SELECT count(*) FROM table1 WHERE %s IS NULL",
+				pq.QuoteIdentifier(migrations.TemporaryName("name_length")))).Scan(&missing)
+			assert.NoError(t, err)
+			return missing
+		}
+
+		// Start a migration that needs a backfill: existing rows are left as
+		// they are
+		err = mig.Start(ctx, &migrations.Migration{
+			Name: "02_add_column",
+			Operations: migrations.Operations{
+				&migrations.OpAddColumn{
+					Table: "table1",
+					Up:    ptr("length(name)"),
+					Column: migrations.Column{
+						Name:     "name_length",
+						Type:     "integer",
+						Nullable: ptr(true),
+					},
+				},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 25, countMissing())
+
+		// The migration can't be completed until the backfill has run
+		err = mig.Complete(ctx)
+		assert.ErrorIs(t, err, state.ErrPendingBackfills)
+
+		var progress []migrations.BackfillProgress
+		err = mig.Backfill(ctx, func(p migrations.BackfillProgress) { progress = append(progress, p) })
+		assert.NoError(t, err)
+		assert.Equal(t, 0, countMissing())
+		assert.Equal(t, migrations.OpNameAddColumn, progress[len(progress)-1].Operation)
+		assert.True(t, progress[len(progress)-1].Done)
+
+		// There is nothing left to backfill
+		err = mig.Backfill(ctx)
+		assert.NoError(t, err)
+
+		err = mig.Complete(ctx)
+		assert.NoError(t, err)
+		assert.True(t, columnExists(t, db, "table1", "name_length"))
+	})
+}
+
+func TestVerifyUpColumns(t *testing.T) {
+	t.Parallel()
+
+	opts := []roll.Option{roll.WithCompleteVerification(migrations.VerifyConfig{})}
+	testutils.WithMigratorInSchemaAndConnectionToContainerWithOptions(t, "public", opts, func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		// Create a table with some rows
+		err := mig.Start(ctx, &migrations.Migration{
+			Name:       "01_create_table",
+			Operations: migrations.Operations{createTableOp("table1")},
+		})
+		assert.NoError(t, err)
+		err = mig.Complete(ctx)
+		assert.NoError(t, err)
+
+		_, err = db.ExecContext(ctx, "INSERT INTO table1 (id, name) SELECT i, 'name ' || i FROM generate_series(1, 25) AS i")
+		assert.NoError(t, err)
+
+		err = mig.Start(ctx, &migrations.Migration{
+			Name: "02_add_column",
+			Operations: migrations.Operations{
+				&migrations.OpAddColumn{
+					Table: "table1",
+					Up:    ptr("length(name)"),
+					Column: migrations.Column{
+						Name:     "name_length",
+						Type:     "integer",
+						Nullable: ptr(true),
+					},
+				},
+			},
+		})
+		assert.NoError(t, err)
+
+		// All rows match the up expression after the backfill
+		results, err := mig.Verify(ctx, nil)
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+		assert.Equal(t, migrations.TemporaryName("name_length"), results[0].Column)
+		assert.Equal(t, int64(25), results[0].Checked)
+		assert.Equal(t, int64(0), results[0].Mismatches)
+
+		// Corrupt some rows, bypassing the up trigger
+		_, err = db.ExecContext(ctx, fmt.Sprintf(`
+			ALTER TABLE table1 DISABLE TRIGGER USER;
+			UPDATE table1 SET %s = 0 WHERE id IN (3, 7);
+			ALTER TABLE table1 ENABLE TRIGGER USER;`,
+			pq.QuoteIdentifier(migrations.TemporaryName("name_length"))))
+		assert.NoError(t, err)
+
+		// The mismatching rows are reported by primary key
+		results, err = mig.Verify(ctx, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), results[0].Mismatches)
+		assert.Equal(t, []map[string]*string{{"id": ptr("3")}, {"id": ptr("7")}}, results[0].MismatchedKeys)
+
+		// The migration can't be completed while rows don't match
+		err = mig.Complete(ctx)
+		var mismatchErr migrations.UpColumnMismatchError
+		assert.ErrorAs(t, err, &mismatchErr)
+		assert.Equal(t, int64(2), mismatchErr.Mismatches)
+
+		// Fix the rows by firing the up trigger again
+		_, err = db.ExecContext(ctx, "UPDATE table1 SET name = name WHERE id IN (3, 7)")
+		assert.NoError(t, err)
+
+		err = mig.Complete(ctx)
+		assert.NoError(t, err)
+	})
+}
+
+func TestTriggersDetectTheVersionOfAWrite(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithMigratorAndConnectionToContainer(t, func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		err := mig.Start(ctx, &migrations.Migration{
+			Name:       "01_create_table",
+			Operations: migrations.Operations{createTableOp("table1")},
+		})
+		assert.NoError(t, err)
+		err = mig.Complete(ctx)
+		assert.NoError(t, err)
+
+		// Change the type of the `name` column, upper-casing the values written
+		// through the old version and lower-casing the values written through
+		// the new one
+		err = mig.Start(ctx, &migrations.Migration{
+			Name: "02_change_type",
+			Operations: migrations.Operations{
+				&migrations.OpAlterColumn{
+					Table:  "table1",
+					Column: "name",
+					Type:   ptr("text"),
+					Up:     ptr("upper(name)"),
+					Down:   ptr("lower(name)"),
+				},
+			},
+		})
+		assert.NoError(t, err)
+
+		oldSchema := roll.VersionedSchemaName(schema, "01_create_table")
+		newSchema := roll.VersionedSchemaName(schema, "02_change_type")
+		insert := func(t *testing.T, setup, versionSchema string, id int) {
+			t.Helper()
+
+			conn, err := db.Conn(ctx)
+			assert.NoError(t, err)
+			defer conn.Close()
+
+			_, err = conn.ExecContext(ctx, setup)
+			assert.NoError(t, err)
+
+			//nolint:gosec // this is a test so we don't care about SQL injection
+			_, err = conn.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s.table1 (id, name) VALUES (%d, 'Name')", pq.QuoteIdentifier(versionSchema), id))
+			assert.NoError(t, err)
+		}
+
+		// The new version is detected from the first schema of the search_path
+		insert(t, fmt.Sprintf("SET search_path = %s, public", pq.QuoteIdentifier(newSchema)), newSchema, 1)
+
+		// The version is detected from the version schema setting, whatever the
+		// search_path
+		insert(t, fmt.Sprintf("SET search_path = public; SET %s = %s", migrations.VersionSchemaSetting, pq.QuoteLiteral(newSchema)), newSchema, 2)
+		insert(t, fmt.Sprintf("SET search_path = %s; SET %s = %s", pq.QuoteIdentifier(newSchema), migrations.VersionSchemaSetting, pq.QuoteLiteral(oldSchema)), oldSchema, 3)
+
+		rows := MustSelect(t, db, schema, "01_create_table", "table1")
+		assert.Equal(t, []map[string]any{
+			{"id": 1, "name": "name"},
+			{"id": 2, "name": "name"},
+			{"id": 3, "name": "Name"},
+		}, rows)
+
+		rows = MustSelect(t, db, schema, "02_change_type", "table1")
+		assert.Equal(t, []map[string]any{
+			{"id": 1, "name": "Name"},
+			{"id": 2, "name": "Name"},
+			{"id": 3, "name": "NAME"},
+		}, rows)
+	})
+}
+
+func TestVerifyUpColumnsEvaluatesExpressionsAsTheTriggers(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithMigratorAndConnectionToContainer(t, func(mig *roll.Roll, db *sql.DB) {
+		ctx := context.Background()
+
+		err := mig.Start(ctx, &migrations.Migration{
+			Name:       "01_create_table",
+			Operations: migrations.Operations{createTableOp("table1")},
+		})
+		assert.NoError(t, err)
+		err = mig.Complete(ctx)
+		assert.NoError(t, err)
+
+		err = mig.Start(ctx, &migrations.Migration{
+			Name: "02_change_type",
+			Operations: migrations.Operations{
+				&migrations.OpAlterColumn{
+					Table:  "table1",
+					Column: "name",
+					Type:   ptr("text"),
+					Up:     ptr("upper(name)"),
+					Down:   ptr("lower(name)"),
+				},
+			},
+		})
+		assert.NoError(t, err)
+
+		// Write a row through each version
+		insert := func(version string, id int, name string) {
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			_, err = tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL search_path = %s", pq.QuoteIdentifier(roll.VersionedSchemaName(schema, version))))
+			assert.NoError(t, err)
+			_, err = tx.ExecContext(ctx, "INSERT INTO table1 (id, name) VALUES ($1, $2)", id, name)
+			assert.NoError(t, err)
+			assert.NoError(t, tx.Commit())
+		}
+		insert("01_create_table", 1, "Alice")
+		insert("02_change_type", 2, "Bob")
+
+		// The row written through the new version doesn't match the up
+		// expression, but its old column matches the down expression
+		results, err := mig.Verify(ctx, nil)
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+		assert.Equal(t, int64(2), results[0].Checked)
+		assert.Equal(t, int64(0), results[0].Mismatches)
+
+		// Corrupt a row, bypassing the triggers
+		_, err = db.ExecContext(ctx, fmt.Sprintf(`
+			ALTER TABLE table1 DISABLE TRIGGER USER;
+			UPDATE table1 SET %s = 'Carol' WHERE id = 1;
+			ALTER TABLE table1 ENABLE TRIGGER USER;`,
+			pq.QuoteIdentifier(migrations.TemporaryName("name"))))
+		assert.NoError(t, err)
+
+		results, err = mig.Verify(ctx, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), results[0].Mismatches)
+		assert.Equal(t, []map[string]*string{{"id": ptr("1")}}, results[0].MismatchedKeys)
+	})
+}
+
 func TestRoleIsRespected(t *testing.T) {
 	t.Parallel()
 
@@ -2,6 +2,12 @@
 
 package roll
 
+import (
+	"time"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
 type options struct {
 	// lock timeout in milliseconds for pgroll DDL operations
 	lockTimeoutMs int
@@ -11,6 +17,27 @@ type options struct {
 
 	// disable pgroll version schemas creation and deletion
 	disableVersionSchemas bool
+
+	// keep an interrupted migration in progress instead of rolling it back
+	keepOnInterrupt bool
+
+	// number of rows updated by each backfill batch
+	backfillBatchSize int
+
+	// time to wait between two backfill batches
+	backfillBatchDelay time.Duration
+
+	// thresholds used to adapt the pace of backfills to the database load
+	backfillThrottle migrations.ThrottleConfig
+
+	// number of partitions of a table backfilled concurrently
+	backfillWorkers int
+
+	// defer backfills until `Backfill` is called
+	deferBackfill bool
+
+	// verify up columns before completing migrations, if set
+	completeVerification *migrations.VerifyConfig
 }
 
 type Option func(*options)
@@ -36,3 +63,66 @@ func WithDisableViewsManagement() Option {
 		o.disableVersionSchemas = true
 	}
 }
+
+// WithKeepOnInterrupt keeps a migration whose start is interrupted (by
+// cancelling the context passed to `Start`) in progress instead of rolling it
+// back. Calling `Start` again with the same migration resumes it.
+func WithKeepOnInterrupt() Option {
+	return func(o *options) {
+		o.keepOnInterrupt = true
+	}
+}
+
+// WithBackfillBatchSize sets the number of rows updated by each batch of the
+// backfills run by migrations. Migrations can override it with their own
+// backfill settings.
+func WithBackfillBatchSize(batchSize int) Option {
+	return func(o *options) {
+		o.backfillBatchSize = batchSize
+	}
+}
+
+// WithBackfillBatchDelay sets the time to wait between two batches of the
+// backfills run by migrations. Migrations can override it with their own
+// backfill settings.
+func WithBackfillBatchDelay(delay time.Duration) Option {
+	return func(o *options) {
+		o.backfillBatchDelay = delay
+	}
+}
+
+// WithBackfillThrottle makes backfills adapt their pace to the load of the
+// database: they slow down, or pause, while any of the thresholds in the given
+// config is exceeded, and speed up again while the database is idle.
+func WithBackfillThrottle(throttle migrations.ThrottleConfig) Option {
+	return func(o *options) {
+		o.backfillThrottle = throttle
+	}
+}
+
+// WithBackfillWorkers sets the number of partitions of a table that are
+// backfilled concurrently, each on its own connection. Migrations can override
+// it with their own backfill settings.
+func WithBackfillWorkers(workers int) Option {
+	return func(o *options) {
+		o.backfillWorkers = workers
+	}
+}
+
+// WithDeferredBackfill makes `Start` skip the backfills of migrations,
+// recording them as pending instead. The pending backfills are run by
+// `Backfill`, and migrations can't be completed until they have all finished.
+func WithDeferredBackfill() Option {
+	return func(o *options) {
+		o.deferBackfill = true
+	}
+}
+
+// WithCompleteVerification makes `Complete` verify the up columns of the
+// migration as `Verify` does, and refuse to complete it if any row doesn't
+// match its up expression.
+func WithCompleteVerification(cfg migrations.VerifyConfig) Option {
+	return func(o *options) {
+		o.completeVerification = &cfg
+	}
+}
@@ -5,10 +5,12 @@ package roll
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 
 	"github.com/lib/pq"
 
+	"github.com/xataio/pgroll/pkg/migrations"
 	"github.com/xataio/pgroll/pkg/state"
 )
 
@@ -25,6 +27,15 @@ type Roll struct {
 	// disable pgroll version schemas creation and deletion
 	disableVersionSchemas bool
 
+	// keep an interrupted migration in progress instead of rolling it back
+	keepOnInterrupt bool
+
+	// how backfills are run, unless overridden by a migration
+	backfillConfig *migrations.BackfillConfig
+
+	// verify up columns before completing migrations, if set
+	completeVerification *migrations.VerifyConfig
+
 	state     *state.State
 	pgVersion PGVersion
 }
@@ -42,32 +53,34 @@ func New(ctx context.Context, pgURL, schema string, state *state.State, opts ...
 
 	dsn += " search_path=" + schema
 
-	conn, err := sql.Open("postgres", dsn)
+	connector, err := pq.NewConnector(dsn)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := conn.PingContext(ctx); err != nil {
-		return nil, err
+	// The session settings are applied to every connection of the pool, as
+	// backfill workers run on connections of their own
+	settings := []sessionSetting{{
+		sql:         "SET LOCAL pgroll.internal to 'TRUE'",
+		description: "pgroll.internal to true",
+	}}
+	if options.lockTimeoutMs > 0 {
+		settings = append(settings, sessionSetting{
+			sql:         fmt.Sprintf("SET lock_timeout to '%dms'", options.lockTimeoutMs),
+			description: "lock_timeout",
+		})
 	}
-
-	_, err = conn.ExecContext(ctx, "SET LOCAL pgroll.internal to 'TRUE'")
-	if err != nil {
-		return nil, fmt.Errorf("unable to set pgroll.internal to true: %w", err)
+	if options.role != "" {
+		settings = append(settings, sessionSetting{
+			sql:         fmt.Sprintf("SET ROLE %s", options.role),
+			description: fmt.Sprintf("role to '%s'", options.role),
+		})
 	}
 
-	if options.lockTimeoutMs > 0 {
-		_, err = conn.ExecContext(ctx, fmt.Sprintf("SET lock_timeout to '%dms'", options.lockTimeoutMs))
-		if err != nil {
-			return nil, fmt.Errorf("unable to set lock_timeout: %w", err)
-		}
-	}
+	conn := sql.OpenDB(&sessionConnector{Connector: connector, settings: settings})
 
-	if options.role != "" {
-		_, err = conn.ExecContext(ctx, fmt.Sprintf("SET ROLE %s", options.role))
-		if err != nil {
-			return nil, fmt.Errorf("unable to set role to '%s': %w", options.role, err)
-		}
+	if err := conn.PingContext(ctx); err != nil {
+		return nil, err
 	}
 
 	var pgMajorVersion PGVersion
@@ -82,9 +95,52 @@ func New(ctx context.Context, pgURL, schema string, state *state.State, opts ...
 		state:                 state,
 		pgVersion:             PGVersion(pgMajorVersion),
 		disableVersionSchemas: options.disableVersionSchemas,
+		keepOnInterrupt:       options.keepOnInterrupt,
+		completeVerification:  options.completeVerification,
+		backfillConfig: &migrations.BackfillConfig{
+			BatchSize:  options.backfillBatchSize,
+			BatchDelay: options.backfillBatchDelay,
+			Throttle:   options.backfillThrottle,
+			Workers:    options.backfillWorkers,
+			Defer:      options.deferBackfill,
+		},
 	}, nil
 }
 
+// sessionSetting is a statement that configures the session of a connection
+type sessionSetting struct {
+	sql         string
+	description string
+}
+
+// sessionConnector opens connections with the given session settings
+type sessionConnector struct {
+	driver.Connector
+	settings []sessionSetting
+}
+
+func (c *sessionConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("unable to configure connection: %T doesn't support Exec", conn)
+	}
+
+	for _, s := range c.settings {
+		if _, err := execer.ExecContext(ctx, s.sql, nil); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("unable to set %s: %w", s.description, err)
+		}
+	}
+
+	return conn, nil
+}
+
 func (m *Roll) Init(ctx context.Context) error {
 	return m.state.Init(ctx)
 }
@@ -97,6 +153,10 @@ func (m *Roll) Status(ctx context.Context, schema string) (*state.Status, error)
 	return m.state.Status(ctx, schema)
 }
 
+func (m *Roll) History(ctx context.Context, schema string, all bool) ([]state.HistoryEntry, error) {
+	return m.state.History(ctx, schema, all)
+}
+
 func (m *Roll) Close() error {
 	err := m.state.Close()
 	if err != nil {
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package roll
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionSettingsAreAppliedToEveryConnection(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := &fakeConnector{}
+	db := sql.OpenDB(&sessionConnector{Connector: fake, settings: []sessionSetting{
+		{sql: "SET lock_timeout to '100ms'", description: "lock_timeout"},
+		{sql: "SET ROLE pgroll", description: "role to 'pgroll'"},
+	}})
+	defer db.Close()
+
+	// Hold two connections at once, as backfill workers do
+	conn1, err := db.Conn(ctx)
+	assert.NoError(t, err)
+	defer conn1.Close()
+	conn2, err := db.Conn(ctx)
+	assert.NoError(t, err)
+	defer conn2.Close()
+
+	assert.Len(t, fake.conns, 2)
+	for _, c := range fake.conns {
+		assert.Equal(t, []string{"SET lock_timeout to '100ms'", "SET ROLE pgroll"}, c.statements)
+	}
+}
+
+func TestFailedSessionSettingFailsTheConnection(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeConnector{err: errors.New("permission denied")}
+	db := sql.OpenDB(&sessionConnector{Connector: fake, settings: []sessionSetting{
+		{sql: "SET ROLE pgroll", description: "role to 'pgroll'"},
+	}})
+	defer db.Close()
+
+	err := db.PingContext(context.Background())
+	assert.EqualError(t, err, "unable to set role to 'pgroll': permission denied")
+	assert.True(t, fake.conns[0].closed)
+}
+
+type fakeConnector struct {
+	mu    sync.Mutex
+	err   error
+	conns []*fakeConn
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	conn := &fakeConn{err: c.err}
+	c.conns = append(c.conns, conn)
+	return conn, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver { return nil }
+
+type fakeConn struct {
+	err        error
+	statements []string
+	closed     bool
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.statements = append(c.statements, query)
+	return driver.RowsAffected(0), nil
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error                        { c.closed = true; return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
@@ -0,0 +1,179 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"reflect"
+	"slices"
+	"sort"
+)
+
+type DifferenceKind string
+
+const (
+	AddedDifference   DifferenceKind = "added"
+	RemovedDifference DifferenceKind = "removed"
+	ChangedDifference DifferenceKind = "changed"
+)
+
+type ObjectKind string
+
+const (
+	TableObject            ObjectKind = "table"
+	ColumnObject           ObjectKind = "column"
+	IndexObject            ObjectKind = "index"
+	PrimaryKeyObject       ObjectKind = "primary key"
+	ForeignKeyObject       ObjectKind = "foreign key"
+	CheckConstraintObject  ObjectKind = "check constraint"
+	UniqueConstraintObject ObjectKind = "unique constraint"
+)
+
+// Difference describes an object that differs between two schemas
+type Difference struct {
+	// Whether the object was added, removed or changed
+	Kind DifferenceKind `json:"kind"`
+
+	// The kind of object that differs
+	Object ObjectKind `json:"object"`
+
+	// The table the object belongs to, or the table itself
+	Table string `json:"table"`
+
+	// The name of the object within the table. Unset for tables and primary
+	// keys.
+	Name string `json:"name,omitempty"`
+
+	// The object in the original schema, unset if it was added
+	From any `json:"from,omitempty"`
+
+	// The object in the new schema, unset if it was removed
+	To any `json:"to,omitempty"`
+}
+
+// Diff returns the differences between the tables of two schemas, ordered by
+// table, kind of object and name.
+// Table OIDs are not compared, as they don't describe the structure of the
+// schema.
+func Diff(from, to *Schema) []Difference {
+	diffs := []Difference{}
+
+	for name, fromTable := range from.Tables {
+		toTable, ok := to.Tables[name]
+		if !ok {
+			diffs = append(diffs, Difference{Kind: RemovedDifference, Object: TableObject, Table: name, From: fromTable})
+			continue
+		}
+		diffs = append(diffs, diffTables(name, &fromTable, &toTable)...)
+	}
+	for name, toTable := range to.Tables {
+		if _, ok := from.Tables[name]; !ok {
+			diffs = append(diffs, Difference{Kind: AddedDifference, Object: TableObject, Table: name, To: toTable})
+		}
+	}
+
+	sort.SliceStable(diffs, func(i, j int) bool {
+		if diffs[i].Table != diffs[j].Table {
+			return diffs[i].Table < diffs[j].Table
+		}
+		if diffs[i].Object != diffs[j].Object {
+			return diffs[i].Object < diffs[j].Object
+		}
+		return diffs[i].Name < diffs[j].Name
+	})
+
+	return diffs
+}
+
+func diffTables(table string, from, to *Table) []Difference {
+	var diffs []Difference
+
+	if from.Name != to.Name || from.Comment != to.Comment {
+		diffs = append(diffs, Difference{
+			Kind:   ChangedDifference,
+			Object: TableObject,
+			Table:  table,
+			From:   map[string]string{"name": from.Name, "comment": from.Comment},
+			To:     map[string]string{"name": to.Name, "comment": to.Comment},
+		})
+	}
+
+	if !slices.Equal(from.PrimaryKey, to.PrimaryKey) {
+		diffs = append(diffs, Difference{Kind: ChangedDifference, Object: PrimaryKeyObject, Table: table, From: from.PrimaryKey, To: to.PrimaryKey})
+	}
+
+	diffs = append(diffs, diffMaps(table, ColumnObject, from.Columns, to.Columns, func(a, b Column) bool {
+		// schemas recorded before positions were tracked have no positions
+		if a.Position == 0 || b.Position == 0 {
+			a.Position, b.Position = 0, 0
+		}
+		// nor the properties of columns read since, so those are only
+		// compared when the recorded column has them
+		if a.Generated == nil {
+			a.Generated = b.Generated
+		}
+		if a.Identity == "" {
+			a.Identity = b.Identity
+		}
+		if a.Collation == "" {
+			a.Collation = b.Collation
+		}
+		if a.Storage == "" {
+			a.Storage = b.Storage
+		}
+		if a.StatisticsTarget == nil {
+			a.StatisticsTarget = b.StatisticsTarget
+		}
+		if len(a.Privileges) == 0 {
+			a.Privileges = b.Privileges
+		}
+		return reflect.DeepEqual(a, b)
+	})...)
+	diffs = append(diffs, diffMaps(table, IndexObject, from.Indexes, to.Indexes, func(a, b Index) bool {
+		// schemas recorded before full definitions were read only have the
+		// name, uniqueness, columns and predicate of indexes
+		if a.Definition == "" || b.Definition == "" {
+			return a.Name == b.Name && a.Unique == b.Unique && slices.Equal(a.Columns, b.Columns) && reflect.DeepEqual(a.Predicate, b.Predicate)
+		}
+		return reflect.DeepEqual(a, b)
+	})...)
+	diffs = append(diffs, diffMaps(table, ForeignKeyObject, from.ForeignKeys, to.ForeignKeys, func(a, b ForeignKey) bool {
+		// likewise, older schemas lack the actions and deferrability of
+		// foreign keys
+		if a.Definition == "" || b.Definition == "" {
+			return a.Name == b.Name &&
+				slices.Equal(a.Columns, b.Columns) &&
+				a.ReferencedTable == b.ReferencedTable &&
+				slices.Equal(a.ReferencedColumns, b.ReferencedColumns)
+		}
+		return reflect.DeepEqual(a, b)
+	})...)
+	diffs = append(diffs, diffMaps(table, CheckConstraintObject, from.CheckConstraints, to.CheckConstraints, func(a, b CheckConstraint) bool {
+		return a.Name == b.Name && slices.Equal(a.Columns, b.Columns) && a.Definition == b.Definition
+	})...)
+	diffs = append(diffs, diffMaps(table, UniqueConstraintObject, from.UniqueConstraints, to.UniqueConstraints, func(a, b UniqueConstraint) bool {
+		return a.Name == b.Name && slices.Equal(a.Columns, b.Columns)
+	})...)
+
+	return diffs
+}
+
+func diffMaps[T any](table string, object ObjectKind, from, to map[string]T, equal func(a, b T) bool) []Difference {
+	var diffs []Difference
+
+	for name, fromObj := range from {
+		toObj, ok := to[name]
+		switch {
+		case !ok:
+			diffs = append(diffs, Difference{Kind: RemovedDifference, Object: object, Table: table, Name: name, From: fromObj})
+		case !equal(fromObj, toObj):
+			diffs = append(diffs, Difference{Kind: ChangedDifference, Object: object, Table: table, Name: name, From: fromObj, To: toObj})
+		}
+	}
+	for name, toObj := range to {
+		if _, ok := from[name]; !ok {
+			diffs = append(diffs, Difference{Kind: AddedDifference, Object: object, Table: table, Name: name, To: toObj})
+		}
+	}
+
+	return diffs
+}
@@ -0,0 +1,182 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+func TestDiff(t *testing.T) {
+	base := func() *schema.Schema {
+		return &schema.Schema{
+			Name: "public",
+			Tables: map[string]schema.Table{
+				"users": {
+					OID:  "16384",
+					Name: "users",
+					Columns: map[string]schema.Column{
+						"id":   {Name: "id", Type: "integer"},
+						"name": {Name: "name", Type: "text", Nullable: true},
+					},
+					PrimaryKey: []string{"id"},
+					Indexes: map[string]schema.Index{
+						"users_pkey": {Name: "users_pkey", Unique: true, Columns: []string{"id"}},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("identical schemas have no differences", func(t *testing.T) {
+		assert.Empty(t, schema.Diff(base(), base()))
+	})
+
+	t.Run("table OIDs are ignored", func(t *testing.T) {
+		to := base()
+		users := to.Tables["users"]
+		users.OID = "16500"
+		to.Tables["users"] = users
+
+		assert.Empty(t, schema.Diff(base(), to))
+	})
+
+	t.Run("unknown column positions are ignored", func(t *testing.T) {
+		to := base()
+		users := to.Tables["users"]
+		users.Columns = map[string]schema.Column{
+			"id":   {Name: "id", Type: "integer", Position: 1},
+			"name": {Name: "name", Type: "text", Nullable: true, Position: 2},
+		}
+		to.Tables["users"] = users
+
+		assert.Empty(t, schema.Diff(base(), to))
+	})
+
+	t.Run("column properties missing from older recorded schemas are ignored", func(t *testing.T) {
+		generated := "upper(name)"
+		target := 500
+		withProperties := func() *schema.Schema {
+			s := base()
+			users := s.Tables["users"]
+			users.Columns = map[string]schema.Column{
+				"id": {Name: "id", Type: "integer", Identity: "always"},
+				"name": {
+					Name:             "name",
+					Type:             "text",
+					Nullable:         true,
+					Generated:        &generated,
+					Collation:        "C",
+					Storage:          "EXTERNAL",
+					StatisticsTarget: &target,
+					Privileges:       []schema.ColumnPrivilege{{Grantee: "reader", Privilege: "SELECT"}},
+				},
+			}
+			s.Tables["users"] = users
+			return s
+		}
+
+		// the recorded schema predates these properties
+		assert.Empty(t, schema.Diff(base(), withProperties()))
+
+		// but they are compared once they have been recorded
+		changed := withProperties()
+		users := changed.Tables["users"]
+		name := users.Columns["name"]
+		name.Collation = "POSIX"
+		users.Columns["name"] = name
+		changed.Tables["users"] = users
+
+		diffs := schema.Diff(withProperties(), changed)
+		assert.Len(t, diffs, 1)
+		assert.Equal(t, schema.ColumnObject, diffs[0].Object)
+		assert.Equal(t, "name", diffs[0].Name)
+	})
+
+	t.Run("index and foreign key details are compared when both schemas have them", func(t *testing.T) {
+		withDetails := func(method, onDelete string) *schema.Schema {
+			s := base()
+			users := s.Tables["users"]
+			users.Indexes = map[string]schema.Index{
+				"users_pkey": {
+					Name:       "users_pkey",
+					Unique:     true,
+					Columns:    []string{"id"},
+					Method:     method,
+					Definition: "CREATE UNIQUE INDEX users_pkey ON public.users USING " + method + " (id)",
+				},
+			}
+			users.ForeignKeys = map[string]schema.ForeignKey{
+				"users_manager_fkey": {
+					Name:              "users_manager_fkey",
+					Columns:           []string{"manager_id"},
+					ReferencedTable:   "users",
+					ReferencedColumns: []string{"id"},
+					OnDelete:          onDelete,
+					OnUpdate:          "NO ACTION",
+					Definition:        "FOREIGN KEY (manager_id) REFERENCES users(id) ON DELETE " + onDelete,
+				},
+			}
+			s.Tables["users"] = users
+			return s
+		}
+		withoutDetails := func() *schema.Schema {
+			s := base()
+			users := s.Tables["users"]
+			users.ForeignKeys = map[string]schema.ForeignKey{
+				"users_manager_fkey": {
+					Name:              "users_manager_fkey",
+					Columns:           []string{"manager_id"},
+					ReferencedTable:   "users",
+					ReferencedColumns: []string{"id"},
+				},
+			}
+			s.Tables["users"] = users
+			return s
+		}
+
+		assert.Empty(t, schema.Diff(withDetails("btree", "CASCADE"), withDetails("btree", "CASCADE")))
+		assert.Empty(t, schema.Diff(withoutDetails(), withDetails("btree", "CASCADE")))
+
+		diffs := schema.Diff(withDetails("btree", "NO ACTION"), withDetails("hash", "CASCADE"))
+		if assert.Len(t, diffs, 2) {
+			assert.Equal(t, schema.ForeignKeyObject, diffs[0].Object)
+			assert.Equal(t, schema.IndexObject, diffs[1].Object)
+		}
+	})
+
+	t.Run("added, removed and changed objects are reported in order", func(t *testing.T) {
+		to := base()
+		users := to.Tables["users"]
+		users.Columns = map[string]schema.Column{
+			"id":    {Name: "id", Type: "bigint"},
+			"email": {Name: "email", Type: "text"},
+		}
+		users.Indexes = map[string]schema.Index{}
+		to.Tables["users"] = users
+		to.Tables["posts"] = schema.Table{Name: "posts"}
+
+		diffs := schema.Diff(base(), to)
+
+		type summary struct {
+			Kind   schema.DifferenceKind
+			Object schema.ObjectKind
+			Table  string
+			Name   string
+		}
+		var got []summary
+		for _, d := range diffs {
+			got = append(got, summary{d.Kind, d.Object, d.Table, d.Name})
+		}
+
+		assert.Equal(t, []summary{
+			{schema.AddedDifference, schema.TableObject, "posts", ""},
+			{schema.AddedDifference, schema.ColumnObject, "users", "email"},
+			{schema.ChangedDifference, schema.ColumnObject, "users", "id"},
+			{schema.RemovedDifference, schema.ColumnObject, "users", "name"},
+			{schema.RemovedDifference, schema.IndexObject, "users", "users_pkey"},
+		}, got)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 )
 
 // XXX we create a view of the schema with the minimum required for us to
@@ -26,6 +27,16 @@ type Schema struct {
 	Name string `json:"name"`
 	// Tables is a map of virtual table name -> table mapping
 	Tables map[string]Table `json:"tables"`
+
+	// Types is a map of the enum, domain, composite, range and base types of
+	// the schema
+	Types map[string]Type `json:"types,omitempty"`
+
+	// Sequences is a map of the sequences of the schema
+	Sequences map[string]Sequence `json:"sequences,omitempty"`
+
+	// Views is a map of the views and materialized views of the schema
+	Views map[string]View `json:"views,omitempty"`
 }
 
 type Table struct {
@@ -55,6 +66,23 @@ type Table struct {
 
 	// UniqueConstraints is a map of all unique constraints defined on the table
 	UniqueConstraints map[string]UniqueConstraint `json:"uniqueConstraints"`
+
+	// PartitionKey is the partition key of a partitioned table, eg.
+	// `RANGE (created_at)`, empty for other tables
+	PartitionKey string `json:"partitionKey,omitempty"`
+
+	// PartitionOf is the name of the partitioned table that the table is a
+	// partition of, empty for other tables
+	PartitionOf string `json:"partitionOf,omitempty"`
+
+	// PartitionBound is the bound of a partition, eg.
+	// `FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')`
+	PartitionBound string `json:"partitionBound,omitempty"`
+
+	// GeneratedNames maps the names of the objects that pgroll duplicated
+	// during the active migration, when they had to be shortened, to the
+	// names of the objects they duplicate. It isn't read from the database.
+	GeneratedNames map[string]string `json:"-"`
 }
 
 type Column struct {
@@ -64,12 +92,54 @@ type Column struct {
 	// Column type
 	Type string `json:"type"`
 
+	// Position is the logical position of the column in the table, which
+	// orders the columns of the views. It is carried across migrations rather
+	// than read from the catalog, so that a column keeps its position when it
+	// is replaced by a duplicate. Columns added during a migration are
+	// positioned after the existing columns.
+	Position int `json:"position"`
+
 	Default  *string `json:"default"`
 	Nullable bool    `json:"nullable"`
 	Unique   bool    `json:"unique"`
 
 	// Optional comment for the column
 	Comment string `json:"comment"`
+
+	// Generated is the expression of a generated column, nil for other columns
+	Generated *string `json:"generated,omitempty"`
+
+	// Identity is `always` or `by default` for identity columns, depending on
+	// how their values are generated, and empty for other columns
+	Identity string `json:"identity,omitempty"`
+
+	// Collation is the collation of the column, if it isn't the default
+	// collation of its type
+	Collation string `json:"collation,omitempty"`
+
+	// Storage is the storage mode of the column, eg. `EXTERNAL`, if it isn't
+	// the default storage mode of its type
+	Storage string `json:"storage,omitempty"`
+
+	// StatisticsTarget is the statistics target set on the column, nil if the
+	// default target is used
+	StatisticsTarget *int `json:"statisticsTarget,omitempty"`
+
+	// Privileges are the privileges granted on the column itself, rather than
+	// on its table
+	Privileges []ColumnPrivilege `json:"privileges,omitempty"`
+}
+
+type ColumnPrivilege struct {
+	// Grantee is the role the privilege is granted to, or `PUBLIC`
+	Grantee string `json:"grantee"`
+
+	// Privilege is the kind of privilege, eg. `SELECT` or `UPDATE`
+	Privilege string `json:"privilege"`
+
+	// Grantable indicates whether the grantee can grant the privilege to
+	// others
+	Grantable bool `json:"grantable"`
 }
 
 type Index struct {
@@ -79,8 +149,26 @@ type Index struct {
 	// Unique indicates whether or not the index is unique
 	Unique bool `json:"unique"`
 
-	// Columns is the set of key columns on which the index is defined
+	// Columns is the set of key columns on which the index is defined.
+	// Index expressions are given by their definition.
 	Columns []string `json:"columns"`
+
+	// Predicate is the WHERE clause of a partial index, nil otherwise
+	Predicate *string `json:"predicate,omitempty"`
+
+	// Method is the access method of the index, eg. btree or gin
+	Method string `json:"method"`
+
+	// Include is the set of non-key columns of the index, given by `INCLUDE`
+	Include []string `json:"include,omitempty"`
+
+	// SortOrders are the sort orders of the key columns, eg. `DESC` or
+	// `ASC NULLS FIRST`. It is nil if all of them are in ascending order.
+	SortOrders []string `json:"sortOrders,omitempty"`
+
+	// Definition is the full definition of the index, as given by
+	// pg_get_indexdef
+	Definition string `json:"definition"`
 }
 
 type ForeignKey struct {
@@ -95,6 +183,20 @@ type ForeignKey struct {
 
 	// The columns in the referenced table that the foreign key references
 	ReferencedColumns []string `json:"referencedColumns"`
+
+	// The actions taken when the referenced row is deleted or updated, eg.
+	// `NO ACTION` or `CASCADE`
+	OnDelete string `json:"onDelete"`
+	OnUpdate string `json:"onUpdate"`
+
+	// Whether checking the foreign key can be deferred, and whether it is
+	// deferred by default
+	Deferrable        bool `json:"deferrable"`
+	InitiallyDeferred bool `json:"initiallyDeferred"`
+
+	// Definition is the full definition of the foreign key, as given by
+	// pg_get_constraintdef
+	Definition string `json:"definition"`
 }
 
 type CheckConstraint struct {
@@ -116,6 +218,97 @@ type UniqueConstraint struct {
 	Columns []string `json:"columns"`
 }
 
+type TypeKind string
+
+const (
+	EnumType      TypeKind = "enum"
+	DomainType    TypeKind = "domain"
+	CompositeType TypeKind = "composite"
+	RangeType     TypeKind = "range"
+
+	// BaseType is the kind of the types defined by extensions, such as citext
+	BaseType TypeKind = "base"
+)
+
+type Type struct {
+	// Name is the name of the type in postgres
+	Name string `json:"name"`
+
+	// Kind is the kind of type: enum, domain, composite, range or base
+	Kind TypeKind `json:"kind"`
+
+	// Values are the labels of an enum type, in order
+	Values []string `json:"values,omitempty"`
+
+	// BaseType is the type a domain is based on
+	BaseType string `json:"baseType,omitempty"`
+
+	// NotNull indicates whether a domain is NOT NULL
+	NotNull bool `json:"notNull,omitempty"`
+
+	// Default is the default value of a domain
+	Default *string `json:"default,omitempty"`
+
+	// Checks are the definitions of the check constraints of a domain
+	Checks []string `json:"checks,omitempty"`
+
+	// Attributes are the attributes of a composite type, in order
+	Attributes []TypeAttribute `json:"attributes,omitempty"`
+}
+
+type TypeAttribute struct {
+	// Name is the name of the attribute
+	Name string `json:"name"`
+
+	// Type is the type of the attribute
+	Type string `json:"type"`
+}
+
+type Sequence struct {
+	// Name is the name of the sequence in postgres
+	Name string `json:"name"`
+
+	// Type is the data type of the sequence
+	Type string `json:"type"`
+
+	Start     int64 `json:"start"`
+	Increment int64 `json:"increment"`
+	Min       int64 `json:"min"`
+	Max       int64 `json:"max"`
+	Cycle     bool  `json:"cycle"`
+
+	// OwnerTable and OwnerColumn identify the column that owns the sequence,
+	// such as a serial or identity column, if any
+	OwnerTable  string `json:"ownerTable,omitempty"`
+	OwnerColumn string `json:"ownerColumn,omitempty"`
+}
+
+type View struct {
+	// Name is the name of the view in postgres
+	Name string `json:"name"`
+
+	// Materialized indicates whether the view is a materialized view
+	Materialized bool `json:"materialized"`
+
+	// Definition is the query of the view
+	Definition string `json:"definition"`
+}
+
+// Clone returns a deep copy of the schema
+func (s *Schema) Clone() (*Schema, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var clone Schema
+	if err := json.Unmarshal(b, &clone); err != nil {
+		return nil, err
+	}
+
+	return &clone, nil
+}
+
 func (s *Schema) GetTable(name string) *Table {
 	if s.Tables == nil {
 		return nil
@@ -127,6 +320,37 @@ func (s *Schema) GetTable(name string) *Table {
 	return &t
 }
 
+func (s *Schema) GetType(name string) *Type {
+	t, ok := s.Types[name]
+	if !ok {
+		return nil
+	}
+	return &t
+}
+
+func (s *Schema) GetSequence(name string) *Sequence {
+	seq, ok := s.Sequences[name]
+	if !ok {
+		return nil
+	}
+	return &seq
+}
+
+func (s *Schema) GetView(name string) *View {
+	v, ok := s.Views[name]
+	if !ok {
+		return nil
+	}
+	return &v
+}
+
+// RelationExists returns true if the schema has a table, view, sequence or
+// type with the given name. Tables can't be given any of these names, as they
+// share the namespace of relations and, through their row type, of types.
+func (s *Schema) RelationExists(name string) bool {
+	return s.GetTable(name) != nil || s.GetView(name) != nil || s.GetSequence(name) != nil || s.GetType(name) != nil
+}
+
 func (s *Schema) AddTable(name string, t Table) {
 	if s.Tables == nil {
 		s.Tables = make(map[string]Table)
@@ -149,6 +373,31 @@ func (s *Schema) RenameTable(from, to string) error {
 	return nil
 }
 
+// CarryColumnPositions gives the columns of the tables of the schema the
+// positions of the columns with the same names in the same tables of the given
+// schema, typically the schema as recorded before it was read again from the
+// database. Other columns are positioned after them, in their current order.
+func (s *Schema) CarryColumnPositions(from *Schema) {
+	for name, table := range s.Tables {
+		fromTable, ok := from.Tables[name]
+		if !ok {
+			continue
+		}
+
+		next := fromTable.nextPosition()
+		for _, col := range table.ColumnNames() {
+			c := table.Columns[col]
+			if fromCol, ok := fromTable.Columns[col]; ok && fromCol.Position != 0 {
+				c.Position = fromCol.Position
+			} else {
+				c.Position = next
+				next++
+			}
+			table.Columns[col] = c
+		}
+	}
+}
+
 func (s *Schema) RemoveTable(name string) {
 	delete(s.Tables, name)
 }
@@ -184,14 +433,52 @@ func (t *Table) GetPrimaryKey() (columns []*Column) {
 	return columns
 }
 
+// AddColumn adds a column to the table, or replaces the existing column with
+// the same name. Unless the column is given a position, it keeps the position
+// of the column it replaces or is positioned after all other columns.
 func (t *Table) AddColumn(name string, c Column) {
 	if t.Columns == nil {
 		t.Columns = make(map[string]Column)
 	}
 
+	if c.Position == 0 {
+		if existing, ok := t.Columns[name]; ok {
+			c.Position = existing.Position
+		} else {
+			c.Position = t.nextPosition()
+		}
+	}
+
 	t.Columns[name] = c
 }
 
+// ColumnNames returns the names of the columns of the table, ordered by their
+// position. Columns with the same position are ordered by name.
+func (t *Table) ColumnNames() []string {
+	names := make([]string, 0, len(t.Columns))
+	for name := range t.Columns {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		a, b := t.Columns[names[i]], t.Columns[names[j]]
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		return names[i] < names[j]
+	})
+
+	return names
+}
+
+func (t *Table) nextPosition() int {
+	position := 0
+	for _, c := range t.Columns {
+		position = max(position, c.Position)
+	}
+	return position + 1
+}
+
 func (t *Table) RemoveColumn(column string) {
 	delete(t.Columns, column)
 }
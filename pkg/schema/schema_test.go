@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+func TestColumnPositions(t *testing.T) {
+	table := func() *schema.Table {
+		return &schema.Table{
+			Name: "users",
+			Columns: map[string]schema.Column{
+				"id":    {Name: "id", Position: 1},
+				"name":  {Name: "name", Position: 3},
+				"email": {Name: "email", Position: 2},
+			},
+		}
+	}
+
+	t.Run("columns are ordered by position", func(t *testing.T) {
+		assert.Equal(t, []string{"id", "email", "name"}, table().ColumnNames())
+	})
+
+	t.Run("added columns are positioned after the existing columns", func(t *testing.T) {
+		tbl := table()
+		tbl.AddColumn("age", schema.Column{Name: "_pgroll_new_age"})
+
+		assert.Equal(t, 4, tbl.GetColumn("age").Position)
+		assert.Equal(t, []string{"id", "email", "name", "age"}, tbl.ColumnNames())
+	})
+
+	t.Run("replaced columns keep their position", func(t *testing.T) {
+		tbl := table()
+		tbl.AddColumn("email", schema.Column{Name: "_pgroll_new_email"})
+
+		assert.Equal(t, 2, tbl.GetColumn("email").Position)
+		assert.Equal(t, []string{"id", "email", "name"}, tbl.ColumnNames())
+	})
+
+	t.Run("renamed columns keep their position", func(t *testing.T) {
+		tbl := table()
+		tbl.RenameColumn("email", "mail")
+
+		assert.Equal(t, []string{"id", "mail", "name"}, tbl.ColumnNames())
+	})
+
+	t.Run("columns without positions are ordered by name", func(t *testing.T) {
+		tbl := &schema.Table{
+			Columns: map[string]schema.Column{
+				"b": {Name: "b"},
+				"a": {Name: "a"},
+			},
+		}
+
+		assert.Equal(t, []string{"a", "b"}, tbl.ColumnNames())
+	})
+}
+
+func TestCarryColumnPositions(t *testing.T) {
+	recorded := &schema.Schema{
+		Tables: map[string]schema.Table{
+			"users": {
+				Name: "users",
+				Columns: map[string]schema.Column{
+					"id":    {Name: "id", Position: 1},
+					"email": {Name: "email", Position: 2},
+					"name":  {Name: "name", Position: 3},
+				},
+			},
+		},
+	}
+
+	// email was replaced by a duplicate, which comes last in the catalog, and
+	// age was added
+	live := &schema.Schema{
+		Tables: map[string]schema.Table{
+			"users": {
+				Name: "users",
+				Columns: map[string]schema.Column{
+					"id":    {Name: "id", Position: 1},
+					"name":  {Name: "name", Position: 3},
+					"email": {Name: "email", Position: 4},
+					"age":   {Name: "age", Position: 5},
+				},
+			},
+			"posts": {
+				Name: "posts",
+				Columns: map[string]schema.Column{
+					"title": {Name: "title", Position: 2},
+				},
+			},
+		},
+	}
+
+	live.CarryColumnPositions(recorded)
+
+	users := live.GetTable("users")
+	assert.Equal(t, []string{"id", "email", "name", "age"}, users.ColumnNames())
+	assert.Equal(t, 2, users.GetColumn("email").Position)
+	assert.Equal(t, 4, users.GetColumn("age").Position)
+
+	// tables that weren't recorded keep the positions they were read with
+	assert.Equal(t, 2, live.GetTable("posts").GetColumn("title").Position)
+}
+
+func TestRelationExists(t *testing.T) {
+	s := &schema.Schema{
+		Tables:    map[string]schema.Table{"users": {Name: "users"}},
+		Types:     map[string]schema.Type{"mood": {Name: "mood", Kind: schema.EnumType}},
+		Sequences: map[string]schema.Sequence{"users_id_seq": {Name: "users_id_seq"}},
+		Views:     map[string]schema.View{"active_users": {Name: "active_users"}},
+	}
+
+	for _, name := range []string{"users", "mood", "users_id_seq", "active_users"} {
+		assert.True(t, s.RelationExists(name), name)
+	}
+	assert.False(t, s.RelationExists("posts"))
+	assert.False(t, schema.New().RelationExists("users"))
+}
@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+// PendingBackfill is a backfill of the active migration that hasn't finished
+type PendingBackfill struct {
+	// The index of the operation that requires the backfill
+	Operation int
+
+	// The table to backfill
+	Table string
+}
+
+// PendingBackfills returns the backfills of the active migration that haven't
+// finished, ordered by operation
+func (s *State) PendingBackfills(ctx context.Context, schema, migration string) ([]PendingBackfill, error) {
+	rows, err := s.pgConn.QueryContext(ctx,
+		fmt.Sprintf(`SELECT operation, table_name FROM %s.backfills
+			WHERE schema=$1 AND migration=$2 AND NOT done
+			ORDER BY operation, table_name`, pq.QuoteIdentifier(s.schema)),
+		schema, migration)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pending []PendingBackfill
+	for rows.Next() {
+		var b PendingBackfill
+		if err := rows.Scan(&b.Operation, &b.Table); err != nil {
+			return nil, err
+		}
+		pending = append(pending, b)
+	}
+
+	return pending, rows.Err()
+}
+
+// backfillCheckpoints stores the backfills of one operation of the active
+// migration and their checkpoints
+type backfillCheckpoints struct {
+	state     *State
+	schema    string
+	migration string
+	operation int
+}
+
+// BackfillCheckpoints returns a store for the backfills run by the operation at
+// the given index of the active migration, and their checkpoints.
+// Checkpoints are removed along with the migration when it is rolled back and
+// cleared once it is completed.
+func (s *State) BackfillCheckpoints(schema, migration string, operation int) migrations.BackfillCheckpointer {
+	return &backfillCheckpoints{
+		state:     s,
+		schema:    schema,
+		migration: migration,
+		operation: operation,
+	}
+}
+
+func (c *backfillCheckpoints) SaveBackfill(ctx context.Context, table string, done bool) error {
+	_, err := c.state.pgConn.ExecContext(ctx,
+		fmt.Sprintf(`INSERT INTO %s.backfills (schema, migration, operation, table_name, done)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (schema, migration, operation, table_name)
+			DO UPDATE SET done=EXCLUDED.done, updated_at=CURRENT_TIMESTAMP`,
+			pq.QuoteIdentifier(c.state.schema)),
+		c.schema, c.migration, c.operation, table, done)
+	return err
+}
+
+func (c *backfillCheckpoints) LoadCheckpoints(ctx context.Context, table string) ([]migrations.BackfillCheckpoint, error) {
+	rows, err := c.state.pgConn.QueryContext(ctx,
+		fmt.Sprintf(`SELECT last_key, upper_key, done FROM %s.backfill_checkpoints
+			WHERE schema=$1 AND migration=$2 AND operation=$3 AND table_name=$4
+			ORDER BY partition`, pq.QuoteIdentifier(c.state.schema)),
+		c.schema, c.migration, c.operation, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checkpoints []migrations.BackfillCheckpoint
+	for rows.Next() {
+		var lastKey, upperKey []byte
+		var cp migrations.BackfillCheckpoint
+		if err := rows.Scan(&lastKey, &upperKey, &cp.Done); err != nil {
+			return nil, err
+		}
+		if cp.LastKey, err = unmarshalKey(lastKey); err != nil {
+			return nil, err
+		}
+		if cp.UpperKey, err = unmarshalKey(upperKey); err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+
+	return checkpoints, rows.Err()
+}
+
+func (c *backfillCheckpoints) SaveCheckpoint(ctx context.Context, table string, partition int, cp migrations.BackfillCheckpoint) error {
+	lastKey, err := marshalKey(cp.LastKey)
+	if err != nil {
+		return err
+	}
+	upperKey, err := marshalKey(cp.UpperKey)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.state.pgConn.ExecContext(ctx,
+		fmt.Sprintf(`INSERT INTO %s.backfill_checkpoints (schema, migration, operation, table_name, partition, last_key, upper_key, done)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (schema, migration, operation, table_name, partition)
+			DO UPDATE SET last_key=EXCLUDED.last_key, upper_key=EXCLUDED.upper_key, done=EXCLUDED.done, updated_at=CURRENT_TIMESTAMP`,
+			pq.QuoteIdentifier(c.state.schema)),
+		c.schema, c.migration, c.operation, table, partition, lastKey, upperKey, cp.Done)
+	return err
+}
+
+// marshalKey encodes a key as JSON, or NULL if the key is unbounded
+func marshalKey(key []*string) ([]byte, error) {
+	if key == nil {
+		return nil, nil
+	}
+	return json.Marshal(key)
+}
+
+func unmarshalKey(raw []byte) ([]*string, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	var key []*string
+	if err := json.Unmarshal(raw, &key); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal backfill checkpoint: %w", err)
+	}
+	return key, nil
+}
@@ -2,6 +2,27 @@
 
 package state
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var ErrNoActiveMigration = errors.New("no active migration")
+
+var ErrNoInterruptedMigration = errors.New("no interrupted migration")
+
+var ErrActiveMigration = errors.New("a migration is in progress")
+
+var ErrPendingBackfills = errors.New("the active migration has pending backfills")
+
+// ChecksumMismatchError is returned when a migration has the same name as a
+// migration that was already started, but different operations.
+type ChecksumMismatchError struct {
+	Name     string
+	Expected string
+	Actual   string
+}
+
+func (e ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("migration %q has been modified since it was started: checksum is %s, expected %s", e.Name, e.Actual, e.Expected)
+}
@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package state
+
+import (
+	"time"
+
+	"github.com/xataio/pgroll/pkg/migrations"
+)
+
+// MigrationPhase is the phase of a migration in which it failed.
+type MigrationPhase string
+
+const (
+	ValidationMigrationPhase MigrationPhase = "validation"
+	StartMigrationPhase      MigrationPhase = "start"
+)
+
+type HistoryStatus string
+
+const (
+	CompleteHistoryStatus   HistoryStatus = "complete"
+	InProgressHistoryStatus HistoryStatus = "in progress"
+	FailedHistoryStatus     HistoryStatus = "failed"
+	RolledBackHistoryStatus HistoryStatus = "rolled back"
+)
+
+// HistoryEntry describes one migration attempt in the history of a schema.
+type HistoryEntry struct {
+	// The name of the migration.
+	Name string `json:"name"`
+
+	// The migration itself.
+	Migration migrations.Migration `json:"migration"`
+
+	// Whether the migration was run by pgroll or inferred from DDL statements
+	// run outside of pgroll.
+	MigrationType string `json:"migrationType"`
+
+	// The outcome of the migration.
+	Status HistoryStatus `json:"status"`
+
+	// The phase in which a failed migration failed.
+	Phase MigrationPhase `json:"phase,omitempty"`
+
+	// The error that caused a failed migration to fail.
+	Error string `json:"error,omitempty"`
+
+	// When the migration was started.
+	StartedAt time.Time `json:"startedAt"`
+
+	// When the migration was completed, failed or was rolled back. Unset while
+	// the migration is in progress.
+	EndedAt *time.Time `json:"endedAt,omitempty"`
+}
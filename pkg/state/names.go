@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package state
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// SaveGeneratedNames records the original names of the objects duplicated by
+// the active migration whose duplicates had to be given shortened names. The
+// names are given by table, and map the name of each duplicate to the name of
+// the object it duplicates.
+func (s *State) SaveGeneratedNames(ctx context.Context, schema, migration string, names map[string]map[string]string) error {
+	for table, tableNames := range names {
+		for name, original := range tableNames {
+			_, err := s.pgConn.ExecContext(ctx,
+				fmt.Sprintf(`INSERT INTO %s.generated_names (schema, migration, table_name, name, original)
+					VALUES ($1, $2, $3, $4, $5)
+					ON CONFLICT (schema, migration, table_name, name) DO UPDATE SET original = EXCLUDED.original`,
+					pq.QuoteIdentifier(s.schema)),
+				schema, migration, table, name, original)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// GeneratedNames returns the names recorded with `SaveGeneratedNames` for the
+// active migration
+func (s *State) GeneratedNames(ctx context.Context, schema, migration string) (map[string]map[string]string, error) {
+	rows, err := s.pgConn.QueryContext(ctx,
+		fmt.Sprintf(`SELECT table_name, name, original FROM %s.generated_names
+			WHERE schema=$1 AND migration=$2`, pq.QuoteIdentifier(s.schema)),
+		schema, migration)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[string]map[string]string)
+	for rows.Next() {
+		var table, name, original string
+		if err := rows.Scan(&table, &name, &original); err != nil {
+			return nil, err
+		}
+
+		if names[table] == nil {
+			names[table] = make(map[string]string)
+		}
+		names[table][name] = original
+	}
+
+	return names, rows.Err()
+}
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package state_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/xataio/pgroll/pkg/state"
+	"github.com/xataio/pgroll/pkg/testutils"
+)
+
+// BenchmarkReadSchema measures the cost of reading a schema with many tables,
+// each of them with several columns, indexes and constraints, either in full
+// or for a single table.
+func BenchmarkReadSchema(b *testing.B) {
+	for _, tables := range []int{100, 1000, 5000} {
+		tables := tables
+
+		testutils.WithStateAndConnectionToContainer(b, func(st *state.State, db *sql.DB) {
+			ctx := context.Background()
+
+			if err := createSyntheticSchema(ctx, db, tables); err != nil {
+				b.Fatal(err)
+			}
+
+			b.Run(fmt.Sprintf("%d tables/full", tables), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := st.ReadSchema(ctx, "public"); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+
+			b.Run(fmt.Sprintf("%d tables/one table", tables), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := st.ReadTables(ctx, "public", []string{"table_0"}); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+// createSyntheticSchema creates the given number of tables in the public
+// schema, each with a primary key, a foreign key to the previous table, a
+// check constraint, a unique constraint and an index.
+func createSyntheticSchema(ctx context.Context, db *sql.DB, tables int) error {
+	const batch = 100
+
+	for start := 0; start < tables; start += batch {
+		var stmts []string
+		for i := start; i < start+batch && i < tables; i++ {
+			references := ""
+			if i > 0 {
+				references = fmt.Sprintf("REFERENCES table_%d (id)", i-1)
+			}
+
+			stmts = append(stmts,
+				fmt.Sprintf(`CREATE TABLE table_%[1]d (
+					id serial PRIMARY KEY,
+					parent_id integer %[2]s,
+					name varchar(255) NOT NULL UNIQUE,
+					age integer CHECK (age > 0),
+					created_at timestamptz DEFAULT now()
+				)`, i, references),
+				fmt.Sprintf("CREATE INDEX table_%[1]d_created_at_idx ON table_%[1]d (created_at)", i),
+				fmt.Sprintf("COMMENT ON TABLE table_%[1]d IS 'table %[1]d'", i))
+		}
+
+		if _, err := db.ExecContext(ctx, strings.Join(stmts, ";\n")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package state
+
+import (
+	"context"
+
+	"github.com/xataio/pgroll/pkg/schema"
+)
+
+// SchemaCache holds the schema of a database schema as it was last read, so
+// that after changes to some of its tables only those tables have to be read
+// again.
+type SchemaCache struct {
+	state  *State
+	name   string
+	schema *schema.Schema
+}
+
+// NewSchemaCache returns an empty cache of the given schema
+func (s *State) NewSchemaCache(schemaName string) *SchemaCache {
+	return &SchemaCache{state: s, name: schemaName}
+}
+
+// NewSchemaCacheFrom returns a cache of the given schema, as already read
+func (s *State) NewSchemaCacheFrom(sc *schema.Schema) *SchemaCache {
+	return &SchemaCache{state: s, name: sc.Name, schema: sc}
+}
+
+// Read returns the cached schema, reading it from the database on first use.
+// The returned schema is shared with the cache.
+func (c *SchemaCache) Read(ctx context.Context) (*schema.Schema, error) {
+	if c.schema != nil {
+		return c.schema, nil
+	}
+	return c.Refresh(ctx, nil)
+}
+
+// Refresh reads the given tables again and returns the updated schema, which
+// no longer holds the tables that don't exist anymore. Types, sequences and
+// views are always read again, as changes to tables can change them too. If
+// tables is nil, or the schema wasn't read yet, the whole schema is read again.
+// Columns keep the logical positions they had in the cached schema.
+func (c *SchemaCache) Refresh(ctx context.Context, tables []string) (*schema.Schema, error) {
+	if c.schema == nil || tables == nil {
+		read, err := c.state.ReadSchema(ctx, c.name)
+		if err != nil {
+			return nil, err
+		}
+		if c.schema != nil {
+			read.CarryColumnPositions(c.schema)
+		}
+		c.schema = read
+		return c.schema, nil
+	}
+
+	read, err := c.state.ReadTables(ctx, c.name, tables)
+	if err != nil {
+		return nil, err
+	}
+	read.CarryColumnPositions(c.schema)
+
+	objects, err := c.state.readObjects(ctx, c.name)
+	if err != nil {
+		return nil, err
+	}
+	c.schema.Types = objects.Types
+	c.schema.Sequences = objects.Sequences
+	c.schema.Views = objects.Views
+
+	for _, name := range tables {
+		c.schema.RemoveTable(name)
+	}
+	for name, table := range read.Tables {
+		c.schema.AddTable(name, table)
+	}
+	return c.schema, nil
+}
@@ -48,8 +48,116 @@ ALTER TABLE %[1]s.migrations ADD COLUMN IF NOT EXISTS migration_type
   CONSTRAINT migration_type_check CHECK (migration_type IN ('pgroll', 'inferred')
 );
 
+-- Add a column to record how many of the migration's operations have been completed.
+-- Completion resumes from this point if a previous attempt was interrupted.
+ALTER TABLE %[1]s.migrations ADD COLUMN IF NOT EXISTS completed_operations INTEGER NOT NULL DEFAULT 0;
+
+-- Add a column to store the schema an interrupted migration was started from.
+-- It is only set while the start of the migration is interrupted and can be resumed.
+ALTER TABLE %[1]s.migrations ADD COLUMN IF NOT EXISTS interrupted_schema JSONB;
+
+-- Add a column to record how many of the operations of an interrupted migration
+-- had been started. A resumed start skips them.
+ALTER TABLE %[1]s.migrations ADD COLUMN IF NOT EXISTS started_operations INTEGER NOT NULL DEFAULT 0;
+
+-- Add a column to store the schema of the new version of a started migration.
+-- The logical positions of its columns are carried to the resulting schema on completion.
+ALTER TABLE %[1]s.migrations ADD COLUMN IF NOT EXISTS version_schema JSONB;
+
+-- Add a column to store the checksum of the migration's operations, used to
+-- detect migration files that were modified after being started.
+ALTER TABLE %[1]s.migrations ADD COLUMN IF NOT EXISTS checksum TEXT;
+
+-- Failed and rolled back migrations are moved out of the migrations table,
+-- so that they don't take part in the linear history of applied migrations,
+-- and kept here for reference.
+CREATE TABLE IF NOT EXISTS %[1]s.failed_migrations (
+	id					BIGSERIAL PRIMARY KEY,
+	schema				NAME NOT NULL,
+	name				TEXT NOT NULL,
+	migration			JSONB NOT NULL,
+	parent				TEXT,
+	status				VARCHAR(32) NOT NULL CONSTRAINT status_check CHECK (status IN ('failed', 'rolled back')),
+	phase				VARCHAR(32) CONSTRAINT phase_check CHECK (phase IN ('validation', 'start')),
+	error				TEXT,
+	started_at			TIMESTAMP NOT NULL,
+	ended_at			TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS failed_migrations_schema ON %[1]s.failed_migrations (schema, started_at);
+
+-- Progress of the backfills of the active migration, saved after each batch
+-- so that an interrupted backfill can resume where it stopped.
+CREATE TABLE IF NOT EXISTS %[1]s.backfill_checkpoints (
+	schema				NAME NOT NULL,
+	migration			TEXT NOT NULL,
+	operation			INTEGER NOT NULL,
+	table_name			TEXT NOT NULL,
+	partition			INTEGER NOT NULL,
+	last_key			JSONB,
+	upper_key			JSONB,
+	done				BOOLEAN NOT NULL DEFAULT false,
+	updated_at			TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+	PRIMARY KEY (schema, migration, operation, table_name, partition),
+	FOREIGN KEY (schema, migration) REFERENCES %[1]s.migrations(schema, name) ON DELETE CASCADE
+);
+
+-- Backfills required by the operations of the active migration. A migration
+-- can't be completed while any of its backfills hasn't finished, which is the
+-- case of backfills deferred when the migration was started.
+CREATE TABLE IF NOT EXISTS %[1]s.backfills (
+	schema				NAME NOT NULL,
+	migration			TEXT NOT NULL,
+	operation			INTEGER NOT NULL,
+	table_name			TEXT NOT NULL,
+	done				BOOLEAN NOT NULL DEFAULT false,
+	updated_at			TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+	PRIMARY KEY (schema, migration, operation, table_name),
+	FOREIGN KEY (schema, migration) REFERENCES %[1]s.migrations(schema, name) ON DELETE CASCADE
+);
+
+-- Original names of the objects that pgroll duplicated during the active
+-- migration, for the duplicates whose names had to be shortened. They are
+-- used to restore the original names when the migration is completed.
+CREATE TABLE IF NOT EXISTS %[1]s.generated_names (
+	schema				NAME NOT NULL,
+	migration			TEXT NOT NULL,
+	table_name			TEXT NOT NULL,
+	name				TEXT NOT NULL,
+	original			TEXT NOT NULL,
+
+	PRIMARY KEY (schema, migration, table_name, name),
+	FOREIGN KEY (schema, migration) REFERENCES %[1]s.migrations(schema, name) ON DELETE CASCADE
+);
+
 -- Helper functions
 
+-- Get the name of an object derived from the name of another object by adding
+-- a prefix. Names that don't fit in an identifier are shortened and end with
+-- a hash of the full name (see migrations.Identifier).
+CREATE OR REPLACE FUNCTION %[1]s.identifier(prefix text, name text) RETURNS text
+AS $$
+DECLARE
+	full_name text := prefix || name;
+	suffix text;
+BEGIN
+	IF pg_catalog.octet_length(full_name) <= 63 THEN
+		RETURN full_name;
+	END IF;
+
+	suffix := '_' || pg_catalog.left(pg_catalog.encode(pg_catalog.sha256(pg_catalog.convert_to(full_name, 'UTF8')), 'hex'), 8);
+	WHILE pg_catalog.octet_length(full_name) > 63 - pg_catalog.octet_length(suffix) LOOP
+		full_name := pg_catalog.left(full_name, -1);
+	END LOOP;
+
+	RETURN full_name || suffix;
+END;
+$$
+LANGUAGE PLPGSQL
+IMMUTABLE;
+
 -- Are we in the middle of a migration?
 CREATE OR REPLACE FUNCTION %[1]s.is_active_migration_period(schemaname NAME) RETURNS boolean
 	AS $$ SELECT EXISTS (SELECT 1 FROM %[1]s.migrations WHERE schema=schemaname AND done=false) $$
@@ -90,169 +198,394 @@ $$
 LANGUAGE SQL
 STABLE;
 
--- Get the JSON representation of the current schema
-CREATE OR REPLACE FUNCTION %[1]s.read_schema(schemaname text) RETURNS jsonb
-LANGUAGE plpgsql AS $$
-DECLARE
-	tables jsonb;
-BEGIN
-	SELECT json_build_object(
-		'name', schemaname,
-		'tables', (
-			SELECT json_object_agg(t.relname, jsonb_build_object(
-				'name', t.relname,
-				'oid', t.oid,
-				'comment', descr.description,
-				'columns', (
-					SELECT json_object_agg(name, c) FROM (
-						SELECT
-							attr.attname AS name,
-							pg_get_expr(def.adbin, def.adrelid) AS default,
-							NOT (
-								attr.attnotnull
-								OR tp.typtype = 'd'
-								AND tp.typnotnull
-							) AS nullable,
-							CASE
-								WHEN 'character varying' :: regtype = ANY(ARRAY [attr.atttypid, tp.typelem]) THEN REPLACE(
-									format_type(attr.atttypid, attr.atttypmod),
-									'character varying',
-									'varchar'
-								)
-								WHEN 'timestamp with time zone' :: regtype = ANY(ARRAY [attr.atttypid, tp.typelem]) THEN REPLACE(
-									format_type(attr.atttypid, attr.atttypmod),
-									'timestamp with time zone',
-									'timestamptz'
-								)
-								ELSE format_type(attr.atttypid, attr.atttypmod)
-							END AS type,
-							descr.description AS comment,
-							(EXISTS (
-								SELECT 1
-								FROM pg_constraint
-								WHERE conrelid = attr.attrelid
-								AND conkey::int[] @> ARRAY[attr.attnum::int]
-								AND contype = 'u'
-							) OR EXISTS (
-								SELECT 1
-								FROM pg_index
-								JOIN pg_class ON pg_class.oid = pg_index.indexrelid
-								WHERE indrelid = attr.attrelid
-								AND indisunique
-								AND pg_index.indkey::int[] @> ARRAY[attr.attnum::int]
-							)) AS unique
-						FROM
-							pg_attribute AS attr
-							INNER JOIN pg_type AS tp ON attr.atttypid = tp.oid
-							LEFT JOIN pg_attrdef AS def ON attr.attrelid = def.adrelid
-							AND attr.attnum = def.adnum
-							LEFT JOIN pg_description AS descr ON attr.attrelid = descr.objoid
-							AND attr.attnum = descr.objsubid
-						WHERE
-							attr.attnum > 0
-							AND NOT attr.attisdropped
-							AND attr.attrelid = t.oid
-						ORDER BY
-							attr.attnum
-					) c
-				),
-				'primaryKey', (
-					SELECT json_agg(pg_attribute.attname) AS primary_key_columns
-					FROM pg_index, pg_attribute
-					WHERE
-						indrelid = t.oid AND
-						nspname = schemaname AND
-						pg_attribute.attrelid = t.oid AND
-						pg_attribute.attnum = any(pg_index.indkey)
-						AND indisprimary
+-- Get the SQL for the action of a foreign key from its pg_constraint code
+CREATE OR REPLACE FUNCTION %[1]s.foreign_key_action(action "char") RETURNS text
+LANGUAGE sql IMMUTABLE AS $$
+	SELECT CASE action
+		WHEN 'a' THEN 'NO ACTION'
+		WHEN 'r' THEN 'RESTRICT'
+		WHEN 'c' THEN 'CASCADE'
+		WHEN 'n' THEN 'SET NULL'
+		WHEN 'd' THEN 'SET DEFAULT'
+	END
+$$;
+
+-- Get the JSON representation of the given tables of a schema, or of all of
+-- its tables if tablenames is NULL. Each catalog is scanned once for all the
+-- tables, so that reading a schema scales linearly with its number of tables.
+CREATE OR REPLACE FUNCTION %[1]s.read_schema_tables(schemaname text, tablenames text[]) RETURNS jsonb
+LANGUAGE sql STABLE AS $$
+	WITH schema_tables AS (
+		SELECT
+			t.oid,
+			t.relname,
+			descr.description,
+			CASE WHEN t.relkind = 'p' THEN pg_get_partkeydef(t.oid) END AS partition_key,
+			parent.relname AS partition_of,
+			pg_get_expr(t.relpartbound, t.oid) AS partition_bound
+		FROM pg_class AS t
+			INNER JOIN pg_namespace AS ns ON t.relnamespace = ns.oid
+			LEFT JOIN pg_description AS descr ON t.oid = descr.objoid
+			AND descr.classoid = 'pg_class' :: regclass
+			AND descr.objsubid = 0
+			LEFT JOIN pg_inherits AS inh ON t.relispartition
+			AND inh.inhrelid = t.oid
+			LEFT JOIN pg_class AS parent ON inh.inhparent = parent.oid
+		WHERE
+			ns.nspname = schemaname
+			AND t.relkind IN ('r', 'p') -- tables only (ignores views, materialized views & foreign tables)
+			AND (tablenames IS NULL OR t.relname = ANY(tablenames))
+	),
+	-- columns that are part of a unique constraint or a unique index
+	unique_columns AS (
+		SELECT con.conrelid AS attrelid, k.attnum
+		FROM pg_constraint AS con
+			INNER JOIN schema_tables AS t ON con.conrelid = t.oid
+			CROSS JOIN unnest(con.conkey) AS k(attnum)
+		WHERE con.contype = 'u'
+		UNION
+		SELECT pi.indrelid, k.attnum
+		FROM pg_index AS pi
+			INNER JOIN schema_tables AS t ON pi.indrelid = t.oid
+			CROSS JOIN unnest(pi.indkey :: int2[]) AS k(attnum)
+		WHERE pi.indisunique
+	),
+	table_columns AS (
+		SELECT attr.attrelid, json_object_agg(attr.attname, json_build_object(
+			'name', attr.attname,
+			'default', CASE WHEN attr.attgenerated = '' THEN pg_get_expr(def.adbin, def.adrelid) END,
+			'generated', CASE WHEN attr.attgenerated <> '' THEN pg_get_expr(def.adbin, def.adrelid) END,
+			'identity', CASE attr.attidentity
+				WHEN 'a' THEN 'always'
+				WHEN 'd' THEN 'by default'
+			END,
+			'nullable', NOT (
+				attr.attnotnull
+				OR tp.typtype = 'd'
+				AND tp.typnotnull
+			),
+			'type', CASE
+				WHEN 'character varying' :: regtype = ANY(ARRAY [attr.atttypid, tp.typelem]) THEN REPLACE(
+					format_type(attr.atttypid, attr.atttypmod),
+					'character varying',
+					'varchar'
+				)
+				WHEN 'timestamp with time zone' :: regtype = ANY(ARRAY [attr.atttypid, tp.typelem]) THEN REPLACE(
+					format_type(attr.atttypid, attr.atttypmod),
+					'timestamp with time zone',
+					'timestamptz'
+				)
+				ELSE format_type(attr.atttypid, attr.atttypmod)
+			END,
+			'comment', descr.description,
+			'unique', uc.attnum IS NOT NULL,
+			'position', attr.attnum,
+			-- the collation and storage are only given if they differ from the
+			-- defaults of the column's type
+			'collation', CASE WHEN attr.attcollation <> tp.typcollation THEN attr.attcollation :: regcollation :: text END,
+			'storage', CASE WHEN attr.attstorage <> tp.typstorage THEN CASE attr.attstorage
+				WHEN 'p' THEN 'PLAIN'
+				WHEN 'e' THEN 'EXTERNAL'
+				WHEN 'm' THEN 'MAIN'
+				WHEN 'x' THEN 'EXTENDED'
+			END END,
+			-- the default statistics target is -1 before postgres 17, and NULL after
+			'statisticsTarget', NULLIF(attr.attstattarget, -1),
+			'privileges', (
+				SELECT json_agg(json_build_object(
+					'grantee', CASE WHEN acl.grantee = 0 THEN 'PUBLIC' ELSE pg_get_userbyid(acl.grantee) END,
+					'privilege', acl.privilege_type,
+					'grantable', acl.is_grantable
+				) ORDER BY acl.grantee, acl.privilege_type)
+				FROM aclexplode(attr.attacl) AS acl
+			)
+		) ORDER BY attr.attnum) AS columns
+		FROM pg_attribute AS attr
+			INNER JOIN schema_tables AS t ON attr.attrelid = t.oid
+			INNER JOIN pg_type AS tp ON attr.atttypid = tp.oid
+			LEFT JOIN pg_attrdef AS def ON attr.attrelid = def.adrelid
+			AND attr.attnum = def.adnum
+			LEFT JOIN pg_description AS descr ON attr.attrelid = descr.objoid
+			AND descr.classoid = 'pg_class' :: regclass
+			AND attr.attnum = descr.objsubid
+			LEFT JOIN unique_columns AS uc ON attr.attrelid = uc.attrelid
+			AND attr.attnum = uc.attnum
+		WHERE
+			attr.attnum > 0
+			AND NOT attr.attisdropped
+		GROUP BY attr.attrelid
+	),
+	primary_keys AS (
+		SELECT pi.indrelid, json_agg(attr.attname ORDER BY k.ord) AS columns
+		FROM pg_index AS pi
+			INNER JOIN schema_tables AS t ON pi.indrelid = t.oid
+			CROSS JOIN unnest(pi.indkey :: int2[]) WITH ORDINALITY AS k(attnum, ord)
+			INNER JOIN pg_attribute AS attr ON attr.attrelid = pi.indrelid
+			AND attr.attnum = k.attnum
+		WHERE pi.indisprimary
+		GROUP BY pi.indrelid
+	),
+	indexes AS (
+		SELECT ix_details.indrelid, json_object_agg(ix_details.indexrelid :: regclass, json_build_object(
+			'name', ix_details.indexrelid :: regclass,
+			'unique', ix_details.indisunique,
+			'columns', ix_details.columns,
+			'predicate', ix_details.predicate,
+			'method', am.amname,
+			'include', ix_details.include,
+			'sortOrders', ix_details.sort_orders,
+			'definition', pg_get_indexdef(ix_details.indexrelid)
+		)) AS indexes
+		FROM (
+			SELECT
+				pi.indrelid,
+				pi.indexrelid,
+				pi.indisunique,
+				-- index expressions are listed by their definition
+				array_agg(COALESCE(a.attname, pg_get_indexdef(pi.indexrelid, k.ord :: int, true)) ORDER BY k.ord)
+					FILTER (WHERE k.ord <= pi.indnkeyatts) AS columns,
+				array_agg(a.attname ORDER BY k.ord) FILTER (WHERE k.ord > pi.indnkeyatts) AS include,
+				-- the sort orders are only listed if any key column isn't in the
+				-- default ascending order
+				CASE WHEN bool_or(k.option <> 0) THEN
+					array_agg(CASE k.option
+						WHEN 0 THEN 'ASC'
+						WHEN 1 THEN 'DESC NULLS LAST'
+						WHEN 2 THEN 'ASC NULLS FIRST'
+						WHEN 3 THEN 'DESC'
+					END ORDER BY k.ord) FILTER (WHERE k.ord <= pi.indnkeyatts)
+				END AS sort_orders,
+				pg_get_expr(pi.indpred, pi.indrelid) AS predicate
+			FROM pg_index AS pi
+				INNER JOIN schema_tables AS t ON pi.indrelid = t.oid
+				-- the options are only given for key columns
+				CROSS JOIN unnest(pi.indkey :: int2[], pi.indoption :: int2[]) WITH ORDINALITY AS k(attnum, option, ord)
+				LEFT JOIN pg_attribute AS a ON a.attrelid = pi.indrelid
+				AND a.attnum = k.attnum
+				AND k.attnum <> 0
+			GROUP BY pi.indexrelid, pi.indisunique, pi.indpred, pi.indrelid, pi.indnkeyatts
+		) AS ix_details
+			INNER JOIN pg_class AS ix_cl ON ix_cl.oid = ix_details.indexrelid
+			INNER JOIN pg_am AS am ON am.oid = ix_cl.relam
+		GROUP BY ix_details.indrelid
+	),
+	-- the columns of check, unique and foreign key constraints, in the order
+	-- of the constraint's definition
+	constraint_columns AS (
+		SELECT
+			con.oid,
+			con.conrelid,
+			con.conname,
+			con.contype,
+			array_agg(attr.attname ORDER BY k.ord) AS columns,
+			array_agg(ref_attr.attname ORDER BY k.ord) AS referenced_columns
+		FROM pg_constraint AS con
+			INNER JOIN schema_tables AS t ON con.conrelid = t.oid
+			CROSS JOIN unnest(con.conkey, con.confkey) WITH ORDINALITY AS k(attnum, refattnum, ord)
+			INNER JOIN pg_attribute AS attr ON attr.attrelid = con.conrelid
+			AND attr.attnum = k.attnum
+			LEFT JOIN pg_attribute AS ref_attr ON ref_attr.attrelid = con.confrelid
+			AND ref_attr.attnum = k.refattnum
+		WHERE con.contype IN ('c', 'u', 'f')
+		GROUP BY con.oid, con.conrelid, con.conname, con.contype
+	),
+	check_constraints AS (
+		SELECT cc.conrelid, json_object_agg(cc.conname, json_build_object(
+			'name', cc.conname,
+			'columns', cc.columns,
+			'definition', pg_get_constraintdef(cc.oid)
+		)) AS constraints
+		FROM constraint_columns AS cc
+		WHERE cc.contype = 'c'
+		GROUP BY cc.conrelid
+	),
+	unique_constraints AS (
+		SELECT uc.conrelid, json_object_agg(uc.conname, json_build_object(
+			'name', uc.conname,
+			'columns', uc.columns
+		)) AS constraints
+		FROM constraint_columns AS uc
+		WHERE uc.contype = 'u'
+		GROUP BY uc.conrelid
+	),
+	foreign_keys AS (
+		SELECT fk.conrelid, json_object_agg(fk.conname, json_build_object(
+			'name', fk.conname,
+			'columns', fk.columns,
+			'referencedTable', fk_cl.relname,
+			'referencedColumns', fk.referenced_columns,
+			'onDelete', %[1]s.foreign_key_action(con.confdeltype),
+			'onUpdate', %[1]s.foreign_key_action(con.confupdtype),
+			'deferrable', con.condeferrable,
+			'initiallyDeferred', con.condeferred,
+			'definition', pg_get_constraintdef(con.oid)
+		)) AS constraints
+		FROM constraint_columns AS fk
+			INNER JOIN pg_constraint AS con ON con.oid = fk.oid
+			INNER JOIN pg_class AS fk_cl ON con.confrelid = fk_cl.oid
+		WHERE fk.contype = 'f'
+		GROUP BY fk.conrelid
+	)
+	SELECT jsonb_object_agg(t.relname, jsonb_build_object(
+		'name', t.relname,
+		'oid', t.oid,
+		'comment', t.description,
+		'partitionKey', t.partition_key,
+		'partitionOf', t.partition_of,
+		'partitionBound', t.partition_bound,
+		'columns', c.columns,
+		'primaryKey', pk.columns,
+		'indexes', ix.indexes,
+		'checkConstraints', cc.constraints,
+		'uniqueConstraints', uc.constraints,
+		'foreignKeys', fk.constraints
+	))
+	FROM schema_tables AS t
+		LEFT JOIN table_columns AS c ON c.attrelid = t.oid
+		LEFT JOIN primary_keys AS pk ON pk.indrelid = t.oid
+		LEFT JOIN indexes AS ix ON ix.indrelid = t.oid
+		LEFT JOIN check_constraints AS cc ON cc.conrelid = t.oid
+		LEFT JOIN unique_constraints AS uc ON uc.conrelid = t.oid
+		LEFT JOIN foreign_keys AS fk ON fk.conrelid = t.oid
+$$;
+
+-- Get the JSON representation of the objects of a schema other than tables:
+-- the enum, domain, composite, range and base types, the sequences and the views
+CREATE OR REPLACE FUNCTION %[1]s.read_schema_objects(schemaname text) RETURNS jsonb
+LANGUAGE sql STABLE AS $$
+	SELECT jsonb_build_object(
+		'types', (
+			SELECT jsonb_object_agg(tp.typname, jsonb_build_object(
+				'name', tp.typname,
+				'kind', CASE tp.typtype
+					WHEN 'e' THEN 'enum'
+					WHEN 'd' THEN 'domain'
+					WHEN 'c' THEN 'composite'
+					WHEN 'r' THEN 'range'
+					WHEN 'b' THEN 'base'
+				END,
+				'values', (
+					SELECT jsonb_agg(e.enumlabel ORDER BY e.enumsortorder)
+					FROM pg_enum AS e
+					WHERE e.enumtypid = tp.oid
 				),
-				'indexes', (
-				  SELECT json_object_agg(ix_details.indexrelid::regclass, json_build_object(
-				    'name', ix_details.indexrelid::regclass,
-				    'unique', ix_details.indisunique,
-				    'columns', ix_details.columns
-				  ))
-				  FROM (
-				    SELECT 
-				      pi.indexrelid, 
-				      pi.indisunique,
-				      array_agg(a.attname) AS columns
-				    FROM pg_index pi
-				    JOIN pg_attribute a ON a.attrelid = pi.indrelid AND a.attnum = ANY(pi.indkey)
-				    WHERE indrelid = t.oid::regclass
-				    GROUP BY pi.indexrelid, pi.indisunique
-				  ) as ix_details
+				'baseType', CASE WHEN tp.typtype = 'd' THEN format_type(tp.typbasetype, tp.typtypmod) END,
+				'notNull', tp.typnotnull,
+				'default', tp.typdefault,
+				'checks', (
+					SELECT jsonb_agg(pg_get_constraintdef(con.oid) ORDER BY con.conname)
+					FROM pg_constraint AS con
+					WHERE con.contypid = tp.oid
+					AND con.contype = 'c'
 				),
-				'checkConstraints', (
-					SELECT json_object_agg(cc_details.conname, json_build_object(
-						'name', cc_details.conname,
-						'columns', cc_details.columns,
-						'definition', cc_details.definition
-					))
-					FROM (
-						SELECT
-							cc_constraint.conname,
-							array_agg(cc_attr.attname ORDER BY cc_constraint.conkey::int[]) AS columns,
-							pg_get_constraintdef(cc_constraint.oid) AS definition
-						FROM pg_constraint AS cc_constraint
-						INNER JOIN pg_attribute cc_attr ON cc_attr.attrelid = cc_constraint.conrelid AND cc_attr.attnum = ANY(cc_constraint.conkey)
-						WHERE cc_constraint.conrelid = t.oid
-						AND cc_constraint.contype = 'c'
-						GROUP BY cc_constraint.oid, cc_constraint.conname
-					) AS cc_details
-        ),
-				'uniqueConstraints', (
-					SELECT json_object_agg(uc_details.conname, json_build_object(
-						'name', uc_details.conname,
-						'columns', uc_details.columns
-					))
-					FROM (
-						SELECT
-							uc_constraint.conname,
-							array_agg(uc_attr.attname ORDER BY uc_constraint.conkey::int[]) AS columns,
-							pg_get_constraintdef(uc_constraint.oid) AS definition
-						FROM pg_constraint AS uc_constraint
-						INNER JOIN pg_attribute uc_attr ON uc_attr.attrelid = uc_constraint.conrelid AND uc_attr.attnum = ANY(uc_constraint.conkey)
-						WHERE uc_constraint.conrelid = t.oid
-						AND uc_constraint.contype = 'u'
-						GROUP BY uc_constraint.oid, uc_constraint.conname
-					) AS uc_details
-        ),
-				'foreignKeys', (
-					SELECT json_object_agg(fk_details.conname, json_build_object(
-						'name', fk_details.conname,
-						'columns', fk_details.columns,
-						'referencedTable', fk_details.referencedTable,
-						'referencedColumns', fk_details.referencedColumns
-					))
-					FROM (
-						SELECT
-							fk_constraint.conname,
-							array_agg(fk_attr.attname ORDER BY fk_constraint.conkey::int[]) AS columns,
-							fk_cl.relname AS referencedTable,
-							array_agg(ref_attr.attname ORDER BY fk_constraint.confkey::int[]) AS referencedColumns
-						FROM pg_constraint AS fk_constraint
-						INNER JOIN pg_class fk_cl ON fk_constraint.confrelid = fk_cl.oid
-						INNER JOIN pg_attribute fk_attr ON fk_attr.attrelid = fk_constraint.conrelid AND fk_attr.attnum = ANY(fk_constraint.conkey)
-						INNER JOIN pg_attribute ref_attr ON ref_attr.attrelid = fk_constraint.confrelid AND ref_attr.attnum = ANY(fk_constraint.confkey)
-						WHERE fk_constraint.conrelid = t.oid
-						AND fk_constraint.contype = 'f'
-						GROUP BY fk_constraint.conname, fk_cl.relname
-					) AS fk_details
+				'attributes', (
+					SELECT jsonb_agg(jsonb_build_object(
+						'name', attr.attname,
+						'type', format_type(attr.atttypid, attr.atttypmod)
+					) ORDER BY attr.attnum)
+					FROM pg_attribute AS attr
+					WHERE attr.attrelid = tp.typrelid
+					AND attr.attnum > 0
+					AND NOT attr.attisdropped
 				)
-			)) FROM pg_class AS t
-				INNER JOIN pg_namespace AS ns ON t.relnamespace = ns.oid
-				LEFT JOIN pg_description AS descr ON t.oid = descr.objoid
-				AND descr.objsubid = 0
+			))
+			FROM pg_type AS tp
+				INNER JOIN pg_namespace AS ns ON tp.typnamespace = ns.oid
+				LEFT JOIN pg_class AS cl ON tp.typrelid = cl.oid
 			WHERE
 				ns.nspname = schemaname
-				AND t.relkind IN ('r', 'p') -- tables only (ignores views, materialized views & foreign tables)
-			)
+				-- the row types of tables and views, and the array types, are not
+				-- listed
+				AND (tp.typtype IN ('e', 'd', 'r') OR tp.typtype = 'b' AND tp.typcategory <> 'A' OR tp.typtype = 'c' AND cl.relkind = 'c')
+		),
+		'sequences', (
+			SELECT jsonb_object_agg(cl.relname, jsonb_build_object(
+				'name', cl.relname,
+				'type', format_type(seq.seqtypid, NULL),
+				'start', seq.seqstart,
+				'increment', seq.seqincrement,
+				'min', seq.seqmin,
+				'max', seq.seqmax,
+				'cycle', seq.seqcycle,
+				'ownerTable', owner.relname,
+				'ownerColumn', owner_attr.attname
+			))
+			FROM pg_sequence AS seq
+				INNER JOIN pg_class AS cl ON seq.seqrelid = cl.oid
+				INNER JOIN pg_namespace AS ns ON cl.relnamespace = ns.oid
+				-- the column that owns the sequence, or the identity column it belongs to
+				LEFT JOIN pg_depend AS dep ON dep.classid = 'pg_class' :: regclass
+				AND dep.objid = cl.oid
+				AND dep.refclassid = 'pg_class' :: regclass
+				AND dep.deptype IN ('a', 'i')
+				LEFT JOIN pg_class AS owner ON dep.refobjid = owner.oid
+				LEFT JOIN pg_attribute AS owner_attr ON dep.refobjid = owner_attr.attrelid
+				AND dep.refobjsubid = owner_attr.attnum
+			WHERE ns.nspname = schemaname
+		),
+		'views', (
+			SELECT jsonb_object_agg(cl.relname, jsonb_build_object(
+				'name', cl.relname,
+				'materialized', cl.relkind = 'm',
+				'definition', pg_get_viewdef(cl.oid)
+			))
+			FROM pg_class AS cl
+				INNER JOIN pg_namespace AS ns ON cl.relnamespace = ns.oid
+			WHERE
+				ns.nspname = schemaname
+				AND cl.relkind IN ('v', 'm')
 		)
-	INTO tables;
+	)
+$$;
 
-	RETURN tables;
-END;
+-- Give the columns of the tables of a schema the logical positions of the
+-- columns with the same names in the same tables of a previous schema, as the
+-- positions read from the catalog change when columns are replaced by
+-- duplicates. Other columns are positioned after them, in their current order.
+CREATE OR REPLACE FUNCTION %[1]s.carry_column_positions(live jsonb, recorded jsonb) RETURNS jsonb
+LANGUAGE sql IMMUTABLE AS $$
+	SELECT CASE
+		WHEN recorded IS NULL OR jsonb_typeof(live->'tables') IS DISTINCT FROM 'object' THEN live
+		ELSE live || jsonb_build_object('tables', COALESCE((
+			SELECT jsonb_object_agg(t.key, CASE
+				WHEN recorded->'tables'->t.key IS NULL THEN t.value
+				ELSE t.value || jsonb_build_object('columns', (
+					SELECT jsonb_object_agg(c.key, c.value || jsonb_build_object('position', c.position))
+					FROM (
+						SELECT
+							col.key,
+							col.value,
+							COALESCE(
+								col.recorded_position,
+								col.last_position + row_number() OVER (PARTITION BY col.recorded_position IS NULL ORDER BY col.live_position, col.key)
+							) AS position
+						FROM (
+							SELECT
+								cur.key,
+								cur.value,
+								(cur.value->>'position')::int AS live_position,
+								NULLIF((recorded->'tables'->t.key->'columns'->cur.key->>'position')::int, 0) AS recorded_position,
+								(
+									SELECT COALESCE(max((p.value->>'position')::int), 0)
+									FROM jsonb_each(COALESCE(NULLIF(recorded->'tables'->t.key->'columns', 'null'), '{}')) AS p
+								) AS last_position
+							FROM jsonb_each(COALESCE(NULLIF(t.value->'columns', 'null'), '{}')) AS cur
+						) AS col
+					) AS c
+				))
+			END)
+			FROM jsonb_each(live->'tables') AS t
+		), '{}'::jsonb))
+	END
+$$;
+
+-- Get the JSON representation of the current schema
+CREATE OR REPLACE FUNCTION %[1]s.read_schema(schemaname text) RETURNS jsonb
+LANGUAGE sql STABLE AS $$
+	SELECT jsonb_build_object(
+		'name', schemaname,
+		'tables', %[1]s.read_schema_tables(schemaname, NULL)
+	) || %[1]s.read_schema_objects(schemaname)
 $$;
 
 CREATE OR REPLACE FUNCTION %[1]s.raw_migration() RETURNS event_trigger
@@ -309,7 +642,10 @@ BEGIN
 				)
 			)
 		),
-		%[1]s.read_schema(schemaname),
+		%[1]s.carry_column_positions(
+			%[1]s.read_schema(schemaname),
+			(SELECT resulting_schema FROM %[1]s.migrations WHERE schema=schemaname AND name=%[1]s.latest_version(schemaname))
+		),
 		true,
 		%[1]s.latest_version(schemaname),
 		'inferred'
@@ -467,10 +803,15 @@ func (s *State) Start(ctx context.Context, schemaname string, migration *migrati
 		return nil, fmt.Errorf("unable to marshal migration: %w", err)
 	}
 
+	checksum, err := migration.Checksum()
+	if err != nil {
+		return nil, fmt.Errorf("unable to compute migration checksum: %w", err)
+	}
+
 	// create a new migration object and return the previous known schema
 	// if there is no previous migration, read the schema from postgres
 	stmt := fmt.Sprintf(`
-		INSERT INTO %[1]s.migrations (schema, name, parent, migration) VALUES ($1, $2, %[1]s.latest_version($1), $3)
+		INSERT INTO %[1]s.migrations (schema, name, parent, migration, checksum) VALUES ($1, $2, %[1]s.latest_version($1), $3, $4)
 		RETURNING (
 			SELECT COALESCE(
 				(SELECT resulting_schema FROM %[1]s.migrations WHERE schema=$1 AND name=%[1]s.latest_version($1)),
@@ -478,7 +819,7 @@ func (s *State) Start(ctx context.Context, schemaname string, migration *migrati
 		)`, pq.QuoteIdentifier(s.schema))
 
 	var rawSchema string
-	err = s.pgConn.QueryRowContext(ctx, stmt, schemaname, migration.Name, rawMigration).Scan(&rawSchema)
+	err = s.pgConn.QueryRowContext(ctx, stmt, schemaname, migration.Name, rawMigration, checksum).Scan(&rawSchema)
 	if err != nil {
 		return nil, err
 	}
@@ -492,9 +833,155 @@ func (s *State) Start(ctx context.Context, schemaname string, migration *migrati
 	return &schema, nil
 }
 
-// Complete marks a migration as completed
-func (s *State) Complete(ctx context.Context, schema, name string) error {
-	res, err := s.pgConn.ExecContext(ctx, fmt.Sprintf("UPDATE %[1]s.migrations SET done=$1, resulting_schema=(SELECT %[1]s.read_schema($2)) WHERE schema=$2 AND name=$3 AND done=$4", pq.QuoteIdentifier(s.schema)), true, schema, name, false)
+// VerifyChecksum checks that the given migration has the same checksum as the
+// migration with the same name in the history of the schema, if any. It
+// returns a `ChecksumMismatchError` if the migration was modified since it was
+// started. Migrations that were never started, or that were started before
+// checksums were recorded, are considered valid.
+func (s *State) VerifyChecksum(ctx context.Context, schema string, migration *migrations.Migration) error {
+	var expected sql.NullString
+	err := s.pgConn.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT checksum FROM %s.migrations WHERE schema=$1 AND name=$2", pq.QuoteIdentifier(s.schema)),
+		schema, migration.Name).Scan(&expected)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	if !expected.Valid {
+		return nil
+	}
+
+	actual, err := migration.Checksum()
+	if err != nil {
+		return fmt.Errorf("unable to compute migration checksum: %w", err)
+	}
+
+	if actual != expected.String {
+		return ChecksumMismatchError{
+			Name:     migration.Name,
+			Expected: expected.String,
+			Actual:   actual,
+		}
+	}
+
+	return nil
+}
+
+// InterruptStart records that starting the active migration was interrupted
+// after the first `started` of its operations were started. The schema as left
+// by those operations is stored so that the start can be resumed from the next
+// operation with `ResumeStart`.
+func (s *State) InterruptStart(ctx context.Context, schema, name string, resumeSchema *schema.Schema, started int) error {
+	res, err := s.pgConn.ExecContext(ctx,
+		fmt.Sprintf("UPDATE %s.migrations SET interrupted_schema=$1, started_operations=$2, updated_at=CURRENT_TIMESTAMP WHERE schema=$3 AND name=$4 AND done=$5", pq.QuoteIdentifier(s.schema)),
+		resumeSchema, started, schema, name, false)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("no migration found with name %s", name)
+	}
+
+	return nil
+}
+
+// SaveVersionSchema records the schema of the new version of the active
+// migration with the given name, once all of its operations are started.
+func (s *State) SaveVersionSchema(ctx context.Context, schema, name string, versionSchema *schema.Schema) error {
+	res, err := s.pgConn.ExecContext(ctx,
+		fmt.Sprintf("UPDATE %s.migrations SET version_schema=$1, updated_at=CURRENT_TIMESTAMP WHERE schema=$2 AND name=$3 AND done=$4", pq.QuoteIdentifier(s.schema)),
+		versionSchema, schema, name, false)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("no migration found with name %s", name)
+	}
+
+	return nil
+}
+
+// VersionSchema returns the schema of the new version of the migration with
+// the given name, as recorded by `SaveVersionSchema`, or nil if it wasn't
+// recorded.
+func (s *State) VersionSchema(ctx context.Context, schemaname, name string) (*schema.Schema, error) {
+	var rawSchema []byte
+	err := s.pgConn.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT version_schema FROM %s.migrations WHERE schema=$1 AND name=$2", pq.QuoteIdentifier(s.schema)),
+		schemaname, name).Scan(&rawSchema)
+	if err != nil {
+		return nil, err
+	}
+	if rawSchema == nil {
+		return nil, nil
+	}
+
+	var sc schema.Schema
+	if err := json.Unmarshal(rawSchema, &sc); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal schema: %w", err)
+	}
+
+	return &sc, nil
+}
+
+// ResumeStart clears the interruption of the active migration with the given
+// name and returns the schema to resume its start from, along with the number
+// of its operations that were already started.
+// It returns `ErrNoInterruptedMigration` if there is no such migration.
+func (s *State) ResumeStart(ctx context.Context, schemaname, name string) (*schema.Schema, int, error) {
+	var rawSchema []byte
+	var started int
+	err := s.pgConn.QueryRowContext(ctx,
+		fmt.Sprintf(`UPDATE %[1]s.migrations m SET interrupted_schema=NULL, updated_at=CURRENT_TIMESTAMP
+			FROM (SELECT schema, name, interrupted_schema, started_operations FROM %[1]s.migrations WHERE schema=$1 AND name=$2 AND done=$3 AND interrupted_schema IS NOT NULL FOR UPDATE) prev
+			WHERE m.schema=prev.schema AND m.name=prev.name
+			RETURNING prev.interrupted_schema, prev.started_operations`, pq.QuoteIdentifier(s.schema)),
+		schemaname, name, false).Scan(&rawSchema, &started)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, 0, ErrNoInterruptedMigration
+		}
+		return nil, 0, err
+	}
+
+	var sc schema.Schema
+	err = json.Unmarshal(rawSchema, &sc)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to unmarshal schema: %w", err)
+	}
+
+	return &sc, started, nil
+}
+
+// Complete marks a migration as completed and clears its backfills, their
+// checkpoints and the original names of its duplicated objects. The resulting
+// schema of the migration is read from the database unless it is given.
+func (s *State) Complete(ctx context.Context, schema, name string, resulting *schema.Schema) error {
+	res, err := s.pgConn.ExecContext(ctx, fmt.Sprintf(`
+		WITH checkpoints AS (
+			DELETE FROM %[1]s.backfill_checkpoints WHERE schema=$2 AND migration=$3
+		), backfills AS (
+			DELETE FROM %[1]s.backfills WHERE schema=$2 AND migration=$3
+		), names AS (
+			DELETE FROM %[1]s.generated_names WHERE schema=$2 AND migration=$3
+		)
+		UPDATE %[1]s.migrations SET done=$1, resulting_schema=COALESCE($5::jsonb, %[1]s.read_schema($2)) WHERE schema=$2 AND name=$3 AND done=$4`,
+		pq.QuoteIdentifier(s.schema)), true, schema, name, false, resulting)
 	if err != nil {
 		return err
 	}
@@ -511,6 +998,45 @@ func (s *State) Complete(ctx context.Context, schema, name string) error {
 	return err
 }
 
+// CompletedOperations returns the number of operations of the active
+// migration that have already been completed
+func (s *State) CompletedOperations(ctx context.Context, schema, name string) (int, error) {
+	var completed int
+	err := s.pgConn.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT completed_operations FROM %s.migrations WHERE schema=$1 AND name=$2 AND done=$3", pq.QuoteIdentifier(s.schema)),
+		schema, name, false).Scan(&completed)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNoActiveMigration
+		}
+		return 0, err
+	}
+
+	return completed, nil
+}
+
+// CompleteOperation records that the operation at the given index of the
+// active migration has been completed, along with all operations before it
+func (s *State) CompleteOperation(ctx context.Context, schema, name string, index int) error {
+	res, err := s.pgConn.ExecContext(ctx,
+		fmt.Sprintf("UPDATE %s.migrations SET completed_operations=$1, updated_at=CURRENT_TIMESTAMP WHERE schema=$2 AND name=$3 AND done=$4", pq.QuoteIdentifier(s.schema)),
+		index+1, schema, name, false)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("no migration found with name %s", name)
+	}
+
+	return nil
+}
+
 func (s *State) ReadSchema(ctx context.Context, schemaName string) (*schema.Schema, error) {
 	var rawSchema []byte
 	err := s.pgConn.QueryRowContext(ctx, fmt.Sprintf("SELECT %s.read_schema($1)", s.schema), schemaName).Scan(&rawSchema)
@@ -527,9 +1053,116 @@ func (s *State) ReadSchema(ctx context.Context, schemaName string) (*schema.Sche
 	return &sc, nil
 }
 
+// ReadTables reads the schema of the given tables of a schema, or of all of
+// its tables if tables is nil. The returned schema only holds the tables that
+// exist.
+func (s *State) ReadTables(ctx context.Context, schemaName string, tables []string) (*schema.Schema, error) {
+	var rawTables []byte
+	err := s.pgConn.QueryRowContext(ctx, fmt.Sprintf("SELECT %s.read_schema_tables($1, $2)", pq.QuoteIdentifier(s.schema)),
+		schemaName, pq.Array(tables)).Scan(&rawTables)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := schema.New()
+	sc.Name = schemaName
+	if rawTables == nil {
+		return sc, nil
+	}
+
+	err = json.Unmarshal(rawTables, &sc.Tables)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal schema: %w", err)
+	}
+
+	return sc, nil
+}
+
+// readObjects reads the types, sequences and views of a schema
+func (s *State) readObjects(ctx context.Context, schemaName string) (*schema.Schema, error) {
+	var rawObjects []byte
+	err := s.pgConn.QueryRowContext(ctx, fmt.Sprintf("SELECT %s.read_schema_objects($1)", pq.QuoteIdentifier(s.schema)),
+		schemaName).Scan(&rawObjects)
+	if err != nil {
+		return nil, err
+	}
+
+	var sc schema.Schema
+	err = json.Unmarshal(rawObjects, &sc)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal schema: %w", err)
+	}
+
+	return &sc, nil
+}
+
+// Drift compares the schema recorded by the latest migration in the history
+// of the given schema with the live schema in the database, and returns the
+// differences between them. Any difference means that the schema was changed
+// without the change being recorded in the history.
+// It returns `ErrActiveMigration` if a migration is in progress, as the live
+// schema is expected to differ from the history until it is completed.
+func (s *State) Drift(ctx context.Context, schemaName string) ([]schema.Difference, error) {
+	isActive, err := s.IsActiveMigrationPeriod(ctx, schemaName)
+	if err != nil {
+		return nil, err
+	}
+	if isActive {
+		return nil, ErrActiveMigration
+	}
+
+	// if there is no history yet, every object in the schema is unrecorded
+	recorded := schema.New()
+	var rawSchema []byte
+	err = s.pgConn.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT resulting_schema FROM %[1]s.migrations WHERE schema=$1 AND name=%[1]s.latest_version($1)", pq.QuoteIdentifier(s.schema)),
+		schemaName).Scan(&rawSchema)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(rawSchema, recorded); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal schema: %w", err)
+		}
+	}
+
+	live, err := s.ReadSchema(ctx, schemaName)
+	if err != nil {
+		return nil, err
+	}
+
+	// the positions of the columns are logical positions, which are only
+	// recorded in the history
+	live.CarryColumnPositions(recorded)
+
+	return schema.Diff(recorded, live), nil
+}
+
 // Rollback removes a migration from the state (we consider it rolled back, as if it never started)
+// The migration is kept in the history of failed migrations as rolled back.
 func (s *State) Rollback(ctx context.Context, schema, name string) error {
-	res, err := s.pgConn.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s.migrations WHERE schema=$1 AND name=$2 AND done=$3", pq.QuoteIdentifier(s.schema)), schema, name, false)
+	return s.removeMigration(ctx, schema, name, RolledBackHistoryStatus, nil, nil)
+}
+
+// Fail removes a migration that failed in the given phase from the state, as
+// `Rollback` does. The migration is kept in the history of failed migrations,
+// along with the error that caused it to fail.
+func (s *State) Fail(ctx context.Context, schema, name string, phase MigrationPhase, cause error) error {
+	errMsg := cause.Error()
+	return s.removeMigration(ctx, schema, name, FailedHistoryStatus, &phase, &errMsg)
+}
+
+func (s *State) removeMigration(ctx context.Context, schema, name string, status HistoryStatus, phase *MigrationPhase, errMsg *string) error {
+	res, err := s.pgConn.ExecContext(ctx, fmt.Sprintf(`
+		WITH removed AS (
+			DELETE FROM %[1]s.migrations WHERE schema=$1 AND name=$2 AND done=$3
+			RETURNING schema, name, migration, parent, created_at
+		)
+		INSERT INTO %[1]s.failed_migrations (schema, name, migration, parent, status, phase, error, started_at)
+		SELECT schema, name, migration, parent, $4, $5, $6, created_at FROM removed`,
+		pq.QuoteIdentifier(s.schema)),
+		schema, name, false, status, phase, errMsg)
 	if err != nil {
 		return err
 	}
@@ -545,3 +1178,53 @@ func (s *State) Rollback(ctx context.Context, schema, name string) error {
 
 	return nil
 }
+
+// History returns the migrations applied to the given schema, including the
+// active one, ordered by start time. If `all` is true, migrations that failed
+// or were rolled back are included as well.
+func (s *State) History(ctx context.Context, schema string, all bool) ([]HistoryEntry, error) {
+	query := fmt.Sprintf(`
+		SELECT name, migration, migration_type,
+			CASE WHEN done THEN $2 ELSE $3 END, NULL, NULL,
+			created_at, CASE WHEN done THEN updated_at END
+		FROM %[1]s.migrations
+		WHERE schema=$1`, pq.QuoteIdentifier(s.schema))
+	args := []any{schema, CompleteHistoryStatus, InProgressHistoryStatus}
+
+	if all {
+		query += fmt.Sprintf(`
+		UNION ALL
+		SELECT name, migration, 'pgroll', status, phase, error, started_at, ended_at
+		FROM %[1]s.failed_migrations
+		WHERE schema=$1`, pq.QuoteIdentifier(s.schema))
+	}
+	query += " ORDER BY 7"
+
+	rows, err := s.pgConn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []HistoryEntry
+	for rows.Next() {
+		var entry HistoryEntry
+		var rawMigration []byte
+		var phase, errMsg sql.NullString
+
+		err := rows.Scan(&entry.Name, &rawMigration, &entry.MigrationType, &entry.Status, &phase, &errMsg, &entry.StartedAt, &entry.EndedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(rawMigration, &entry.Migration); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal migration %q: %w", entry.Name, err)
+		}
+		entry.Phase = MigrationPhase(phase.String)
+		entry.Error = errMsg.String
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
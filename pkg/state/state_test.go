@@ -114,6 +114,125 @@ func TestInferredMigration(t *testing.T) {
 	})
 }
 
+func TestDrift(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithStateAndConnectionToContainer(t, func(st *state.State, db *sql.DB) {
+		ctx := context.Background()
+
+		// create a table; the change is recorded in the history as an inferred migration
+		if _, err := db.ExecContext(ctx, "CREATE TABLE public.table1 (id int PRIMARY KEY)"); err != nil {
+			t.Fatal(err)
+		}
+
+		diffs, err := st.Drift(ctx, "public")
+		assert.NoError(t, err)
+		assert.Empty(t, diffs)
+
+		// add a column without the change being recorded in the history
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tx.ExecContext(ctx, "SET LOCAL pgroll.internal TO 'FALSE'"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tx.ExecContext(ctx, "ALTER TABLE public.table1 ADD COLUMN name text"); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		diffs, err = st.Drift(ctx, "public")
+		assert.NoError(t, err)
+		assert.Len(t, diffs, 1)
+		assert.Equal(t, schema.AddedDifference, diffs[0].Kind)
+		assert.Equal(t, schema.ColumnObject, diffs[0].Object)
+		assert.Equal(t, "table1", diffs[0].Table)
+		assert.Equal(t, "name", diffs[0].Name)
+	})
+}
+
+func TestCarryColumnPositionsMatchesSchemaCarryColumnPositions(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithStateAndConnectionToContainer(t, func(st *state.State, db *sql.DB) {
+		ctx := context.Background()
+
+		recorded := &schema.Schema{
+			Name: "public",
+			Tables: map[string]schema.Table{
+				"users": {
+					Name: "users",
+					Columns: map[string]schema.Column{
+						"id":    {Name: "id", Position: 1},
+						"email": {Name: "email", Position: 2},
+						"name":  {Name: "name", Position: 3},
+					},
+				},
+			},
+		}
+		live := &schema.Schema{
+			Name: "public",
+			Tables: map[string]schema.Table{
+				"users": {
+					Name: "users",
+					Columns: map[string]schema.Column{
+						"id":    {Name: "id", Position: 1},
+						"name":  {Name: "name", Position: 3},
+						"email": {Name: "email", Position: 4},
+						"zip":   {Name: "zip", Position: 6},
+						"age":   {Name: "age", Position: 5},
+					},
+				},
+				"posts": {
+					Name: "posts",
+					Columns: map[string]schema.Column{
+						"title": {Name: "title", Position: 2},
+					},
+				},
+			},
+		}
+
+		var rawSchema []byte
+		err := db.QueryRowContext(ctx, "SELECT pgroll.carry_column_positions($1, $2)", live, recorded).Scan(&rawSchema)
+		assert.NoError(t, err)
+
+		var got schema.Schema
+		if err := json.Unmarshal(rawSchema, &got); err != nil {
+			t.Fatal(err)
+		}
+
+		live.CarryColumnPositions(recorded)
+		assert.Equal(t, live, &got)
+	})
+}
+
+func TestIdentifierMatchesMigrationsIdentifier(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithStateAndConnectionToContainer(t, func(st *state.State, db *sql.DB) {
+		ctx := context.Background()
+
+		names := []string{
+			"name",
+			strings.Repeat("a", 51),
+			strings.Repeat("a", 52),
+			strings.Repeat("a", 100),
+			strings.Repeat("é", 40),
+			"table_" + strings.Repeat("名", 30),
+		}
+
+		for _, name := range names {
+			var id string
+			err := db.QueryRowContext(ctx, "SELECT pgroll.identifier($1, $2)", "_pgroll_new_", name).Scan(&id)
+			assert.NoError(t, err)
+			assert.Equal(t, migrations.Identifier("_pgroll_new_", name), id)
+		}
+	})
+}
+
 func TestReadSchema(t *testing.T) {
 	t.Parallel()
 
@@ -136,6 +255,7 @@ func TestReadSchema(t *testing.T) {
 							Columns: map[string]schema.Column{
 								"id": {
 									Name:     "id",
+									Position: 1,
 									Type:     "integer",
 									Nullable: true,
 								},
@@ -155,6 +275,7 @@ func TestReadSchema(t *testing.T) {
 							Columns: map[string]schema.Column{
 								"id": {
 									Name:     "id",
+									Position: 1,
 									Type:     "integer",
 									Nullable: false,
 									Unique:   true,
@@ -162,9 +283,11 @@ func TestReadSchema(t *testing.T) {
 							},
 							Indexes: map[string]schema.Index{
 								"id_unique": {
-									Name:    "id_unique",
-									Unique:  true,
-									Columns: []string{"id"},
+									Name:       "id_unique",
+									Unique:     true,
+									Columns:    []string{"id"},
+									Method:     "btree",
+									Definition: "CREATE UNIQUE INDEX id_unique ON public.table1 USING btree (id)",
 								},
 							},
 							UniqueConstraints: map[string]schema.UniqueConstraint{
@@ -188,20 +311,24 @@ func TestReadSchema(t *testing.T) {
 							Columns: map[string]schema.Column{
 								"id": {
 									Name:     "id",
+									Position: 1,
 									Type:     "integer",
 									Nullable: true,
 								},
 								"name": {
 									Name:     "name",
+									Position: 2,
 									Type:     "text",
 									Nullable: true,
 								},
 							},
 							Indexes: map[string]schema.Index{
 								"idx_name": {
-									Name:    "idx_name",
-									Unique:  false,
-									Columns: []string{"name"},
+									Name:       "idx_name",
+									Unique:     false,
+									Columns:    []string{"name"},
+									Method:     "btree",
+									Definition: "CREATE INDEX idx_name ON public.table1 USING btree (name)",
 								},
 							},
 						},
@@ -219,6 +346,7 @@ func TestReadSchema(t *testing.T) {
 							Columns: map[string]schema.Column{
 								"id": {
 									Name:     "id",
+									Position: 1,
 									Type:     "integer",
 									Nullable: false,
 									Unique:   true,
@@ -227,9 +355,11 @@ func TestReadSchema(t *testing.T) {
 							PrimaryKey: []string{"id"},
 							Indexes: map[string]schema.Index{
 								"table1_pkey": {
-									Name:    "table1_pkey",
-									Unique:  true,
-									Columns: []string{"id"},
+									Name:       "table1_pkey",
+									Unique:     true,
+									Columns:    []string{"id"},
+									Method:     "btree",
+									Definition: "CREATE UNIQUE INDEX table1_pkey ON public.table1 USING btree (id)",
 								},
 							},
 						},
@@ -238,6 +368,7 @@ func TestReadSchema(t *testing.T) {
 							Columns: map[string]schema.Column{
 								"fk": {
 									Name:     "fk",
+									Position: 1,
 									Type:     "integer",
 									Nullable: false,
 								},
@@ -248,6 +379,9 @@ func TestReadSchema(t *testing.T) {
 									Columns:           []string{"fk"},
 									ReferencedTable:   "table1",
 									ReferencedColumns: []string{"id"},
+									OnDelete:          "NO ACTION",
+									OnUpdate:          "NO ACTION",
+									Definition:        "FOREIGN KEY (fk) REFERENCES table1(id)",
 								},
 							},
 						},
@@ -265,12 +399,14 @@ func TestReadSchema(t *testing.T) {
 							Columns: map[string]schema.Column{
 								"id": {
 									Name:     "id",
+									Position: 1,
 									Type:     "integer",
 									Nullable: false,
 									Unique:   true,
 								},
 								"age": {
 									Name:     "age",
+									Position: 2,
 									Type:     "integer",
 									Nullable: true,
 								},
@@ -278,9 +414,11 @@ func TestReadSchema(t *testing.T) {
 							PrimaryKey: []string{"id"},
 							Indexes: map[string]schema.Index{
 								"table1_pkey": {
-									Name:    "table1_pkey",
-									Unique:  true,
-									Columns: []string{"id"},
+									Name:       "table1_pkey",
+									Unique:     true,
+									Columns:    []string{"id"},
+									Method:     "btree",
+									Definition: "CREATE UNIQUE INDEX table1_pkey ON public.table1 USING btree (id)",
 								},
 							},
 							CheckConstraints: map[string]schema.CheckConstraint{
@@ -305,12 +443,14 @@ func TestReadSchema(t *testing.T) {
 							Columns: map[string]schema.Column{
 								"id": {
 									Name:     "id",
+									Position: 1,
 									Type:     "integer",
 									Nullable: false,
 									Unique:   true,
 								},
 								"name": {
 									Name:     "name",
+									Position: 2,
 									Type:     "text",
 									Unique:   true,
 									Nullable: true,
@@ -319,14 +459,18 @@ func TestReadSchema(t *testing.T) {
 							PrimaryKey: []string{"id"},
 							Indexes: map[string]schema.Index{
 								"table1_pkey": {
-									Name:    "table1_pkey",
-									Unique:  true,
-									Columns: []string{"id"},
+									Name:       "table1_pkey",
+									Unique:     true,
+									Columns:    []string{"id"},
+									Method:     "btree",
+									Definition: "CREATE UNIQUE INDEX table1_pkey ON public.table1 USING btree (id)",
 								},
 								"name_unique": {
-									Name:    "name_unique",
-									Unique:  true,
-									Columns: []string{"name"},
+									Name:       "name_unique",
+									Unique:     true,
+									Columns:    []string{"name"},
+									Method:     "btree",
+									Definition: "CREATE UNIQUE INDEX name_unique ON public.table1 USING btree (name)",
 								},
 							},
 							UniqueConstraints: map[string]schema.UniqueConstraint{
@@ -350,22 +494,403 @@ func TestReadSchema(t *testing.T) {
 							Columns: map[string]schema.Column{
 								"a": {
 									Name:     "a",
+									Position: 1,
 									Type:     "text",
 									Nullable: true,
 								},
 								"b": {
 									Name:     "b",
+									Position: 2,
 									Type:     "text",
 									Nullable: true,
 								},
 							},
 							Indexes: map[string]schema.Index{
 								"idx_ab": {
-									Name:    "idx_ab",
-									Unique:  false,
-									Columns: []string{"a", "b"},
+									Name:       "idx_ab",
+									Unique:     false,
+									Columns:    []string{"a", "b"},
+									Method:     "btree",
+									Definition: "CREATE INDEX idx_ab ON public.table1 USING btree (a, b)",
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				name:       "multi-column foreign key",
+				createStmt: "CREATE TABLE public.table1 (a int, b int, PRIMARY KEY (a, b)); CREATE TABLE public.table2 (x int, y int, CONSTRAINT xy_fkey FOREIGN KEY (y, x) REFERENCES public.table1 (a, b))",
+				wantSchema: &schema.Schema{
+					Name: "public",
+					Tables: map[string]schema.Table{
+						"table1": {
+							Name: "table1",
+							Columns: map[string]schema.Column{
+								"a": {
+									Name:     "a",
+									Position: 1,
+									Type:     "integer",
+									Nullable: false,
+									Unique:   true,
+								},
+								"b": {
+									Name:     "b",
+									Position: 2,
+									Type:     "integer",
+									Nullable: false,
+									Unique:   true,
+								},
+							},
+							PrimaryKey: []string{"a", "b"},
+							Indexes: map[string]schema.Index{
+								"table1_pkey": {
+									Name:       "table1_pkey",
+									Unique:     true,
+									Columns:    []string{"a", "b"},
+									Method:     "btree",
+									Definition: "CREATE UNIQUE INDEX table1_pkey ON public.table1 USING btree (a, b)",
+								},
+							},
+						},
+						"table2": {
+							Name: "table2",
+							Columns: map[string]schema.Column{
+								"x": {
+									Name:     "x",
+									Position: 1,
+									Type:     "integer",
+									Nullable: true,
+								},
+								"y": {
+									Name:     "y",
+									Position: 2,
+									Type:     "integer",
+									Nullable: true,
+								},
+							},
+							ForeignKeys: map[string]schema.ForeignKey{
+								"xy_fkey": {
+									Name:              "xy_fkey",
+									Columns:           []string{"y", "x"},
+									ReferencedTable:   "table1",
+									ReferencedColumns: []string{"a", "b"},
+									OnDelete:          "NO ACTION",
+									OnUpdate:          "NO ACTION",
+									Definition:        "FOREIGN KEY (y, x) REFERENCES table1(a, b)",
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				name: "index and foreign key details",
+				createStmt: `CREATE TABLE public.table1 (id int PRIMARY KEY, name text, tags text[], created timestamptz,
+						parent int CONSTRAINT parent_fkey REFERENCES public.table1 (id) ON DELETE SET NULL DEFERRABLE);
+					CREATE INDEX idx_partial ON public.table1 (name) WHERE name IS NOT NULL;
+					CREATE INDEX idx_lower ON public.table1 (lower(name));
+					CREATE INDEX idx_tags ON public.table1 USING gin (tags);
+					CREATE INDEX idx_created ON public.table1 (created DESC, id NULLS FIRST) INCLUDE (name);`,
+				wantSchema: &schema.Schema{
+					Name: "public",
+					Tables: map[string]schema.Table{
+						"table1": {
+							Name: "table1",
+							Columns: map[string]schema.Column{
+								"id": {
+									Name:     "id",
+									Position: 1,
+									Type:     "integer",
+									Nullable: false,
+									Unique:   true,
+								},
+								"name": {
+									Name:     "name",
+									Position: 2,
+									Type:     "text",
+									Nullable: true,
+								},
+								"tags": {
+									Name:     "tags",
+									Position: 3,
+									Type:     "text[]",
+									Nullable: true,
+								},
+								"created": {
+									Name:     "created",
+									Position: 4,
+									Type:     "timestamptz",
+									Nullable: true,
+								},
+								"parent": {
+									Name:     "parent",
+									Position: 5,
+									Type:     "integer",
+									Nullable: true,
+								},
+							},
+							PrimaryKey: []string{"id"},
+							Indexes: map[string]schema.Index{
+								"table1_pkey": {
+									Name:       "table1_pkey",
+									Unique:     true,
+									Columns:    []string{"id"},
+									Method:     "btree",
+									Definition: "CREATE UNIQUE INDEX table1_pkey ON public.table1 USING btree (id)",
+								},
+								"idx_partial": {
+									Name:       "idx_partial",
+									Columns:    []string{"name"},
+									Predicate:  ptr("(name IS NOT NULL)"),
+									Method:     "btree",
+									Definition: "CREATE INDEX idx_partial ON public.table1 USING btree (name) WHERE (name IS NOT NULL)",
+								},
+								"idx_lower": {
+									Name:       "idx_lower",
+									Columns:    []string{"lower(name)"},
+									Method:     "btree",
+									Definition: "CREATE INDEX idx_lower ON public.table1 USING btree (lower(name))",
+								},
+								"idx_tags": {
+									Name:       "idx_tags",
+									Columns:    []string{"tags"},
+									Method:     "gin",
+									Definition: "CREATE INDEX idx_tags ON public.table1 USING gin (tags)",
+								},
+								"idx_created": {
+									Name:       "idx_created",
+									Columns:    []string{"created", "id"},
+									Method:     "btree",
+									Include:    []string{"name"},
+									SortOrders: []string{"DESC", "ASC NULLS FIRST"},
+									Definition: "CREATE INDEX idx_created ON public.table1 USING btree (created DESC, id NULLS FIRST) INCLUDE (name)",
+								},
+							},
+							ForeignKeys: map[string]schema.ForeignKey{
+								"parent_fkey": {
+									Name:              "parent_fkey",
+									Columns:           []string{"parent"},
+									ReferencedTable:   "table1",
+									ReferencedColumns: []string{"id"},
+									OnDelete:          "SET NULL",
+									OnUpdate:          "NO ACTION",
+									Deferrable:        true,
+									Definition:        "FOREIGN KEY (parent) REFERENCES table1(id) ON DELETE SET NULL DEFERRABLE",
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				name: "column attributes",
+				createStmt: `CREATE TABLE public.table1 (id int, name text COLLATE "C");
+					ALTER TABLE public.table1 ALTER COLUMN name SET STORAGE EXTERNAL, ALTER COLUMN name SET STATISTICS 500;
+					GRANT SELECT (name), UPDATE (name) ON public.table1 TO PUBLIC;`,
+				wantSchema: &schema.Schema{
+					Name: "public",
+					Tables: map[string]schema.Table{
+						"table1": {
+							Name: "table1",
+							Columns: map[string]schema.Column{
+								"id": {
+									Name:     "id",
+									Position: 1,
+									Type:     "integer",
+									Nullable: true,
+								},
+								"name": {
+									Name:             "name",
+									Position:         2,
+									Type:             "text",
+									Nullable:         true,
+									Collation:        `"C"`,
+									Storage:          "EXTERNAL",
+									StatisticsTarget: ptr(500),
+									Privileges: []schema.ColumnPrivilege{
+										{Grantee: "PUBLIC", Privilege: "SELECT"},
+										{Grantee: "PUBLIC", Privilege: "UPDATE"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			{
+				name:       "enum, domain and composite types",
+				createStmt: "CREATE TYPE public.mood AS ENUM ('sad', 'ok', 'happy'); CREATE DOMAIN public.age AS int NOT NULL DEFAULT 18 CHECK (VALUE >= 0); CREATE TYPE public.point2d AS (x float8, y float8); CREATE TABLE public.table1 (m public.mood)",
+				wantSchema: &schema.Schema{
+					Name: "public",
+					Tables: map[string]schema.Table{
+						"table1": {
+							Name: "table1",
+							Columns: map[string]schema.Column{
+								"m": {
+									Name:     "m",
+									Position: 1,
+									Type:     "mood",
+									Nullable: true,
+								},
+							},
+						},
+					},
+					Types: map[string]schema.Type{
+						"mood": {
+							Name:   "mood",
+							Kind:   schema.EnumType,
+							Values: []string{"sad", "ok", "happy"},
+						},
+						"age": {
+							Name:     "age",
+							Kind:     schema.DomainType,
+							BaseType: "integer",
+							NotNull:  true,
+							Default:  ptr("18"),
+							Checks:   []string{"CHECK ((VALUE >= 0))"},
+						},
+						"point2d": {
+							Name: "point2d",
+							Kind: schema.CompositeType,
+							Attributes: []schema.TypeAttribute{
+								{Name: "x", Type: "double precision"},
+								{Name: "y", Type: "double precision"},
+							},
+						},
+					},
+				},
+			},
+			{
+				name:       "serial, identity and generated columns",
+				createStmt: "CREATE TABLE public.table1 (id serial, code int GENERATED ALWAYS AS IDENTITY, twice int GENERATED ALWAYS AS (id * 2) STORED)",
+				wantSchema: &schema.Schema{
+					Name: "public",
+					Tables: map[string]schema.Table{
+						"table1": {
+							Name: "table1",
+							Columns: map[string]schema.Column{
+								"id": {
+									Name:     "id",
+									Position: 1,
+									Type:     "integer",
+									Default:  ptr("nextval('table1_id_seq'::regclass)"),
+									Nullable: false,
+								},
+								"code": {
+									Name:     "code",
+									Position: 2,
+									Type:     "integer",
+									Nullable: false,
+									Identity: "always",
+								},
+								"twice": {
+									Name:      "twice",
+									Position:  3,
+									Type:      "integer",
+									Nullable:  true,
+									Generated: ptr("(id * 2)"),
+								},
+							},
+						},
+					},
+					Sequences: map[string]schema.Sequence{
+						"table1_id_seq": {
+							Name:        "table1_id_seq",
+							Type:        "integer",
+							Start:       1,
+							Increment:   1,
+							Min:         1,
+							Max:         2147483647,
+							OwnerTable:  "table1",
+							OwnerColumn: "id",
+						},
+						"table1_code_seq": {
+							Name:        "table1_code_seq",
+							Type:        "integer",
+							Start:       1,
+							Increment:   1,
+							Min:         1,
+							Max:         2147483647,
+							OwnerTable:  "table1",
+							OwnerColumn: "code",
+						},
+					},
+				},
+			},
+			{
+				name:       "views and materialized views",
+				createStmt: "CREATE TABLE public.table1 (id int); CREATE VIEW public.view1 AS SELECT 1 AS one; CREATE MATERIALIZED VIEW public.view2 AS SELECT 1 AS one",
+				wantSchema: &schema.Schema{
+					Name: "public",
+					Tables: map[string]schema.Table{
+						"table1": {
+							Name: "table1",
+							Columns: map[string]schema.Column{
+								"id": {
+									Name:     "id",
+									Position: 1,
+									Type:     "integer",
+									Nullable: true,
+								},
+							},
+						},
+					},
+					Views: map[string]schema.View{
+						"view1": {
+							Name:       "view1",
+							Definition: " SELECT 1 AS one;",
+						},
+						"view2": {
+							Name:         "view2",
+							Materialized: true,
+							Definition:   " SELECT 1 AS one;",
+						},
+					},
+				},
+			},
+			{
+				name:       "partitioned table",
+				createStmt: "CREATE TABLE public.table1 (id int, created date) PARTITION BY RANGE (created); CREATE TABLE public.table1_2024 PARTITION OF public.table1 FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')",
+				wantSchema: &schema.Schema{
+					Name: "public",
+					Tables: map[string]schema.Table{
+						"table1": {
+							Name: "table1",
+							Columns: map[string]schema.Column{
+								"id": {
+									Name:     "id",
+									Position: 1,
+									Type:     "integer",
+									Nullable: true,
+								},
+								"created": {
+									Name:     "created",
+									Position: 2,
+									Type:     "date",
+									Nullable: true,
 								},
 							},
+							PartitionKey: "RANGE (created)",
+						},
+						"table1_2024": {
+							Name: "table1_2024",
+							Columns: map[string]schema.Column{
+								"id": {
+									Name:     "id",
+									Position: 1,
+									Type:     "integer",
+									Nullable: true,
+								},
+								"created": {
+									Name:     "created",
+									Position: 2,
+									Type:     "date",
+									Nullable: true,
+								},
+							},
+							PartitionOf:    "table1",
+							PartitionBound: "FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')",
 						},
 					},
 				},
@@ -393,3 +918,102 @@ func TestReadSchema(t *testing.T) {
 		}
 	})
 }
+
+func TestReadTables(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithStateAndConnectionToContainer(t, func(st *state.State, db *sql.DB) {
+		ctx := context.Background()
+
+		_, err := db.ExecContext(ctx, "CREATE TABLE public.table1 (id int); CREATE TABLE public.table2 (id int); CREATE TABLE public.table3 (id int)")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// only the existing tables among the given ones are read
+		sc, err := st.ReadTables(ctx, "public", []string{"table1", "table3", "missing"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "public", sc.Name)
+		assert.ElementsMatch(t, []string{"table1", "table3"}, tableNames(sc))
+
+		// no tables are given
+		sc, err = st.ReadTables(ctx, "public", []string{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Empty(t, sc.Tables)
+
+		// all tables are read, as with ReadSchema
+		sc, err = st.ReadTables(ctx, "public", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		full, err := st.ReadSchema(ctx, "public")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, full, sc)
+	})
+}
+
+func TestSchemaCache(t *testing.T) {
+	t.Parallel()
+
+	testutils.WithStateAndConnectionToContainer(t, func(st *state.State, db *sql.DB) {
+		ctx := context.Background()
+
+		_, err := db.ExecContext(ctx, "CREATE TABLE public.table1 (id int); CREATE TABLE public.table2 (id int)")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cache := st.NewSchemaCache("public")
+		sc, err := cache.Read(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.ElementsMatch(t, []string{"table1", "table2"}, tableNames(sc))
+
+		// changes to tables that aren't refreshed are not seen
+		_, err = db.ExecContext(ctx, "ALTER TABLE public.table1 ADD COLUMN name text; ALTER TABLE public.table2 ADD COLUMN name text; DROP TABLE public.table2; CREATE TABLE public.table3 (id int)")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sc, err = cache.Refresh(ctx, []string{"table1", "table2"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.ElementsMatch(t, []string{"table1"}, tableNames(sc))
+		assert.NotNil(t, sc.GetTable("table1").GetColumn("name"))
+
+		// a full refresh reads every table
+		sc, err = cache.Refresh(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.ElementsMatch(t, []string{"table1", "table3"}, tableNames(sc))
+
+		// a cache of an already read schema only reads the refreshed tables
+		sc = schema.New()
+		sc.Name = "public"
+		sc.AddTable("virtual", schema.Table{Name: "virtual"})
+		sc, err = st.NewSchemaCacheFrom(sc).Refresh(ctx, []string{"table3"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.ElementsMatch(t, []string{"virtual", "table3"}, tableNames(sc))
+	})
+}
+
+func ptr[T any](x T) *T { return &x }
+
+func tableNames(sc *schema.Schema) []string {
+	names := make([]string, 0, len(sc.Tables))
+	for name := range sc.Tables {
+		names = append(names, name)
+	}
+	return names
+}
@@ -75,7 +75,7 @@ func SharedTestMain(m *testing.M) {
 	os.Exit(exitCode)
 }
 
-func WithStateAndConnectionToContainer(t *testing.T, fn func(*state.State, *sql.DB)) {
+func WithStateAndConnectionToContainer(t testing.TB, fn func(*state.State, *sql.DB)) {
 	t.Helper()
 	ctx := context.Background()
 
@@ -129,7 +129,7 @@ func WithStateAndConnectionToContainer(t *testing.T, fn func(*state.State, *sql.
 	fn(st, db)
 }
 
-func WithMigratorInSchemaAndConnectionToContainerWithOptions(t *testing.T, schema string, opts []roll.Option, fn func(mig *roll.Roll, db *sql.DB)) {
+func WithMigratorInSchemaAndConnectionToContainerWithOptions(t testing.TB, schema string, opts []roll.Option, fn func(mig *roll.Roll, db *sql.DB)) {
 	t.Helper()
 	ctx := context.Background()
 
@@ -209,10 +209,10 @@ func WithMigratorInSchemaAndConnectionToContainerWithOptions(t *testing.T, schem
 	fn(mig, db)
 }
 
-func WithMigratorInSchemaAndConnectionToContainer(t *testing.T, schema string, fn func(mig *roll.Roll, db *sql.DB)) {
+func WithMigratorInSchemaAndConnectionToContainer(t testing.TB, schema string, fn func(mig *roll.Roll, db *sql.DB)) {
 	WithMigratorInSchemaAndConnectionToContainerWithOptions(t, schema, []roll.Option{roll.WithLockTimeoutMs(500)}, fn)
 }
 
-func WithMigratorAndConnectionToContainer(t *testing.T, fn func(mig *roll.Roll, db *sql.DB)) {
+func WithMigratorAndConnectionToContainer(t testing.TB, fn func(mig *roll.Roll, db *sql.DB)) {
 	WithMigratorInSchemaAndConnectionToContainerWithOptions(t, "public", []roll.Option{roll.WithLockTimeoutMs(500)}, fn)
 }